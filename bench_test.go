@@ -1,14 +1,21 @@
 package gendoc_test
 
 import (
+	"fmt"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin_go "github.com/golang/protobuf/protoc-gen-go/plugin"
 	. "github.com/pseudomuto/protoc-gen-doc"
+	"github.com/pseudomuto/protokit"
 	"github.com/pseudomuto/protokit/utils"
+	"github.com/stretchr/testify/require"
 )
 
 func BenchmarkParseCodeRequest(b *testing.B) {
-	set, _ := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(b, err)
 	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto")
 	plugin := new(Plugin)
 
@@ -16,3 +23,83 @@ func BenchmarkParseCodeRequest(b *testing.B) {
 		plugin.Generate(req)
 	}
 }
+
+func BenchmarkNewTemplate(b *testing.B) {
+	descs := protokit.ParseCodeGenRequest(syntheticRequest(200, 20, 10))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewTemplate(descs)
+	}
+}
+
+// syntheticRequest generates a request for the given number of files, each in its own package with the given number
+// of messages (every one with a nested enum and a map field), fields per message and a service.
+func syntheticRequest(files, messages, fields int) *plugin_go.CodeGeneratorRequest {
+	req := new(plugin_go.CodeGeneratorRequest)
+	for f := 0; f < files; f++ {
+		pkg := fmt.Sprintf("bench.pkg%d", f)
+		fd := &descriptor.FileDescriptorProto{
+			Name:    proto.String(fmt.Sprintf("bench/file%d.proto", f)),
+			Package: proto.String(pkg),
+			Syntax:  proto.String("proto3"),
+		}
+		service := &descriptor.ServiceDescriptorProto{Name: proto.String("Service")}
+
+		for m := 0; m < messages; m++ {
+			name := fmt.Sprintf("Message%d", m)
+			msg := &descriptor.DescriptorProto{
+				Name: proto.String(name),
+				EnumType: []*descriptor.EnumDescriptorProto{{
+					Name: proto.String("Kind"),
+					Value: []*descriptor.EnumValueDescriptorProto{
+						{Name: proto.String(fmt.Sprintf("M%d_KIND_UNSPECIFIED", m)), Number: proto.Int32(0)},
+						{Name: proto.String(fmt.Sprintf("M%d_KIND_OTHER", m)), Number: proto.Int32(1)},
+					},
+				}},
+				NestedType: []*descriptor.DescriptorProto{{
+					Name:    proto.String("LabelsEntry"),
+					Options: &descriptor.MessageOptions{MapEntry: proto.Bool(true)},
+					Field: []*descriptor.FieldDescriptorProto{
+						{Name: proto.String("key"), Number: proto.Int32(1), Label: descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Type: descriptor.FieldDescriptorProto_TYPE_STRING.Enum()},
+						{Name: proto.String("value"), Number: proto.Int32(2), Label: descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Type: descriptor.FieldDescriptorProto_TYPE_STRING.Enum()},
+					},
+				}},
+			}
+			for i := 0; i < fields; i++ {
+				msg.Field = append(msg.Field, &descriptor.FieldDescriptorProto{
+					Name:   proto.String(fmt.Sprintf("field%d", i)),
+					Number: proto.Int32(int32(i + 1)),
+					Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:   descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+				})
+			}
+			msg.Field = append(msg.Field, &descriptor.FieldDescriptorProto{
+				Name:     proto.String("labels"),
+				Number:   proto.Int32(int32(fields + 1)),
+				Label:    descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+				Type:     descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+				TypeName: proto.String(fmt.Sprintf(".%s.%s.LabelsEntry", pkg, name)),
+			}, &descriptor.FieldDescriptorProto{
+				Name:     proto.String("kind"),
+				Number:   proto.Int32(int32(fields + 2)),
+				Label:    descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptor.FieldDescriptorProto_TYPE_ENUM.Enum(),
+				TypeName: proto.String(fmt.Sprintf(".%s.%s.Kind", pkg, name)),
+			})
+			fd.MessageType = append(fd.MessageType, msg)
+
+			service.Method = append(service.Method, &descriptor.MethodDescriptorProto{
+				Name:       proto.String(fmt.Sprintf("Call%d", m)),
+				InputType:  proto.String(fmt.Sprintf(".%s.%s", pkg, name)),
+				OutputType: proto.String(fmt.Sprintf(".%s.%s", pkg, name)),
+			})
+		}
+		fd.Service = append(fd.Service, service)
+
+		req.ProtoFile = append(req.ProtoFile, fd)
+		req.FileToGenerate = append(req.FileToGenerate, fd.GetName())
+	}
+	return req
+}
@@ -0,0 +1,147 @@
+package gendoc
+
+import (
+	"fmt"
+	"slices"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// CustomOption is a custom option the Template reads, e.g. the category of types. Each is read from the option named
+// `docs.<custom option>` unless WithOptionName says otherwise, e.g. `docs.category` for types annotated with
+// `option (docs.category) = "Billing";`.
+type CustomOption string
+
+const (
+	// CategoryOption is the (string) option categorizing messages, enums and services (see Template.ByCategory).
+	CategoryOption CustomOption = "category"
+	// OrderOption is the (integer) option pinning messages, enums, services and fields. Entities with the option come
+	// first, by increasing value, then the others in their usual order: by name for messages, enums and services, by
+	// declaration for fields. Ties keep the usual order as well.
+	OrderOption CustomOption = "order"
+	// ReplacedByOption is the (string) option naming the successor of a deprecated message, field, enum value or method
+	// (see Message.ReplacedBy).
+	ReplacedByOption CustomOption = "replaced_by"
+	// VisibilityOption is the (enum or string) option setting the visibility of fields (see WithVisibility).
+	VisibilityOption CustomOption = "visibility"
+	// ExampleOption is the (string) option giving an example value of fields in protojson form, e.g.
+	// `[(docs.example) = "42"]` (see MessageField.Example).
+	ExampleOption CustomOption = "example"
+	// FormatOption is the (string) option giving the format of the values of fields, e.g. `RFC3339` for a timestamp
+	// string (see MessageField.Format).
+	FormatOption CustomOption = "format"
+	// UnitOption is the (string) option giving the unit of the values of fields, e.g. `bytes` or `ms` (see
+	// MessageField.Unit).
+	UnitOption CustomOption = "unit"
+	// TagsOption is the (string) option tagging methods with a comma-separated list of tags, e.g.
+	// `option (docs.tags) = "admin,beta";` (see ServiceMethod.Tags).
+	TagsOption CustomOption = "tags"
+)
+
+var customOptions = []CustomOption{
+	CategoryOption, OrderOption, ReplacedByOption, VisibilityOption, ExampleOption, FormatOption, UnitOption, TagsOption,
+}
+
+// NewCustomOption returns the CustomOption with the given name.
+func NewCustomOption(name string) (CustomOption, error) {
+	if option := CustomOption(name); slices.Contains(customOptions, option) {
+		return option, nil
+	}
+	return "", fmt.Errorf("Invalid custom option: %s", name)
+}
+
+// DefaultName returns the name of the option read as the custom option unless WithOptionName says otherwise.
+func (o CustomOption) DefaultName() string { return "docs." + string(o) }
+
+// WithOptionName sets the name of the option read as the given custom option, e.g. `acme.docs.category` for types
+// annotated with `option (acme.docs.category) = "Billing";`. Defaults to its DefaultName.
+func WithOptionName(option CustomOption, name string) TemplateOption {
+	return func(t *Template) {
+		if t.optionNames == nil {
+			t.optionNames = make(map[CustomOption]string)
+		}
+		t.optionNames[option] = name
+	}
+}
+
+// customOption returns the value of the custom option among the options, if it's set.
+func (t *Template) customOption(options map[string]interface{}, option CustomOption) interface{} {
+	name, ok := t.optionNames[option]
+	if !ok {
+		name = option.DefaultName()
+	}
+	return options[name]
+}
+
+// dedicatedOptions are the custom options documented by fields of their own (e.g. ServiceMethod.HTTPRules or
+// MessageField.FieldBehaviors), which read them from the unknown fields of the options. They aren't resolved, so that
+// they aren't listed among the other options as well.
+var dedicatedOptions = map[protoreflect.FullName]bool{
+	"buf.validate.oneof":        true,
+	"google.api.field_behavior": true,
+	"google.api.http":           true,
+	"grpc.gateway.protoc_gen_openapiv2.options.openapiv2_operation": true,
+	"grpc.gateway.protoc_gen_openapiv2.options.openapiv2_tag":       true,
+}
+
+// optionResolver resolves custom options, e.g. `option (docs.category) = "Billing";`, with the extensions declared by
+// the files of the request. Extensions that aren't registered in the running binary (which is the case of those of
+// users with the plugin) are parsed as unknown fields, so they don't show up in Options otherwise.
+type optionResolver struct {
+	types *protoregistry.Types
+}
+
+// newOptionResolver returns a resolver for the extensions declared by the given files. Files they depend on may be
+// missing, e.g. google/protobuf/descriptor.proto.
+func newOptionResolver(files []*descriptorpb.FileDescriptorProto) *optionResolver {
+	reg, err := protodesc.FileOptions{AllowUnresolvable: true}.NewFiles(&descriptorpb.FileDescriptorSet{File: files})
+	if err != nil {
+		return nil
+	}
+
+	r := &optionResolver{types: new(protoregistry.Types)}
+	reg.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		r.register(fd.Extensions())
+		r.registerNested(fd.Messages())
+		return true
+	})
+	return r
+}
+
+func (r *optionResolver) registerNested(msgs protoreflect.MessageDescriptors) {
+	for i := 0; i < msgs.Len(); i++ {
+		r.register(msgs.Get(i).Extensions())
+		r.registerNested(msgs.Get(i).Messages())
+	}
+}
+
+func (r *optionResolver) register(exts protoreflect.ExtensionDescriptors) {
+	for i := 0; i < exts.Len(); i++ {
+		if ext := exts.Get(i); !dedicatedOptions[ext.FullName()] {
+			// conflicting declarations keep the first one
+			_ = r.types.RegisterExtension(dynamicpb.NewExtensionType(ext))
+		}
+	}
+}
+
+// resolve returns a copy of the options with the unknown fields the resolver knows the extensions of parsed as such,
+// or the options themselves when there's nothing to resolve. The options are left untouched, as some of their unknown
+// fields are read later on (see dedicatedOptions).
+func (r *optionResolver) resolve(opts protoreflect.ProtoMessage) protoreflect.ProtoMessage {
+	if r == nil || opts == nil || !opts.ProtoReflect().IsValid() || len(opts.ProtoReflect().GetUnknown()) == 0 {
+		return opts
+	}
+
+	resolved := proto.Clone(opts)
+	resolved.ProtoReflect().SetUnknown(nil)
+	err := proto.UnmarshalOptions{Merge: true, Resolver: r.types}.Unmarshal(opts.ProtoReflect().GetUnknown(), resolved)
+	if err != nil {
+		return opts
+	}
+	return resolved
+}
@@ -0,0 +1,444 @@
+package gendoc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ChangeKind classifies a difference between two versions of an entity.
+type ChangeKind string
+
+const (
+	// ChangeAdded marks entities that only exist in the new version.
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved marks entities that only exist in the old version.
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeModified marks entities whose definition changed, e.g. the type or label of a field, or its deprecation.
+	ChangeModified ChangeKind = "changed"
+	// ChangeDescription marks entities of which only the description changed. These changes are never breaking.
+	ChangeDescription ChangeKind = "description"
+)
+
+// Diff lists the messages, enums and services that differ between two templates, sorted by full name. It's meant to
+// be rendered as a changelog (e.g. release notes).
+type Diff struct {
+	Messages []*TypeDiff `json:"messages"`
+	Enums    []*TypeDiff `json:"enums"`
+	Services []*TypeDiff `json:"services"`
+}
+
+// IsEmpty reports whether the templates are equal, as far as the diff is concerned.
+func (d *Diff) IsEmpty() bool {
+	return len(d.Messages) == 0 && len(d.Enums) == 0 && len(d.Services) == 0
+}
+
+// TypeDiff describes how a message, enum or service changed. Members are the fields (matched by number), enum values
+// (matched by name) or methods (matched by name) that changed. A type is ChangeModified when its own definition or any
+// of its members changed, and ChangeDescription when nothing but descriptions changed. It's Breaking when it was
+// removed or any of its members changed in a breaking way, and Deprecated when it became deprecated.
+type TypeDiff struct {
+	Kind       ChangeKind    `json:"kind"`
+	FullName   string        `json:"fullName"`
+	Package    string        `json:"package"`
+	Breaking   bool          `json:"breaking,omitempty"`
+	Deprecated bool          `json:"deprecated,omitempty"`
+	Details    []string      `json:"details,omitempty"`
+	Members    []*MemberDiff `json:"members,omitempty"`
+}
+
+// MemberDiff describes how a field, enum value or method changed. Details list the changes in a human readable form,
+// e.g. `type: int32 -> int64`. Removals are breaking, like changes of the wire format or the signature: a field
+// number reused by another name, type, label or oneof, an enum value renumbered, or another request or response type.
+type MemberDiff struct {
+	Kind       ChangeKind `json:"kind"`
+	Name       string     `json:"name"`
+	Breaking   bool       `json:"breaking,omitempty"`
+	Deprecated bool       `json:"deprecated,omitempty"`
+	Details    []string   `json:"details,omitempty"`
+}
+
+// DiffTemplates compares the messages, enums and services of two templates. Internal messages (e.g. map entries) are
+// ignored, changes of their key and value types are reported on the map fields instead.
+func DiffTemplates(old, new *Template) *Diff {
+	diff := &Diff{Messages: []*TypeDiff{}, Enums: []*TypeDiff{}, Services: []*TypeDiff{}}
+
+	oldMessages, newMessages := map[string]*Message{}, map[string]*Message{}
+	oldEnums, newEnums := map[string]*Enum{}, map[string]*Enum{}
+	oldServices, newServices := map[string]*Service{}, map[string]*Service{}
+	packages := map[string]string{}
+	collect := func(t *Template, messages map[string]*Message, enums map[string]*Enum, services map[string]*Service) {
+		for _, file := range t.Files {
+			for _, m := range file.VisibleMessages() {
+				messages[m.FullName] = m
+				packages[m.FullName] = file.Package
+			}
+			for _, e := range file.Enums {
+				enums[e.FullName] = e
+				packages[e.FullName] = file.Package
+			}
+			for _, s := range file.Services {
+				services[s.FullName] = s
+				packages[s.FullName] = file.Package
+			}
+		}
+	}
+	collect(old, oldMessages, oldEnums, oldServices)
+	collect(new, newMessages, newEnums, newServices)
+
+	for _, name := range unionKeys(oldMessages, newMessages) {
+		if d := diffMessages(name, oldMessages[name], newMessages[name]); d != nil {
+			d.Package = packages[name]
+			diff.Messages = append(diff.Messages, d)
+		}
+	}
+	for _, name := range unionKeys(oldEnums, newEnums) {
+		if d := diffEnums(name, oldEnums[name], newEnums[name]); d != nil {
+			d.Package = packages[name]
+			diff.Enums = append(diff.Enums, d)
+		}
+	}
+	for _, name := range unionKeys(oldServices, newServices) {
+		if d := diffServices(name, oldServices[name], newServices[name]); d != nil {
+			d.Package = packages[name]
+			diff.Services = append(diff.Services, d)
+		}
+	}
+
+	return diff
+}
+
+func diffMessages(name string, old, new *Message) *TypeDiff {
+	if d := addedOrRemoved(name, old != nil, new != nil); d != nil {
+		return d
+	}
+
+	d := &TypeDiff{FullName: name}
+	d.Details = appendChange(d.Details, "deprecated", isDeprecated(old.Options), isDeprecated(new.Options))
+	d.Deprecated = !isDeprecated(old.Options) && isDeprecated(new.Options)
+	described := old.Description != new.Description
+
+	oldFields, newFields := map[string]*MessageField{}, map[string]*MessageField{}
+	for _, f := range old.allFields() {
+		oldFields[fmt.Sprint(f.Index)] = f
+	}
+	for _, f := range new.allFields() {
+		newFields[fmt.Sprint(f.Index)] = f
+	}
+	for _, number := range unionKeysBy(oldFields, newFields, func(f *MessageField) int { return f.Index }) {
+		o, n := oldFields[number], newFields[number]
+		if m := addedOrRemovedMember(o, n, func(f *MessageField) string { return f.Name }); m != nil {
+			d.Members = append(d.Members, m)
+			continue
+		}
+
+		m := &MemberDiff{Name: n.Name}
+		m.Details = appendChange(m.Details, "name", o.Name, n.Name)
+		m.Details = appendChange(m.Details, "type", fieldType(o), fieldType(n))
+		m.Details = appendChange(m.Details, "label", o.Label, n.Label)
+		m.Details = appendChange(m.Details, "oneof", o.OneofDecl, n.OneofDecl)
+		m.Breaking = len(m.Details) > 0
+		m.Details = appendChange(m.Details, "deprecated", isDeprecated(o.Options), isDeprecated(n.Options))
+		m.Deprecated = !isDeprecated(o.Options) && isDeprecated(n.Options)
+		if m = classifyMember(m, o.Description != n.Description); m != nil {
+			d.Members = append(d.Members, m)
+		}
+	}
+
+	return classifyType(d, described)
+}
+
+func diffEnums(name string, old, new *Enum) *TypeDiff {
+	if d := addedOrRemoved(name, old != nil, new != nil); d != nil {
+		return d
+	}
+
+	d := &TypeDiff{FullName: name}
+	d.Details = appendChange(d.Details, "deprecated", isDeprecated(old.Options), isDeprecated(new.Options))
+	d.Deprecated = !isDeprecated(old.Options) && isDeprecated(new.Options)
+	described := old.Description != new.Description
+
+	oldValues, newValues := map[string]*EnumValue{}, map[string]*EnumValue{}
+	for _, v := range old.Values {
+		oldValues[v.Name] = v
+	}
+	for _, v := range new.Values {
+		newValues[v.Name] = v
+	}
+	for _, valueName := range unionKeys(oldValues, newValues) {
+		o, n := oldValues[valueName], newValues[valueName]
+		if m := addedOrRemovedMember(o, n, func(v *EnumValue) string { return v.Name }); m != nil {
+			d.Members = append(d.Members, m)
+			continue
+		}
+
+		m := &MemberDiff{Name: valueName}
+		m.Details = appendChange(m.Details, "number", o.Number, n.Number)
+		m.Breaking = len(m.Details) > 0
+		m.Details = appendChange(m.Details, "deprecated", isDeprecated(o.Options), isDeprecated(n.Options))
+		m.Deprecated = !isDeprecated(o.Options) && isDeprecated(n.Options)
+		if m = classifyMember(m, o.Description != n.Description); m != nil {
+			d.Members = append(d.Members, m)
+		}
+	}
+
+	return classifyType(d, described)
+}
+
+func diffServices(name string, old, new *Service) *TypeDiff {
+	if d := addedOrRemoved(name, old != nil, new != nil); d != nil {
+		return d
+	}
+
+	d := &TypeDiff{FullName: name}
+	d.Details = appendChange(d.Details, "deprecated", isDeprecated(old.Options), isDeprecated(new.Options))
+	d.Deprecated = !isDeprecated(old.Options) && isDeprecated(new.Options)
+	described := old.Description != new.Description
+
+	oldMethods, newMethods := map[string]*ServiceMethod{}, map[string]*ServiceMethod{}
+	for _, m := range old.Methods {
+		oldMethods[m.Name] = m
+	}
+	for _, m := range new.Methods {
+		newMethods[m.Name] = m
+	}
+	for _, methodName := range unionKeys(oldMethods, newMethods) {
+		o, n := oldMethods[methodName], newMethods[methodName]
+		if m := addedOrRemovedMember(o, n, func(m *ServiceMethod) string { return m.Name }); m != nil {
+			d.Members = append(d.Members, m)
+			continue
+		}
+
+		m := &MemberDiff{Name: methodName}
+		m.Details = appendChange(m.Details, "request", methodType(o.RequestFullType, o.RequestStreaming), methodType(n.RequestFullType, n.RequestStreaming))
+		m.Details = appendChange(m.Details, "response", methodType(o.ResponseFullType, o.ResponseStreaming), methodType(n.ResponseFullType, n.ResponseStreaming))
+		m.Breaking = len(m.Details) > 0
+		m.Details = appendChange(m.Details, "deprecated", isDeprecated(o.Options), isDeprecated(n.Options))
+		m.Deprecated = !isDeprecated(o.Options) && isDeprecated(n.Options)
+		if m = classifyMember(m, o.Description != n.Description); m != nil {
+			d.Members = append(d.Members, m)
+		}
+	}
+
+	return classifyType(d, described)
+}
+
+func addedOrRemoved(name string, inOld, inNew bool) *TypeDiff {
+	switch {
+	case !inOld:
+		return &TypeDiff{Kind: ChangeAdded, FullName: name}
+	case !inNew:
+		return &TypeDiff{Kind: ChangeRemoved, FullName: name, Breaking: true}
+	}
+	return nil
+}
+
+func addedOrRemovedMember[T any](old, new *T, name func(*T) string) *MemberDiff {
+	switch {
+	case old == nil:
+		return &MemberDiff{Kind: ChangeAdded, Name: name(new)}
+	case new == nil:
+		return &MemberDiff{Kind: ChangeRemoved, Name: name(old), Breaking: true}
+	}
+	return nil
+}
+
+// classifyMember sets the kind of a member that exists in both versions, or returns nil if it didn't change.
+func classifyMember(m *MemberDiff, described bool) *MemberDiff {
+	switch {
+	case len(m.Details) > 0:
+		m.Kind = ChangeModified
+	case described:
+		m.Kind = ChangeDescription
+	default:
+		return nil
+	}
+	return m
+}
+
+// classifyType sets the kind of a type that exists in both versions, or returns nil if neither the type nor any of its
+// members changed.
+func classifyType(d *TypeDiff, described bool) *TypeDiff {
+	d.Kind = ChangeDescription
+	if len(d.Details) > 0 {
+		d.Kind = ChangeModified
+	}
+	for _, m := range d.Members {
+		if m.Kind != ChangeDescription {
+			d.Kind = ChangeModified
+		}
+		d.Breaking = d.Breaking || m.Breaking
+	}
+	if d.Kind == ChangeDescription && !described && len(d.Members) == 0 {
+		return nil
+	}
+	return d
+}
+
+type diffRenderer struct{}
+
+// Apply renders the changes from the previous version of the files (see WithDiffBase) to the template as a Markdown
+// changelog, e.g. for release notes: the messages, enums and services, fields, enum values and methods that were
+// added, removed, changed or deprecated, by package. Breaking changes are marked as such. Changes of descriptions are
+// left out.
+func (r *diffRenderer) Apply(template *Template) ([]byte, error) {
+	if template.diffBase == nil {
+		return nil, errors.New("The diff output needs the previous version of the files, see WithDiffBase")
+	}
+	return renderChangelog(DiffTemplates(template.diffBase, template)), nil
+}
+
+// changelogSections are the sections of the changelog of each package, in order.
+var changelogSections = []string{"Added", "Removed", "Changed", "Deprecated"}
+
+func renderChangelog(diff *Diff) []byte {
+	entries := map[string]map[string][]string{}
+	add := func(pkg, section, kind, name string, breaking bool, details []string) {
+		entry := fmt.Sprintf("%s `%s`", kind, name)
+		if len(details) > 0 {
+			entry += ": " + strings.Join(details, ", ")
+		}
+		if breaking {
+			entry = "**Breaking:** " + entry
+		}
+		if entries[pkg] == nil {
+			entries[pkg] = map[string][]string{}
+		}
+		entries[pkg][section] = append(entries[pkg][section], entry)
+	}
+	// changed drops the deprecation from the details of entities listed as deprecated
+	changed := func(details []string, deprecated bool) []string {
+		var res []string
+		for _, detail := range details {
+			if !deprecated || !strings.HasPrefix(detail, "deprecated: ") {
+				res = append(res, detail)
+			}
+		}
+		return res
+	}
+	addTypes := func(kind, memberKind string, diffs []*TypeDiff) {
+		for _, d := range diffs {
+			switch d.Kind {
+			case ChangeAdded:
+				add(d.Package, "Added", kind, d.FullName, false, nil)
+			case ChangeRemoved:
+				add(d.Package, "Removed", kind, d.FullName, d.Breaking, nil)
+			}
+			if details := changed(d.Details, d.Deprecated); len(details) > 0 {
+				add(d.Package, "Changed", kind, d.FullName, false, details)
+			}
+			if d.Deprecated {
+				add(d.Package, "Deprecated", kind, d.FullName, false, nil)
+			}
+
+			for _, m := range d.Members {
+				name := d.FullName + "." + m.Name
+				switch m.Kind {
+				case ChangeAdded:
+					add(d.Package, "Added", memberKind, name, false, nil)
+				case ChangeRemoved:
+					add(d.Package, "Removed", memberKind, name, m.Breaking, nil)
+				}
+				if details := changed(m.Details, m.Deprecated); len(details) > 0 {
+					add(d.Package, "Changed", memberKind, name, m.Breaking, details)
+				}
+				if m.Deprecated {
+					add(d.Package, "Deprecated", memberKind, name, false, nil)
+				}
+			}
+		}
+	}
+	addTypes("Message", "Field", diff.Messages)
+	addTypes("Enum", "Value", diff.Enums)
+	addTypes("Service", "Method", diff.Services)
+
+	var out strings.Builder
+	out.WriteString("# API Changes\n")
+	if len(entries) == 0 {
+		out.WriteString("\nNo changes.\n")
+	}
+
+	packages := make([]string, 0, len(entries))
+	for pkg := range entries {
+		packages = append(packages, pkg)
+	}
+	sort.Strings(packages)
+	for _, pkg := range packages {
+		if pkg == "" {
+			out.WriteString("\n## Files without a package\n")
+		} else {
+			fmt.Fprintf(&out, "\n## %s\n", pkg)
+		}
+		for _, section := range changelogSections {
+			if len(entries[pkg][section]) == 0 {
+				continue
+			}
+			fmt.Fprintf(&out, "\n### %s\n\n", section)
+			for _, entry := range entries[pkg][section] {
+				fmt.Fprintf(&out, "- %s\n", entry)
+			}
+		}
+	}
+	return []byte(out.String())
+}
+
+func appendChange[T comparable](details []string, what string, old, new T) []string {
+	if old == new {
+		return details
+	}
+	return append(details, fmt.Sprintf("%s: %s -> %s", what, changeValue(old), changeValue(new)))
+}
+
+func changeValue(v interface{}) string {
+	if s := fmt.Sprint(v); s != "" {
+		return s
+	}
+	return `""`
+}
+
+func isDeprecated(options map[string]interface{}) bool {
+	deprecated, _ := options["deprecated"].(bool)
+	return deprecated
+}
+
+func fieldType(f *MessageField) string {
+	if f.IsMap {
+		return fmt.Sprintf("map<%s, %s>", f.MapKeyType, f.MapValueType)
+	}
+	return f.FullType
+}
+
+func methodType(fullType string, streaming bool) string {
+	if streaming {
+		return "stream " + fullType
+	}
+	return fullType
+}
+
+func unionKeys[T any](old, new map[string]T) []string {
+	keys := make([]string, 0, len(old)+len(new))
+	for k := range old {
+		keys = append(keys, k)
+	}
+	for k := range new {
+		if _, ok := old[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// unionKeysBy is like unionKeys, but sorts the keys by the given int (e.g. field numbers).
+func unionKeysBy[T any](old, new map[string]T, by func(T) int) []string {
+	keys := unionKeys(old, new)
+	value := func(k string) int {
+		if v, ok := new[k]; ok {
+			return by(v)
+		}
+		return by(old[k])
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return value(keys[i]) < value(keys[j]) })
+	return keys
+}
@@ -0,0 +1,209 @@
+package gendoc_test
+
+import (
+	"testing"
+
+	. "github.com/pseudomuto/protoc-gen-doc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffTemplatesWithoutChanges(t *testing.T) {
+	diff := DiffTemplates(template, template)
+	require.True(t, diff.IsEmpty())
+}
+
+func TestDiffTemplates(t *testing.T) {
+	old := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Book"
+			field: { name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32 }
+			field: { name: "title" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "tags" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "isbn" number: 4 label: LABEL_OPTIONAL type: TYPE_STRING }
+		}
+		message_type: { name: "Gone" }
+		enum_type: {
+			name: "Genre"
+			value: { name: "GENRE_UNSPECIFIED" number: 0 }
+			value: { name: "FICTION" number: 1 }
+		}
+		service: {
+			name: "Library"
+			method: { name: "GetBook" input_type: ".test.Book" output_type: ".test.Book" }
+			method: { name: "ListBooks" input_type: ".test.Book" output_type: ".test.Book" }
+		}
+	`)
+	new := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Book"
+			field: { name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT64 }
+			field: { name: "title" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING options: { deprecated: true } }
+			field: { name: "tags" number: 3 label: LABEL_REPEATED type: TYPE_STRING }
+			field: { name: "author" number: 5 label: LABEL_OPTIONAL type: TYPE_STRING }
+		}
+		message_type: { name: "Shelf" }
+		enum_type: {
+			name: "Genre"
+			value: { name: "GENRE_UNSPECIFIED" number: 0 }
+			value: { name: "FICTION" number: 1 }
+			value: { name: "POETRY" number: 2 }
+		}
+		service: {
+			name: "Library"
+			method: { name: "GetBook" input_type: ".test.Book" output_type: ".test.Book" }
+			method: { name: "ListBooks" input_type: ".test.Book" output_type: ".test.Book" server_streaming: true }
+		}
+	`)
+
+	diff := DiffTemplates(old, new)
+	require.False(t, diff.IsEmpty())
+
+	require.Equal(t, []*TypeDiff{
+		{
+			Kind:     ChangeModified,
+			FullName: "test.Book",
+			Package:  "test",
+			Breaking: true,
+			Members: []*MemberDiff{
+				{Kind: ChangeModified, Name: "id", Breaking: true, Details: []string{"type: int32 -> int64"}},
+				{Kind: ChangeModified, Name: "title", Deprecated: true, Details: []string{"deprecated: false -> true"}},
+				{Kind: ChangeModified, Name: "tags", Breaking: true, Details: []string{`label: "" -> repeated`}},
+				{Kind: ChangeRemoved, Name: "isbn", Breaking: true},
+				{Kind: ChangeAdded, Name: "author"},
+			},
+		},
+		{Kind: ChangeRemoved, FullName: "test.Gone", Package: "test", Breaking: true},
+		{Kind: ChangeAdded, FullName: "test.Shelf", Package: "test"},
+	}, diff.Messages)
+
+	require.Equal(t, []*TypeDiff{
+		{
+			Kind:     ChangeModified,
+			FullName: "test.Genre",
+			Package:  "test",
+			Members:  []*MemberDiff{{Kind: ChangeAdded, Name: "POETRY"}},
+		},
+	}, diff.Enums)
+
+	require.Equal(t, []*TypeDiff{
+		{
+			Kind:     ChangeModified,
+			FullName: "test.Library",
+			Package:  "test",
+			Breaking: true,
+			Members: []*MemberDiff{
+				{Kind: ChangeModified, Name: "ListBooks", Breaking: true, Details: []string{"response: test.Book -> stream test.Book"}},
+			},
+		},
+	}, diff.Services)
+}
+
+func TestDiffTemplatesWithDescriptionChanges(t *testing.T) {
+	proto := func(comment string) string {
+		return `
+			name: "api.proto"
+			package: "test"
+			message_type: {
+				name: "Book"
+				field: { name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32 }
+			}
+			source_code_info: {
+				location: { path: [4, 0, 2, 0] span: [1, 0, 1] leading_comments: "` + comment + `" }
+			}
+		`
+	}
+
+	diff := DiffTemplates(newTestTemplate(t, proto("The id.")), newTestTemplate(t, proto("The unique id.")))
+	require.Equal(t, []*TypeDiff{
+		{
+			Kind:     ChangeDescription,
+			FullName: "test.Book",
+			Package:  "test",
+			Members:  []*MemberDiff{{Kind: ChangeDescription, Name: "id"}},
+		},
+	}, diff.Messages)
+	require.Empty(t, diff.Enums)
+	require.Empty(t, diff.Services)
+}
+
+func TestDiffRenderer(t *testing.T) {
+	oldProto := `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Book"
+			field: { name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32 }
+			field: { name: "isbn" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "title" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING }
+		}
+		message_type: { name: "Gone" }
+		service: {
+			name: "Library"
+			method: { name: "GetBook" input_type: ".test.Book" output_type: ".test.Book" }
+		}
+	`
+	old := newTestTemplate(t, oldProto)
+	new := newTestTemplateWithOptions(t, []TemplateOption{WithDiffBase(old)}, `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Book"
+			field: { name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT64 }
+			field: { name: "author" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "title" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING options: { deprecated: true } }
+			field: { name: "subtitle" number: 4 label: LABEL_OPTIONAL type: TYPE_STRING }
+		}
+		service: {
+			name: "Library"
+			method: { name: "GetBook" input_type: ".test.Book" output_type: ".test.Book" }
+			method: { name: "ListBooks" input_type: ".test.Book" output_type: ".test.Book" }
+		}
+	`)
+
+	output, err := RenderTemplate(RenderTypeDiff, new, "")
+	require.NoError(t, err)
+	require.Equal(t, `# API Changes
+
+## test
+
+### Added
+
+- Field `+"`test.Book.subtitle`"+`
+- Method `+"`test.Library.ListBooks`"+`
+
+### Removed
+
+- **Breaking:** Message `+"`test.Gone`"+`
+
+### Changed
+
+- **Breaking:** Field `+"`test.Book.id`"+`: type: int32 -> int64
+- **Breaking:** Field `+"`test.Book.author`"+`: name: isbn -> author
+
+### Deprecated
+
+- Field `+"`test.Book.title`"+`
+`, string(output))
+
+	output, err = RenderTemplate(RenderTypeDiff, newTestTemplateWithOptions(t, []TemplateOption{WithDiffBase(old)}, `
+		name: "api.proto"
+		package: "test"
+	`), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "### Removed\n\n- **Breaking:** Message `test.Book`\n")
+
+	output, err = RenderTemplate(RenderTypeDiff, newTestTemplateWithOptions(t, []TemplateOption{WithDiffBase(old)}, oldProto), "")
+	require.NoError(t, err)
+	require.Equal(t, "# API Changes\n\nNo changes.\n", string(output))
+
+	_, err = RenderTemplate(RenderTypeDiff, old, "")
+	require.Error(t, err)
+}
@@ -0,0 +1,250 @@
+package gendoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pathVariablePattern matches the variables of URL templates, e.g. `{name=shelves/*}` or `{book.id}`.
+var pathVariablePattern = regexp.MustCompile(`\{([^}=]+)(=[^}]*)?\}`)
+
+// maxExampleDepth is the number of message levels expanded by the examples of messages. Deeper messages are left
+// empty.
+const maxExampleDepth = 4
+
+// TextFormatExample returns an example of the message in protobuf text format: every field set to its default value
+// (zero values when there is none), enums to their default value by name, nested messages expanded and repeated
+// fields and maps with a single entry. Only the first field of each oneof is set. Messages nested deeper than a few
+// levels, recursive messages and types that aren't part of the template are left empty.
+func (m Message) TextFormatExample() string {
+	var b strings.Builder
+	writeTextFormat(&b, &m, "", []string{m.FullName})
+	return b.String()
+}
+
+func writeTextFormat(b *strings.Builder, msg *Message, indent string, stack []string) {
+	for _, field := range exampleFields(msg) {
+		if !field.isMessage {
+			b.WriteString(indent + field.Name + ": " + exampleValue(field) + "\n")
+			continue
+		}
+
+		nested := field.messageType
+		if nested == nil || len(stack) >= maxExampleDepth || slices.Contains(stack, nested.FullName) {
+			b.WriteString(indent + field.Name + " {}\n")
+			continue
+		}
+		b.WriteString(indent + field.Name + " {\n")
+		writeTextFormat(b, nested, indent+"  ", append(stack, nested.FullName))
+		b.WriteString(indent + "}\n")
+	}
+}
+
+// exampleFields returns the fields set by the examples of the message, sorted by number. Of the fields of a oneof,
+// only the first one is set.
+func exampleFields(msg *Message) []*MessageField {
+	fields := msg.allFields()
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Index < fields[j].Index })
+
+	oneOfs := map[string]bool{}
+	res := make([]*MessageField, 0, len(fields))
+	for _, field := range fields {
+		if field.IsOneof && !field.proto3Optional {
+			if oneOfs[field.OneofDecl] {
+				continue
+			}
+			oneOfs[field.OneofDecl] = true
+		}
+		res = append(res, field)
+	}
+	return res
+}
+
+// exampleValue returns the text format value of a field that isn't a message: its default value if it has one, the
+// default value of its enum or the zero value of its scalar type otherwise. Enums that aren't part of the template get
+// a zero, their first number.
+func exampleValue(field *MessageField) string {
+	switch {
+	case field.DefaultValue != "" && field.Type == "string":
+		return strconv.Quote(field.DefaultValue)
+	case field.DefaultValue != "" && field.Type == "bytes":
+		// already escaped by normalizeDefault
+		return `"` + field.DefaultValue + `"`
+	case field.DefaultValue != "":
+		return field.DefaultValue
+	case field.enumType != nil:
+		if zero := field.enumType.ZeroValue(); zero != nil {
+			return zero.Name
+		}
+		if len(field.enumType.Values) > 0 {
+			return field.enumType.Values[0].Name
+		}
+	case field.Type == "string" || field.Type == "bytes":
+		return `""`
+	case field.Type == "bool":
+		return "false"
+	}
+	return "0"
+}
+
+// jsonExample returns an example of the message in its protojson form, built like TextFormatExample but with zero
+// values only. Repeated fields have a single element and maps are empty. Fields for which skip returns true are left
+// out of the top-level object.
+func jsonExample(msg *Message, skip func(*MessageField) bool) string {
+	return writeJSONExample(msg, "", []string{msg.FullName}, skip)
+}
+
+func writeJSONExample(msg *Message, indent string, stack []string, skip func(*MessageField) bool) string {
+	entries := make([]string, 0, len(msg.allFields()))
+	for _, field := range exampleFields(msg) {
+		if skip != nil && skip(field) {
+			continue
+		}
+		entries = append(entries, indent+"  "+strconv.Quote(field.JSONName)+": "+jsonFieldExample(field, indent+"  ", stack))
+	}
+	if len(entries) == 0 {
+		return "{}"
+	}
+	return "{\n" + strings.Join(entries, ",\n") + "\n" + indent + "}"
+}
+
+// jsonFieldExample returns the protojson value of a field set to its example (see MessageField.Example), or to its
+// zero value when it has none.
+func jsonFieldExample(field *MessageField, indent string, stack []string) string {
+	if example, ok := jsonOptionExample(field); ok {
+		return example
+	}
+
+	var value string
+	switch nested := field.messageType; {
+	case field.IsMap:
+		return "{}"
+	case !field.isMessage:
+		value = jsonScalarExample(field)
+	case nested == nil || len(stack) >= maxExampleDepth || slices.Contains(stack, nested.FullName):
+		value = "{}"
+	default:
+		value = writeJSONExample(nested, indent, append(stack, nested.FullName), nil)
+	}
+
+	if field.Label == "repeated" {
+		return "[" + value + "]"
+	}
+	return value
+}
+
+// jsonScalarExample returns the protojson zero value of a field that isn't a message. 64-bit integers are strings and
+// enums are named by their default value.
+func jsonScalarExample(field *MessageField) string {
+	switch field.Type {
+	case "string", "bytes":
+		return `""`
+	case "bool":
+		return "false"
+	case "int64", "uint64", "sint64", "fixed64", "sfixed64":
+		return `"0"`
+	}
+	if field.enumType != nil {
+		if zero := field.enumType.ZeroValue(); zero != nil {
+			return strconv.Quote(zero.Name)
+		}
+		if len(field.enumType.Values) > 0 {
+			return strconv.Quote(field.enumType.Values[0].Name)
+		}
+	}
+	return "0"
+}
+
+// jsonOptionExample returns the example of the field (see MessageField.Example) as JSON. Examples that aren't valid
+// JSON, as well as the unquoted examples of strings, bytes and enums, are strings. The example of a repeated field is
+// its single element unless it's an array.
+func jsonOptionExample(field *MessageField) (string, bool) {
+	example := strings.TrimSpace(field.example)
+	if example == "" {
+		return "", false
+	}
+
+	value := example
+	quoted := strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "[")
+	if !json.Valid([]byte(value)) || (!quoted && (field.Type == "string" || field.Type == "bytes" || field.enumType != nil)) {
+		b, _ := json.Marshal(example)
+		value = string(b)
+	}
+	if field.Label == "repeated" && !field.IsMap && !strings.HasPrefix(value, "[") {
+		value = "[" + value + "]"
+	}
+	return value, true
+}
+
+// httpBodyExample returns the JSON skeleton of the body of an HTTP request, if the binding has one. Fields bound to path
+// variables are left out of a `*` body.
+func httpBodyExample(msg *Message, body string, bound map[string]bool) (string, bool) {
+	switch {
+	case body == "":
+		return "", false
+	case msg == nil:
+		return "{}", true
+	case body == "*":
+		return jsonExample(msg, func(field *MessageField) bool { return bound[field.Name] }), true
+	}
+
+	for _, field := range msg.allFields() {
+		if field.Name == body {
+			return jsonFieldExample(field, "", []string{msg.FullName}), true
+		}
+	}
+	return "{}", true
+}
+
+// CurlExample returns a `curl` command calling the first HTTP binding of the method (see HTTPRules) on the server at
+// baseURL, e.g.:
+//
+//	curl -X POST 'https://api.example.com/v1/shelves/name/books' \
+//	  -H 'Content-Type: application/json' \
+//	  -d '{
+//	  "title": ""
+//	}'
+//
+// Path variables are set to the example of the request field they're bound to (see MessageField.Example), or to the
+// name of the field, and the body is the JSON skeleton of the request message (or of the field mapped to the body). It's
+// empty for methods without HTTP bindings.
+func (m ServiceMethod) CurlExample(baseURL string) string {
+	if len(m.HTTPRules) == 0 {
+		return ""
+	}
+	rule := m.HTTPRules[0]
+
+	bound := make(map[string]bool)
+	path := pathVariablePattern.ReplaceAllStringFunc(rule.Path, func(v string) string {
+		match := pathVariablePattern.FindStringSubmatch(v)
+		bound[match[1]] = true
+
+		sample := match[1][strings.LastIndex(match[1], ".")+1:]
+		if m.RequestMessage != nil {
+			if field := m.RequestMessage.fieldNamed(match[1]); field != nil && field.example != "" {
+				sample = strings.Trim(field.example, `"`)
+			}
+		}
+		if pattern := strings.TrimPrefix(match[2], "="); pattern != "" {
+			return strings.NewReplacer("**", sample, "*", sample).Replace(pattern)
+		}
+		return sample
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "curl -X %s %s", rule.Method, shellQuote(strings.TrimSuffix(baseURL, "/")+path))
+	if body, ok := httpBodyExample(m.RequestMessage, rule.Body, bound); ok {
+		b.WriteString(" \\\n  -H 'Content-Type: application/json' \\\n  -d " + shellQuote(body))
+	}
+	return b.String()
+}
+
+// shellQuote quotes s for POSIX shells, in single quotes.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
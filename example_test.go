@@ -0,0 +1,104 @@
+package gendoc_test
+
+import (
+	"testing"
+
+	. "github.com/pseudomuto/protoc-gen-doc"
+	"github.com/pseudomuto/protokit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextFormatExample(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "Book"
+			field: { name: "title" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING default_value: "Untitled \"draft\"" }
+			field: { name: "pages" number: 2 label: LABEL_OPTIONAL type: TYPE_INT32 default_value: "100" }
+			field: { name: "genre" number: 3 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".test.Genre" }
+			field: { name: "tags" number: 4 label: LABEL_REPEATED type: TYPE_STRING }
+			field: { name: "author" number: 5 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Author" }
+			field: { name: "isbn" number: 6 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 }
+			field: { name: "issn" number: 7 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 }
+			field: { name: "ratings" number: 8 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Book.RatingsEntry" }
+			field: { name: "cover" number: 9 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Any" }
+			field: { name: "checksum" number: 10 label: LABEL_OPTIONAL type: TYPE_BYTES default_value: "\\001" }
+			nested_type: {
+				name: "RatingsEntry"
+				field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+				field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_DOUBLE }
+				options: { map_entry: true }
+			}
+			oneof_decl: { name: "id" }
+		}
+		message_type: {
+			name: "Author"
+			field: { name: "name" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "active" number: 2 label: LABEL_OPTIONAL type: TYPE_BOOL }
+			field: { name: "mentor" number: 3 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Author" }
+		}
+		enum_type: {
+			name: "Genre"
+			value: { name: "FICTION" number: 1 }
+			value: { name: "POETRY" number: 2 }
+		}
+	`)
+
+	require.Equal(t, `title: "Untitled \"draft\""
+pages: 100
+genre: FICTION
+tags: ""
+author {
+  name: ""
+  active: false
+  mentor {}
+}
+isbn: ""
+ratings {
+  key: ""
+  value: 0
+}
+cover {}
+checksum: "\x01"
+`, findMessage("Book", tmpl.Files[0]).TextFormatExample())
+}
+
+func TestCurlExample(t *testing.T) {
+	req := newTestRequest(t, docsProto("FieldOptions string example"), `
+		name: "api.proto"
+		package: "test"
+		dependency: "docs.proto"
+		message_type: {
+			name: "CreateBookRequest"
+			field: { name: "parent" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "parent" }
+			field: {
+				name: "title" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "title"
+				options: { [docs.example]: "It's Dune" }
+			}
+		}
+		message_type: {
+			name: "GetBookRequest"
+			field: { name: "book_id" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "bookId" }
+		}
+		service: {
+			name: "Library"
+			method: { name: "CreateBook" input_type: ".test.CreateBookRequest" output_type: ".test.CreateBookRequest" options: {} }
+			method: { name: "GetBook" input_type: ".test.GetBookRequest" output_type: ".test.GetBookRequest" options: {} }
+			method: { name: "Ping" input_type: ".test.GetBookRequest" output_type: ".test.GetBookRequest" }
+		}
+	`)
+	methods := req.ProtoFile[1].Service[0].Method
+	methods[0].Options.ProtoReflect().SetUnknown(encodeFields(72295728, encodeFields(4, "/v1/{parent=shelves/*}/books", 7, "*")))
+	methods[1].Options.ProtoReflect().SetUnknown(encodeFields(72295728, encodeFields(2, "/v1/books/{book_id}")))
+	tmpl := NewTemplate(protokit.ParseCodeGenRequest(req), WithProtoFiles(req.ProtoFile))
+
+	library := findService("Library", tmpl.Files[0])
+	require.Equal(t, `curl -X POST 'https://api.example.com/v1/shelves/parent/books' \
+  -H 'Content-Type: application/json' \
+  -d '{
+  "title": "It'\''s Dune"
+}'`, library.Methods[0].CurlExample("https://api.example.com/"))
+	require.Equal(t, "curl -X GET 'http://localhost:8080/v1/books/book_id'", library.Methods[1].CurlExample("http://localhost:8080"))
+	require.Empty(t, library.Methods[2].CurlExample("http://localhost:8080"))
+}
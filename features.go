@@ -0,0 +1,92 @@
+package gendoc
+
+import (
+	"fmt"
+
+	"github.com/pseudomuto/protokit"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// featuresNumber is the field number of the `features` option of the google.protobuf.*Options messages (editions).
+const featuresNumber = 50
+
+// features are the google.protobuf.FeatureSet features documented for editions files, by field number, along with the
+// names of their values by number.
+var features = map[protowire.Number]struct {
+	name   string
+	values map[uint64]string
+}{
+	1: {"field_presence", map[uint64]string{1: "EXPLICIT", 2: "IMPLICIT", 3: "LEGACY_REQUIRED"}},
+	2: {"enum_type", map[uint64]string{1: "OPEN", 2: "CLOSED"}},
+	3: {"repeated_field_encoding", map[uint64]string{1: "PACKED", 2: "EXPANDED"}},
+	4: {"utf8_validation", map[uint64]string{2: "VERIFY", 3: "NONE"}},
+	5: {"message_encoding", map[uint64]string{1: "LENGTH_PREFIXED", 2: "DELIMITED"}},
+}
+
+// editionDefaults are the features of edition 2023, the ones editions files have unless they set them otherwise.
+var editionDefaults = map[string]string{
+	"field_presence":          "EXPLICIT",
+	"enum_type":               "OPEN",
+	"repeated_field_encoding": "PACKED",
+	"utf8_validation":         "VERIFY",
+	"message_encoding":        "LENGTH_PREFIXED",
+}
+
+// fileFeatures returns the resolved features of an editions file: the edition defaults overridden by the `features`
+// option of the file. It's nil for proto2 and proto3 files.
+func fileFeatures(f *protokit.FileDescriptor) map[string]string {
+	if f == nil || f.GetSyntax() != "editions" {
+		return nil
+	}
+
+	resolved := make(map[string]string, len(editionDefaults))
+	for name, value := range editionDefaults {
+		resolved[name] = value
+	}
+	overrideFeatures(resolved, f.GetOptions())
+	return resolved
+}
+
+// fieldFeatures returns the resolved features of a field of an editions file: those of the file, overridden by the
+// ones of its message (outermost first), then by its own. It's nil for proto2 and proto3 files.
+func fieldFeatures(pf *protokit.FieldDescriptor) map[string]string {
+	resolved := fileFeatures(pf.GetFile())
+	if resolved == nil {
+		return nil
+	}
+
+	var msgs []*protokit.Descriptor
+	for msg := pf.GetMessage(); msg != nil; msg = msg.GetParent() {
+		msgs = append(msgs, msg)
+	}
+	for i := len(msgs) - 1; i >= 0; i-- {
+		overrideFeatures(resolved, msgs[i].GetOptions())
+	}
+	overrideFeatures(resolved, pf.GetOptions())
+	return resolved
+}
+
+// overrideFeatures sets the features set by the `features` option of the given options. The google.protobuf.FeatureSet
+// message is more recent than the descriptors this package is built with, so it's read from the unknown fields.
+func overrideFeatures(resolved map[string]string, opts protoreflect.ProtoMessage) {
+	if set, ok := unknownMessage(opts, featuresNumber); ok {
+		overrideFeatureSet(resolved, set)
+	}
+}
+
+// overrideFeatureSet sets the features of the given (encoded) google.protobuf.FeatureSet. Unknown values are kept as
+// numbers.
+func overrideFeatureSet(resolved map[string]string, b []byte) {
+	forEachField(b, func(num protowire.Number, typ protowire.Type, _ []byte, v uint64) {
+		feature, ok := features[num]
+		if !ok || typ != protowire.VarintType {
+			return
+		}
+		if name, ok := feature.values[v]; ok {
+			resolved[feature.name] = name
+		} else {
+			resolved[feature.name] = fmt.Sprint(v)
+		}
+	})
+}
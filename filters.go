@@ -6,8 +6,12 @@ import (
 	"github.com/gomarkdown/markdown/html"
 	"github.com/gomarkdown/markdown/parser"
 	"html/template"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 var (
@@ -15,6 +19,12 @@ var (
 	spacePattern        = regexp.MustCompile("( )+")
 	multiNewlinePattern = regexp.MustCompile(`(\r\n|\r|\n){2,}`)
 	specialCharsPattern = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+	tableDelimPattern   = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	typeNamePattern     = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*`)
+
+	// htmlEscaper escapes text like html/template does, so that autoLink doesn't change the output of HTML templates.
+	htmlEscaper = strings.NewReplacer("\x00", "\uFFFD", `"`, "&#34;", "&", "&amp;", "'", "&#39;", "+", "&#43;", "<", "&lt;",
+		">", "&gt;")
 )
 
 // PFilter splits the content by new lines and wraps each one in a <p> tag.
@@ -41,11 +51,208 @@ func NoBrFilter(content string) string {
 	return strings.Join(paragraphs, "\n\n")
 }
 
+// MDXFilter escapes the characters that MDX would parse as JSX or expressions (`{`, `}` and `<`) with backslashes.
+// Code spans and fenced code blocks are left as they are.
+func MDXFilter(content string) string {
+	var b strings.Builder
+	fenced := false
+	for i, line := range strings.Split(content, "\n") {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fence := strings.HasPrefix(strings.TrimSpace(line), "```")
+		if fence {
+			fenced = !fenced
+		}
+		if fence || fenced {
+			b.WriteString(line)
+			continue
+		}
+
+		code := false
+		for _, r := range line {
+			switch {
+			case r == '`':
+				code = !code
+			case !code && (r == '{' || r == '}' || r == '<'):
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// InlineFilter returns the content without the Markdown tables it contains, so that it can be put in a table cell. The
+// tables are available from BlocksFilter.
+func InlineFilter(content string) string {
+	inline, _ := splitDescription(content)
+	return inline
+}
+
+// BlocksFilter returns the Markdown tables contained in the content, verbatim and separated by blank lines. Templates
+// render them as a block below the table row holding the rest of the content (see InlineFilter).
+func BlocksFilter(content string) string {
+	_, blocks := splitDescription(content)
+	return blocks
+}
+
+// splitDescription separates the GFM tables (a header row, a delimiter row and the rows up to the next line without a
+// pipe) from the rest of the content.
+func splitDescription(content string) (inline, blocks string) {
+	if !strings.Contains(content, "|") {
+		return content, ""
+	}
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	var text, tables []string
+	for i := 0; i < len(lines); i++ {
+		if i+1 >= len(lines) || !isTableHeader(lines[i], lines[i+1]) {
+			text = append(text, lines[i])
+			continue
+		}
+
+		end := i + 2
+		for end < len(lines) && strings.Contains(lines[end], "|") {
+			end++
+		}
+		tables = append(tables, strings.Join(lines[i:end], "\n"))
+		i = end - 1
+	}
+
+	if len(tables) == 0 {
+		return content, ""
+	}
+	return strings.TrimSpace(strings.Join(text, "\n")), strings.Join(tables, "\n\n")
+}
+
+// isTableHeader reports whether the lines are the header and delimiter rows of a GFM table, which have the same number
+// of cells.
+func isTableHeader(header, delim string) bool {
+	cells := func(line string) int {
+		return strings.Count(strings.Trim(strings.TrimSpace(line), "|"), "|") + 1
+	}
+	return strings.Contains(header, "|") && tableDelimPattern.MatchString(delim) && cells(header) == cells(delim)
+}
+
+// WbrFilter escapes the name and inserts <wbr> word break opportunities after its dots, so that long fully qualified
+// type names can wrap in table cells of HTML output.
+func WbrFilter(name string) template.HTML {
+	return template.HTML(strings.ReplaceAll(template.HTMLEscapeString(name), ".", ".<wbr>"))
+}
+
+// WbrTextFilter is the plain variant of WbrFilter for other output formats. It inserts zero-width spaces instead.
+func WbrTextFilter(name string) string {
+	return strings.ReplaceAll(name, ".", ".\u200b")
+}
+
+// ClassesFilter returns the HTML classes of an entity, made of its kind and its states, e.g. `pgd-field
+// pgd-field-deprecated` for a deprecated field. The kinds are `file`, `message`, `field`, `enum`, `enum-value`,
+// `service`, `method`, `extension` and `option` (custom option definitions); the states `deprecated` and `internal`
+// (messages only).
+func ClassesFilter(entity interface{}) string {
+	var kind string
+	var deprecated, internal bool
+	switch e := entity.(type) {
+	case *File:
+		kind, deprecated = "file", e.Deprecated
+	case *Message:
+		kind, deprecated, internal = "message", isDeprecated(e.Options), e.Internal
+	case *MessageField:
+		kind, deprecated = "field", isDeprecated(e.Options)
+	case *Enum:
+		kind, deprecated = "enum", isDeprecated(e.Options)
+	case *EnumValue:
+		kind, deprecated = "enum-value", isDeprecated(e.Options)
+	case *Service:
+		kind, deprecated = "service", isDeprecated(e.Options)
+	case *ServiceMethod:
+		kind, deprecated = "method", isDeprecated(e.Options)
+	case *MessageExtension:
+		kind = "extension"
+	case *FileExtension:
+		kind = "extension"
+		if e.IsOptionDefinition {
+			kind = "option"
+		}
+	default:
+		return ""
+	}
+
+	classes := "pgd-" + kind
+	if deprecated {
+		classes += " pgd-" + kind + "-deprecated"
+	}
+	if internal {
+		classes += " pgd-" + kind + "-internal"
+	}
+	return classes
+}
+
 // AnchorFilter replaces all special characters with URL friendly dashes
 func AnchorFilter(str string) string {
 	return specialCharsPattern.ReplaceAllString(strings.ReplaceAll(str, "/", "_"), "-")
 }
 
+// SlugFor returns the anchor of the documentation of an entity with the given full name (the name of files): the full
+// name with dots and other special characters replaced by dashes, e.g. `com-example-Vehicle`. Types never share an
+// anchor this way, but files may share the one of a type; Template.Slug returns the anchors every output format uses,
+// which resolve such collisions.
+func SlugFor(fullName string) string {
+	return AnchorFilter(fullName)
+}
+
+// SlugFilter turns a heading into an anchor the way GitHub does: lowercased, punctuation removed and spaces replaced
+// with dashes (e.g. "Vehicle.Category" becomes "vehiclecategory").
+func SlugFilter(str string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '-'
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r):
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, str)
+}
+
+// PluralizeFilter prefixes the word with the count, in its plural form unless the count is 1 (e.g. "3 fields" or
+// "1 entry"). Plurals follow the regular English rules: `-es` after s, x, z, ch and sh, `-ies` after a consonant and y,
+// `-s` otherwise.
+func PluralizeFilter(count int, word string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, word)
+	}
+
+	plural := word + "s"
+	lower := strings.ToLower(word)
+	switch {
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		plural = word + "es"
+	case len(lower) > 1 && strings.HasSuffix(lower, "y") && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		plural = word[:len(word)-1] + "ies"
+	}
+	return fmt.Sprintf("%d %s", count, plural)
+}
+
+// TitleCaseFilter upper-cases the first letter of every space separated word (e.g. "vehicle booking" becomes
+// "Vehicle Booking"). The other letters are left as they are.
+func TitleCaseFilter(str string) string {
+	words := strings.Split(str, " ")
+	for i, word := range words {
+		if r, size := utf8.DecodeRuneInString(word); size > 0 {
+			words[i] = string(unicode.ToUpper(r)) + word[size:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// SnakeToTitleFilter turns a snake_case name into title case words (e.g. "user_id" becomes "User Id").
+func SnakeToTitleFilter(str string) string {
+	return TitleCaseFilter(strings.Join(strings.FieldsFunc(str, func(r rune) bool { return r == '_' }), " "))
+}
+
 func MDFilter(str string) string {
 	extensions := parser.CommonExtensions | parser.AutoHeadingIDs | parser.NoEmptyLineBeforeBlock
 	p := parser.NewWithExtensions(extensions)
@@ -68,10 +275,326 @@ func IsLinkFn(tpl *Template) func(string) bool {
 
 func LinkFn(tpl *Template) func(string, string) string {
 	return func(fullType, ext string) string {
-		l, ok := tpl.links[fullType]
-		if !ok {
+		l := tpl.resolveLink(fullType)
+		if l == nil {
 			return fmt.Sprintf("NOT FOUND: %s", fullType)
 		}
-		return fmt.Sprintf("%s%s#%s", AnchorFilter(l.Package), ext, AnchorFilter(l.FullName))
+		if l.External {
+			return l.ExternalHREF
+		}
+		return fmt.Sprintf("%s%s#%s", AnchorFilter(l.Package), ext, tpl.Slug("type", l.FullName))
+	}
+}
+
+// typeRefFn returns the typeRef template function of the given output format. typeRef(fullType, text) links the text to
+// the definition of the type: with an <a> element in HTML, a link in Markdown, a link or an ulink element in DocBook
+// and not at all in other formats. Scalars link to the scalar value types table, external types (see Link.External) to
+// their external location. The text is returned without link when the type can't be resolved. Text that is HTML
+// already (e.g. the output of wbr) isn't escaped again.
+func typeRefFn(tpl *Template, kind RenderType, anchors *anchors) func(string, interface{}) template.HTML {
+	return func(fullType string, content interface{}) template.HTML {
+		text, isHTML := content.(template.HTML)
+		if !isHTML {
+			text = template.HTML(fmt.Sprint(content))
+		}
+
+		switch kind {
+		case RenderTypeMDX:
+			return template.HTML(mdxTypeRef(tpl, anchors, fullType, string(text)))
+		case RenderTypeDocBook:
+			return template.HTML(docBookTypeRef(tpl, anchors, fullType, string(text)))
+		}
+		if kind != RenderTypeHTML && kind != RenderTypeMarkdown {
+			return text
+		}
+
+		if !isHTML {
+			text = template.HTML(template.HTMLEscapeString(string(text)))
+		}
+		href := typeHref(tpl, anchors, fullType)
+		if href == "" {
+			return text
+		}
+
+		if kind == RenderTypeMarkdown {
+			return template.HTML(fmt.Sprintf("[%s](%s)", text, href))
+		}
+		return template.HTML(fmt.Sprintf(`<a href="%s">%s</a>`, template.HTMLEscapeString(href), text))
+	}
+}
+
+// docBookTypeRef links the text to the type like typeRef does in DocBook: with a link element to the section of the
+// type (or to the row of the scalar value types table), or an ulink element to its external location.
+func docBookTypeRef(tpl *Template, anchors *anchors, fullType, text string) string {
+	text = template.HTMLEscapeString(text)
+	href := typeHref(tpl, anchors, fullType)
+	if href == "" {
+		return text
+	}
+	if id, ok := strings.CutPrefix(href, "#"); ok {
+		return fmt.Sprintf(`<link linkend="%s">%s</link>`, template.HTMLEscapeString(id), text)
+	}
+	return fmt.Sprintf(`<ulink url="%s">%s</ulink>`, template.HTMLEscapeString(href), text)
+}
+
+// typeHref returns the location of the definition of the type in the HTML and Markdown outputs, or an empty string
+// when it can't be resolved or has no documentation.
+func typeHref(tpl *Template, anchors *anchors, fullType string) string {
+	if l := tpl.resolveLink(fullType); l != nil {
+		if l.External {
+			return l.ExternalHREF
+		}
+		return "#" + anchors.ref(l.FullName)
+	}
+	if slices.Contains(scalarTypes, fullType) {
+		return "#" + fullType
+	}
+	return ""
+}
+
+// footnotes collects the types marked with footnotes during a rendering (see Template.FootnoteLinks). Numbers are
+// unique in the document, but a type marked several times before the footnotes are defined keeps its number.
+type footnotes struct {
+	tpl     *Template
+	anchors *anchors
+	next    int
+	numbers map[string]int
+	pending []string
+}
+
+func newFootnotes(tpl *Template, anchors *anchors) *footnotes {
+	return &footnotes{tpl: tpl, anchors: anchors, numbers: map[string]int{}}
+}
+
+// mark returns the text followed by the footnote of the type, e.g. `Vehicle[^1]`. The text is returned alone when the
+// type can't be linked.
+func (f *footnotes) mark(fullType, text string) template.HTML {
+	text = template.HTMLEscapeString(text)
+	if typeHref(f.tpl, f.anchors, fullType) == "" {
+		return template.HTML(text)
+	}
+	n, ok := f.numbers[fullType]
+	if !ok {
+		f.next++
+		n = f.next
+		f.numbers[fullType] = n
+		f.pending = append(f.pending, fullType)
+	}
+	return template.HTML(fmt.Sprintf("%s[^%d]", text, n))
+}
+
+// flush returns the definitions of the footnotes marked since the previous call, preceded by an empty line, e.g.
+// `[^1]: [com.example.Vehicle](#com-example-Vehicle)`. It's empty when there are none.
+func (f *footnotes) flush() template.HTML {
+	var defs strings.Builder
+	for _, fullType := range f.pending {
+		if defs.Len() == 0 {
+			defs.WriteString("\n")
+		}
+		fmt.Fprintf(&defs, "[^%d]: [%s](%s)\n", f.numbers[fullType], template.HTMLEscapeString(fullType),
+			template.HTMLEscapeString(typeHref(f.tpl, f.anchors, fullType)))
+		delete(f.numbers, fullType)
+	}
+	f.pending = nil
+	return template.HTML(defs.String())
+}
+
+// mdxTypeRef is the typeRef function of MDX output. Unlike Markdown, types documented on other pages (see WithPages)
+// are linked by the relative paths of their pages, which Docusaurus resolves to the URLs of the pages.
+func mdxTypeRef(tpl *Template, anchors *anchors, fullType, text string) string {
+	text = MDXFilter(text)
+	href := relLinkFn(tpl, RenderTypeMDX, anchors)(fullType)
+	if href == "" {
+		return text
+	}
+	return fmt.Sprintf("[%s](%s)", text, href)
+}
+
+// autoLinkFn returns the autoLink template function of the given output format. autoLink(text) links the names of the
+// types of the template mentioned in the text (or in the output of another function) like typeRef does, following
+// WithAutoLink. Names in code spans, or
+// adjacent to a path, an anchor or a link (e.g. `#com.example.Vehicle` or `[Vehicle]`), are left as they are.
+func autoLinkFn(tpl *Template, kind RenderType, anchors *anchors) func(any) string {
+	names := tpl.autoLinkNames()
+	typeRef := typeRefFn(tpl, kind, anchors)
+	return func(content any) string {
+		text := fmt.Sprint(content)
+		if len(names) == 0 {
+			return text
+		}
+
+		// the even parts are outside of code spans
+		parts := strings.Split(text, "`")
+		for i := 0; i < len(parts); i += 2 {
+			var b strings.Builder
+			last := 0
+			for _, loc := range typeNamePattern.FindAllStringIndex(parts[i], -1) {
+				fullName, ok := names[parts[i][loc[0]:loc[1]]]
+				if !ok || (loc[0] > 0 && strings.ContainsAny(parts[i][loc[0]-1:loc[0]], "[/#.-<=\"'0123456789")) ||
+					(loc[1] < len(parts[i]) && strings.ContainsAny(parts[i][loc[1]:loc[1]+1], "]/(-")) {
+					continue
+				}
+				b.WriteString(parts[i][last:loc[0]])
+				b.WriteString(string(typeRef(fullName, parts[i][loc[0]:loc[1]])))
+				last = loc[1]
+			}
+			b.WriteString(parts[i][last:])
+			parts[i] = b.String()
+		}
+		return strings.Join(parts, "`")
+	}
+}
+
+// htmlAutoLinkFn returns the autoLink template function of the outputs rendered by html/template (HTML and Markdown).
+// Unlike plain text, which is escaped first, the output of other functions (e.g. `{{autoLink (p .Description)}}`) is
+// linked as it is.
+func htmlAutoLinkFn(tpl *Template, kind RenderType, anchors *anchors) func(any) template.HTML {
+	autoLink := autoLinkFn(tpl, kind, anchors)
+	return func(content any) template.HTML {
+		html, ok := content.(template.HTML)
+		if !ok {
+			html = template.HTML(htmlEscaper.Replace(fmt.Sprint(content)))
+		}
+		return template.HTML(autoLink(html))
+	}
+}
+
+// autoLinkNames maps the names autoLink links (see WithAutoLink) to the full names of their types. Internal messages
+// (e.g. map entries) aren't linked, nor are the names shared by several types in short mode.
+func (t *Template) autoLinkNames() map[string]string {
+	names := map[string]string{}
+	if t.autoLink != AutoLinkFull && t.autoLink != AutoLinkShort {
+		return names
+	}
+
+	short := map[string][]string{}
+	for _, file := range t.Files {
+		for _, msg := range file.Messages {
+			if !msg.Internal {
+				names[msg.FullName] = msg.FullName
+				short[msg.LongName] = append(short[msg.LongName], msg.FullName)
+			}
+		}
+		for _, enum := range file.Enums {
+			names[enum.FullName] = enum.FullName
+			short[enum.LongName] = append(short[enum.LongName], enum.FullName)
+		}
+	}
+
+	if t.autoLink == AutoLinkShort {
+		for name, fullNames := range short {
+			if _, ok := names[name]; !ok && len(fullNames) == 1 {
+				names[name] = fullNames[0]
+			}
+		}
+	}
+	return names
+}
+
+// relLinkFn returns the relLink template function of the given output format. relLink(fullType) returns the location
+// of the definition of the type: `#anchor` when it's documented by the page being rendered, the path of its page
+// relative to this one followed by the anchor when it's documented by another page of the output (e.g.
+// `../v2/index.md#acme-v2-Book`, see WithPages), or its external location. Scalars link to the scalar value types
+// table. It's empty when the type can't be resolved.
+func relLinkFn(tpl *Template, kind RenderType, anchors *anchors) func(string) string {
+	return func(fullType string) string {
+		if l := tpl.resolveLink(fullType); l != nil {
+			if l.External {
+				return l.ExternalHREF
+			}
+			return "#" + anchors.ref(l.FullName)
+		}
+		if page, ok := tpl.pages[fullType]; ok && tpl.page != "" {
+			return relativePage(tpl.page, page) + "#" + tpl.Slug("type", fullType)
+		}
+		if slices.Contains(scalarTypes, fullType) {
+			return "#" + fullType
+		}
+		return ""
+	}
+}
+
+// relativePage returns the path of the page relative to the directory of the page from, e.g. `../v2/index.mdx` for
+// `acme/v2/index.mdx` from `acme/v1/index.mdx`. It's empty when both are the same page.
+func relativePage(from, page string) string {
+	if filepath.Clean(from) == filepath.Clean(page) {
+		return ""
+	}
+	rel, err := filepath.Rel(filepath.Dir(from), page)
+	if err != nil {
+		return filepath.ToSlash(page)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// AnchorMode selects how the anchors of headings are generated by the headingAnchor and anchorRef template functions.
+type AnchorMode string
+
+const (
+	// AnchorModeDefault uses explicit anchors derived from the keys (e.g. full names) of the headings.
+	AnchorModeDefault AnchorMode = "default"
+	// AnchorModeGitHub derives anchors from the heading texts like GitHub does (see SlugFilter), so that links resolve
+	// in Markdown files rendered by GitHub, which drops explicit anchors.
+	AnchorModeGitHub AnchorMode = "github"
+)
+
+// NewAnchorMode returns the AnchorMode with the given name.
+func NewAnchorMode(mode string) (AnchorMode, error) {
+	switch AnchorMode(mode) {
+	case AnchorModeDefault, AnchorModeGitHub:
+		return AnchorMode(mode), nil
+	}
+	return "", fmt.Errorf("Invalid anchor mode: %s", mode)
+}
+
+// anchors hands out the anchors of a single rendering. Headings are identified by a key (e.g. the full name of a
+// message), so that links to a heading can be resolved without knowing its text.
+type anchors struct {
+	mode  AnchorMode
+	slugs map[string]string
+	byKey map[string]string
+	used  map[string]bool
+}
+
+// newAnchors returns the anchors of a rendering of the template. In default mode, the anchors of files and types are
+// their slugs (see Template.Slug).
+func newAnchors(tpl *Template) *anchors {
+	return &anchors{mode: tpl.anchorMode, slugs: tpl.slugs, byKey: map[string]string{}, used: map[string]bool{}}
+}
+
+// key returns the default anchor of a key: the slug of the file or type it names, AnchorFilter otherwise.
+func (a *anchors) key(key string) string {
+	if slug, ok := a.slugs[key]; ok {
+		return slug
+	}
+	return AnchorFilter(key)
+}
+
+// heading returns the anchor of the heading with the given key and text. In GitHub mode, the first call for a key
+// decides its anchor; repeated texts get numbered like on GitHub (`text`, `text-1`, ...), so headings must be
+// declared in document order (e.g. by the table of contents).
+func (a *anchors) heading(key, text string) string {
+	if a.mode != AnchorModeGitHub {
+		return a.key(key)
+	}
+	if anchor, ok := a.byKey[key]; ok {
+		return anchor
+	}
+
+	slug := SlugFilter(text)
+	anchor := slug
+	for i := 1; a.used[anchor]; i++ {
+		anchor = fmt.Sprintf("%s-%d", slug, i)
+	}
+	a.used[anchor] = true
+	a.byKey[key] = anchor
+	return anchor
+}
+
+// ref returns the anchor to link to the heading with the given key. Keys without a heading use their default anchor.
+func (a *anchors) ref(key string) string {
+	if anchor, ok := a.byKey[key]; ok {
+		return anchor
 	}
+	return a.key(key)
 }
@@ -50,6 +50,12 @@ func TestNoBrFilter(t *testing.T) {
 	}
 }
 
+func TestSlugFor(t *testing.T) {
+	require.Equal(t, "com-example-Vehicle", SlugFor("com.example.Vehicle"))
+	require.Equal(t, "com-example-Vehicle-Category", SlugFor("com.example.Vehicle.Category"))
+	require.Equal(t, "com_example_Vehicle-proto", SlugFor("com/example/Vehicle.proto"))
+}
+
 func TestAnchorFilter(t *testing.T) {
 	tests := map[string]string{
 		"com/example/test.proto":  "com_example_test-proto",
@@ -62,3 +68,132 @@ func TestAnchorFilter(t *testing.T) {
 		require.Equal(t, output, AnchorFilter(input))
 	}
 }
+
+func TestSlugFilter(t *testing.T) {
+	tests := map[string]string{
+		"Vehicle.Category":      "vehiclecategory",
+		"File-level Extensions": "file-level-extensions",
+		"Booking.proto":         "bookingproto",
+		"snake_case (v2)!":      "snake_case-v2",
+		"Ünïcode Names":         "ünïcode-names",
+	}
+
+	for input, output := range tests {
+		require.Equal(t, output, SlugFilter(input))
+	}
+}
+
+func TestPluralizeFilter(t *testing.T) {
+	require.Equal(t, "1 field", PluralizeFilter(1, "field"))
+	require.Equal(t, "0 fields", PluralizeFilter(0, "field"))
+	require.Equal(t, "3 fields", PluralizeFilter(3, "field"))
+	require.Equal(t, "2 entries", PluralizeFilter(2, "entry"))
+	require.Equal(t, "2 keys", PluralizeFilter(2, "key"))
+	require.Equal(t, "2 aliases", PluralizeFilter(2, "alias"))
+	require.Equal(t, "2 boxes", PluralizeFilter(2, "box"))
+	require.Equal(t, "2 batches", PluralizeFilter(2, "batch"))
+	require.Equal(t, "2 Messages", PluralizeFilter(2, "Message"))
+}
+
+func TestTitleCaseFilter(t *testing.T) {
+	tests := map[string]string{
+		"vehicle booking":  "Vehicle Booking",
+		"already Title":    "Already Title",
+		"keeps mixedCase":  "Keeps MixedCase",
+		"  extra  spaces ": "  Extra  Spaces ",
+		"ünïcode":          "Ünïcode",
+		"":                 "",
+	}
+
+	for input, output := range tests {
+		require.Equal(t, output, TitleCaseFilter(input))
+	}
+}
+
+func TestSnakeToTitleFilter(t *testing.T) {
+	tests := map[string]string{
+		"user_id":           "User Id",
+		"vehicle":           "Vehicle",
+		"_leading__double_": "Leading Double",
+		"SCREAMING_CASE":    "SCREAMING CASE",
+	}
+
+	for input, output := range tests {
+		require.Equal(t, output, SnakeToTitleFilter(input))
+	}
+}
+
+func TestInlineAndBlocksFilter(t *testing.T) {
+	tests := []struct {
+		input, inline, blocks string
+	}{
+		{"Plain content.", "Plain content.", ""},
+		{"Either a | b.\n---", "Either a | b.\n---", ""},
+		{
+			"The mode.\n\n| Mode | Meaning |\n| --- | :---: |\n| A | all |\n| B | | \n\nDefaults to A.",
+			"The mode.\n\n\nDefaults to A.",
+			"| Mode | Meaning |\n| --- | :---: |\n| A | all |\n| B | | ",
+		},
+		{
+			"Two tables.\nA | B\n-|-\n1 | 2\n\nC | D\n-|-\n3 | 4",
+			"Two tables.",
+			"A | B\n-|-\n1 | 2\n\nC | D\n-|-\n3 | 4",
+		},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.inline, InlineFilter(test.input))
+		require.Equal(t, test.blocks, BlocksFilter(test.input))
+	}
+}
+
+func TestMDXFilter(t *testing.T) {
+	tests := map[string]string{
+		"plain text":                      "plain text",
+		"a {b} <c>":                       "a \\{b\\} \\<c>",
+		"keep `{code}` as is, not {this}": "keep `{code}` as is, not \\{this\\}",
+		"```\n<div>{x}</div>\n```\n<div>": "```\n<div>{x}</div>\n```\n\\<div>",
+	}
+
+	for input, output := range tests {
+		require.Equal(t, output, MDXFilter(input))
+	}
+}
+
+func TestClassesFilter(t *testing.T) {
+	deprecated := map[string]interface{}{"deprecated": true}
+	tests := []struct {
+		entity  interface{}
+		classes string
+	}{
+		{&File{}, "pgd-file"},
+		{&File{Deprecated: true}, "pgd-file pgd-file-deprecated"},
+		{&Message{Internal: true}, "pgd-message pgd-message-internal"},
+		{&MessageField{Options: deprecated}, "pgd-field pgd-field-deprecated"},
+		{&Enum{}, "pgd-enum"},
+		{&EnumValue{Options: deprecated}, "pgd-enum-value pgd-enum-value-deprecated"},
+		{&Service{}, "pgd-service"},
+		{&ServiceMethod{Options: deprecated}, "pgd-method pgd-method-deprecated"},
+		{&MessageExtension{}, "pgd-extension"},
+		{&FileExtension{IsOptionDefinition: true}, "pgd-option"},
+		{"text", ""},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.classes, ClassesFilter(test.entity))
+	}
+}
+
+func TestWbrFilter(t *testing.T) {
+	tests := map[string]html.HTML{
+		"string":                       "string",
+		"com.example.Vehicle.Category": "com.<wbr>example.<wbr>Vehicle.<wbr>Category",
+		"a.<b>":                        "a.<wbr>&lt;b&gt;",
+	}
+
+	for input, output := range tests {
+		require.Equal(t, output, WbrFilter(input))
+	}
+
+	require.Equal(t, "com.\u200bexample.\u200bVehicle", WbrTextFilter("com.example.Vehicle"))
+}
@@ -16,6 +16,7 @@ require (
 	github.com/Masterminds/sprig/v3 v3.2.3
 	github.com/gomarkdown/markdown v0.0.0-20231115200524-a660076da3fd
 	google.golang.org/protobuf v1.31.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -29,5 +30,4 @@ require (
 	github.com/shopspring/decimal v1.3.1 // indirect
 	github.com/spf13/cast v1.6.0 // indirect
 	golang.org/x/crypto v0.16.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
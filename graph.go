@@ -0,0 +1,155 @@
+package gendoc
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Kinds of graph nodes.
+const (
+	GraphNodeMessage   = "message"
+	GraphNodeEnum      = "enum"
+	GraphNodeService   = "service"
+	GraphNodeExtension = "extension"
+)
+
+// Kinds of graph edges.
+const (
+	// GraphEdgeField goes from a message to the message or enum a field (other than a map) is typed with.
+	GraphEdgeField = "field"
+	// GraphEdgeMapValue goes from a message to the message or enum the values of a map field are typed with.
+	GraphEdgeMapValue = "map_value"
+	// GraphEdgeRequest and GraphEdgeResponse go from a service to the request and response messages of a method.
+	GraphEdgeRequest  = "request"
+	GraphEdgeResponse = "response"
+	// GraphEdgeExtends goes from an extension to the message it extends.
+	GraphEdgeExtends = "extends"
+)
+
+// Graph is the dependency graph of the types of a template (see Template.Graph), e.g. for visualizing it.
+type Graph struct {
+	Nodes []*GraphNode `json:"nodes"`
+	Edges []*GraphEdge `json:"edges"`
+}
+
+// GraphNode is a message, enum, service or extension of a graph, identified by its full name.
+type GraphNode struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	// File is the file defining the node, empty for external nodes.
+	File string `json:"file,omitempty"`
+	// External is set for the messages and enums that are referenced, but aren't part of the template (e.g. well-known
+	// types).
+	External bool `json:"external,omitempty"`
+}
+
+// GraphEdge is a reference from a node to another one. Label is the name of the field, method or extension making the
+// reference.
+type GraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Kind  string `json:"kind"`
+	Label string `json:"label"`
+}
+
+// Graph returns the dependency graph of the template: its messages, enums, services and extensions, and the references
+// between them. Internal messages (e.g. map entries) are left out, their values are referenced by map_value edges of the
+// messages using them. Nodes are listed file by file like in the documentation (messages, enums, services, then
+// extensions), followed by the external ones sorted by name; edges are listed in the order of their nodes, then of
+// their fields or methods. References to scalars aren't part of the graph.
+func (t *Template) Graph() *Graph {
+	graph := &Graph{Nodes: []*GraphNode{}, Edges: []*GraphEdge{}}
+	external := map[string]string{}
+	edge := func(from, to, kind, label string, isMessage bool) {
+		if _, ok := t.messages[to]; !ok && t.enums[to] == nil {
+			if t.scalar(to) != nil {
+				return
+			}
+			external[to] = GraphNodeEnum
+			if isMessage {
+				external[to] = GraphNodeMessage
+			}
+		}
+		graph.Edges = append(graph.Edges, &GraphEdge{From: from, To: to, Kind: kind, Label: label})
+	}
+
+	for _, file := range t.Files {
+		extensions := append([]*FileExtension{}, file.Extensions...)
+
+		for _, msg := range file.Messages {
+			for _, ext := range msg.Extensions {
+				extensions = append(extensions, &ext.FileExtension)
+			}
+			if msg.Internal {
+				continue
+			}
+
+			graph.Nodes = append(graph.Nodes, &GraphNode{ID: msg.FullName, Kind: GraphNodeMessage, File: file.Name})
+			for _, field := range msg.allFields() {
+				if !field.IsMap {
+					edge(msg.FullName, field.FullType, GraphEdgeField, field.Name, field.isMessage)
+					continue
+				}
+				if entry := t.messages[field.FullType]; entry != nil {
+					if value := entry.fieldNamed("value"); value != nil {
+						edge(msg.FullName, value.FullType, GraphEdgeMapValue, field.Name, value.isMessage)
+					}
+				}
+			}
+		}
+		for _, enum := range file.Enums {
+			graph.Nodes = append(graph.Nodes, &GraphNode{ID: enum.FullName, Kind: GraphNodeEnum, File: file.Name})
+		}
+		for _, service := range file.Services {
+			graph.Nodes = append(graph.Nodes, &GraphNode{ID: service.FullName, Kind: GraphNodeService, File: file.Name})
+			for _, method := range service.Methods {
+				edge(service.FullName, method.RequestFullType, GraphEdgeRequest, method.Name, true)
+				edge(service.FullName, method.ResponseFullType, GraphEdgeResponse, method.Name, true)
+			}
+		}
+		for _, ext := range extensions {
+			graph.Nodes = append(graph.Nodes, &GraphNode{ID: ext.FullName, Kind: GraphNodeExtension, File: file.Name})
+			edge(ext.FullName, ext.ContainingFullType, GraphEdgeExtends, ext.Name, true)
+		}
+	}
+
+	names := make([]string, 0, len(external))
+	for name := range external {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		graph.Nodes = append(graph.Nodes, &GraphNode{ID: name, Kind: external[name], External: true})
+	}
+	return graph
+}
+
+// UnusedMessages returns the messages of the template that nothing references: no field or map value is typed with
+// them, no method takes or returns them, and no extension extends them. A message only referenced by itself is unused,
+// while one referenced by an unused message isn't, so that removing dead types is done one layer at a time. Internal
+// messages (e.g. map entries) are left out. Messages are listed file by file like in the documentation.
+func (t *Template) UnusedMessages() []*Message {
+	used := make(map[string]bool)
+	for _, edge := range t.Graph().Edges {
+		if edge.From != edge.To {
+			used[edge.To] = true
+		}
+	}
+
+	var unused []*Message
+	for _, file := range t.Files {
+		for _, msg := range file.Messages {
+			if !msg.Internal && !used[msg.FullName] {
+				unused = append(unused, msg)
+			}
+		}
+	}
+	return unused
+}
+
+type graphRenderer struct{}
+
+// Apply renders the dependency graph of the template (see Template.Graph) as JSON.
+func (r *graphRenderer) Apply(template *Template) ([]byte, error) {
+	return json.MarshalIndent(template.Graph(), "", "  ")
+}
@@ -0,0 +1,37 @@
+package gendoc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type grpcRenderer struct{}
+
+// Apply renders a flat listing of the methods of every service of the template, one signature per line like grpcurl
+// prints them, e.g. `com.example.BookingService.BookVehicle(com.example.Booking) returns (stream com.example.Status)`.
+// Streaming requests and responses are marked with the `stream` keyword, and lines are sorted by full method name
+// across packages.
+func (r *grpcRenderer) Apply(template *Template) ([]byte, error) {
+	var lines []string
+	for _, file := range template.Files {
+		for _, service := range file.Services {
+			for _, method := range service.Methods {
+				lines = append(lines, fmt.Sprintf("%s.%s(%s) returns (%s)",
+					service.FullName,
+					method.Name,
+					methodType(method.RequestFullType, method.RequestStreaming),
+					methodType(method.ResponseFullType, method.ResponseStreaming),
+				))
+			}
+		}
+	}
+	sort.Strings(lines)
+
+	var out strings.Builder
+	for _, line := range lines {
+		out.WriteString(line)
+		out.WriteString("\n")
+	}
+	return []byte(out.String()), nil
+}
@@ -0,0 +1,137 @@
+package gendoc
+
+import (
+	"encoding/json"
+)
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchema is the subset of JSON Schema (draft 2020-12) keywords used to describe messages and enums.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Anchor               string                 `json:"$anchor,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Deprecated           bool                   `json:"deprecated,omitempty"`
+	Type                 interface{}            `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	ContentEncoding      string                 `json:"contentEncoding,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Examples             []json.RawMessage      `json:"examples,omitempty"`
+	Defs                 map[string]*jsonSchema `json:"$defs,omitempty"`
+}
+
+// jsonSchemaScalars maps the proto types of the scalar value table to their protojson representation. 64-bit integers
+// are written as strings by protojson, but numbers are accepted as well.
+var jsonSchemaScalars = map[string]jsonSchema{
+	"double":   {Type: "number"},
+	"float":    {Type: "number"},
+	"int32":    {Type: "integer", Format: "int32"},
+	"int64":    {Type: []string{"integer", "string"}, Format: "int64"},
+	"uint32":   {Type: "integer", Format: "uint32"},
+	"uint64":   {Type: []string{"integer", "string"}, Format: "uint64"},
+	"sint32":   {Type: "integer", Format: "int32"},
+	"sint64":   {Type: []string{"integer", "string"}, Format: "int64"},
+	"fixed32":  {Type: "integer", Format: "uint32"},
+	"fixed64":  {Type: []string{"integer", "string"}, Format: "uint64"},
+	"sfixed32": {Type: "integer", Format: "int32"},
+	"sfixed64": {Type: []string{"integer", "string"}, Format: "int64"},
+	"bool":     {Type: "boolean"},
+	"string":   {Type: "string"},
+	"bytes":    {Type: "string", ContentEncoding: "base64"},
+}
+
+type jsonSchemaRenderer struct{}
+
+// Apply renders a single JSON Schema document with one `$defs` entry per (non-internal) message and enum, keyed and
+// anchored by full name. Fields reference other types with `$ref`; types that aren't part of the template (e.g. imported
+// well-known types) are left unconstrained. Messages have an example built from the examples of their fields (see
+// MessageField.Example), or their zero values; fields with an example list it as well.
+func (r *jsonSchemaRenderer) Apply(template *Template) ([]byte, error) {
+	scalars := make(map[string]jsonSchema, len(template.Scalars))
+	for _, scalar := range template.Scalars {
+		if s, ok := jsonSchemaScalars[scalar.ProtoType]; ok {
+			scalars[scalar.ProtoType] = s
+		}
+	}
+
+	defs := make(map[string]*jsonSchema)
+	for _, file := range template.Files {
+		for _, enum := range file.Enums {
+			defs[enum.FullName] = enumSchema(enum)
+		}
+		for _, msg := range file.VisibleMessages() {
+			defs[msg.FullName] = nil
+		}
+	}
+
+	typeSchema := func(fullType string) *jsonSchema {
+		if s, ok := scalars[fullType]; ok {
+			return &s
+		}
+		if _, ok := defs[fullType]; ok {
+			return &jsonSchema{Ref: "#" + fullType}
+		}
+		return &jsonSchema{}
+	}
+
+	for _, file := range template.Files {
+		for _, msg := range file.VisibleMessages() {
+			s := &jsonSchema{
+				Anchor:      msg.FullName,
+				Title:       msg.LongName,
+				Description: msg.Description,
+				Deprecated:  isDeprecated(msg.Options),
+				Type:        "object",
+				Properties:  make(map[string]*jsonSchema),
+				Examples:    []json.RawMessage{json.RawMessage(jsonExample(msg, nil))},
+			}
+
+			for _, field := range msg.allFields() {
+				var fs *jsonSchema
+				switch {
+				case field.IsMap:
+					fs = &jsonSchema{Type: "object", AdditionalProperties: typeSchema(field.MapValueType)}
+				case field.Label == "repeated":
+					fs = &jsonSchema{Type: "array", Items: typeSchema(field.FullType)}
+				default:
+					fs = typeSchema(field.FullType)
+				}
+				fs.Description = field.Description
+				fs.Deprecated = isDeprecated(field.Options)
+				if example, ok := jsonOptionExample(field); ok {
+					fs.Examples = []json.RawMessage{json.RawMessage(example)}
+				}
+				s.Properties[field.JSONName] = fs
+
+				if field.IsRequired() {
+					s.Required = append(s.Required, field.JSONName)
+				}
+			}
+
+			defs[msg.FullName] = s
+		}
+	}
+
+	return json.MarshalIndent(&jsonSchema{Schema: jsonSchemaDialect, Defs: defs}, "", "  ")
+}
+
+func enumSchema(enum *Enum) *jsonSchema {
+	s := &jsonSchema{
+		Anchor:      enum.FullName,
+		Title:       enum.LongName,
+		Description: enum.Description,
+		Deprecated:  isDeprecated(enum.Options),
+		Type:        "string",
+		Enum:        make([]string, 0, len(enum.Values)),
+	}
+	for _, value := range enum.Values {
+		s.Enum = append(s.Enum, value.Name)
+	}
+	return s
+}
@@ -0,0 +1,145 @@
+package gendoc
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// javaBoxed maps the primitive Java types of scalars to the classes boxing them, which collections hold.
+var javaBoxed = map[string]string{
+	"boolean": "Boolean",
+	"double":  "Double",
+	"float":   "Float",
+	"int":     "Integer",
+	"long":    "Long",
+}
+
+// languageType returns the type of the field in the language set by WithTypeLanguage (see MessageField.LanguageType).
+func (t *Template) languageType(field *MessageField) string {
+	switch t.typeLang {
+	case TypeLanguageJava:
+		if field.IsMap {
+			return fmt.Sprintf("Map<%s, %s>", t.javaBoxedType(field.MapKeyType), t.javaBoxedType(field.MapValueType))
+		}
+		if field.Label == "repeated" {
+			return fmt.Sprintf("List<%s>", t.javaBoxedType(field.FullType))
+		}
+		return t.javaType(field.FullType)
+	case TypeLanguageCSharp:
+		if field.IsMap {
+			return fmt.Sprintf("MapField<%s, %s>", t.csharpType(field.MapKeyType), t.csharpType(field.MapValueType))
+		}
+		if field.Label == "repeated" {
+			return fmt.Sprintf("RepeatedField<%s>", t.csharpType(field.FullType))
+		}
+		return t.csharpType(field.FullType)
+	}
+	return ""
+}
+
+// javaType returns the Java type of a scalar, message or enum. Messages and enums are named by their generated class,
+// nested in the outer class of their file unless the file sets `java_multiple_files`.
+func (t *Template) javaType(fullType string) string {
+	if scalar := t.scalar(fullType); scalar != nil {
+		return scalar.JavaType
+	}
+
+	file, longName := t.typeFile(fullType)
+	if file == nil || file.FDS == nil || file.FDS.GetOptions().GetJavaMultipleFiles() {
+		return longName
+	}
+	return javaOuterClass(file) + "." + longName
+}
+
+func (t *Template) javaBoxedType(fullType string) string {
+	javaType := t.javaType(fullType)
+	if boxed, ok := javaBoxed[javaType]; ok {
+		return boxed
+	}
+	return javaType
+}
+
+// csharpType returns the C# type of a scalar, message or enum. Nested messages and enums are generated in the `Types`
+// class of their parent, e.g. `Vehicle.Types.Category`.
+func (t *Template) csharpType(fullType string) string {
+	if scalar := t.scalar(fullType); scalar != nil {
+		return scalar.CSharp
+	}
+
+	_, longName := t.typeFile(fullType)
+	return strings.ReplaceAll(longName, ".", ".Types.")
+}
+
+func (t *Template) scalar(protoType string) *ScalarValue {
+	for _, scalar := range t.Scalars {
+		if scalar.ProtoType == protoType {
+			return scalar
+		}
+	}
+	return nil
+}
+
+// typeFile returns the file defining the message or enum with the given full name, and its name relative to the
+// package. Types that aren't part of the template have no file; well-known types are named after their message, others
+// by their full name.
+func (t *Template) typeFile(fullType string) (*File, string) {
+	name := ""
+	if msg := t.messages[fullType]; msg != nil {
+		name = msg.File
+	} else if enum := t.enums[fullType]; enum != nil {
+		name = enum.File
+	}
+
+	for _, file := range t.Files {
+		if file.Name == name {
+			return file, strings.TrimPrefix(fullType, file.Package+".")
+		}
+	}
+	return nil, strings.TrimPrefix(fullType, "google.protobuf.")
+}
+
+// javaOuterClass returns the name of the outer class protoc generates for a Java file: the `java_outer_classname`
+// option, or the file name in CamelCase (`vehicle_service.proto` becomes `VehicleService`), suffixed with `OuterClass`
+// when a top-level message, enum or service has the same name.
+func javaOuterClass(file *File) string {
+	if name := file.FDS.GetOptions().GetJavaOuterClassname(); name != "" {
+		return name
+	}
+
+	var b strings.Builder
+	upper := true
+	for _, r := range strings.TrimSuffix(path.Base(file.Name), ".proto") {
+		switch {
+		case unicode.IsLetter(r) && upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		case unicode.IsLetter(r):
+			b.WriteRune(r)
+		case unicode.IsDigit(r):
+			b.WriteRune(r)
+			upper = true
+		default:
+			upper = true
+		}
+	}
+
+	name := b.String()
+	for _, msg := range file.FDS.FileDescriptorProto.GetMessageType() {
+		if msg.GetName() == name {
+			return name + "OuterClass"
+		}
+	}
+	for _, enum := range file.FDS.FileDescriptorProto.GetEnumType() {
+		if enum.GetName() == name {
+			return name + "OuterClass"
+		}
+	}
+	for _, service := range file.FDS.FileDescriptorProto.GetService() {
+		if service.GetName() == name {
+			return name + "OuterClass"
+		}
+	}
+	return name
+}
@@ -0,0 +1,141 @@
+package gendoc
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// Kinds of navigation nodes.
+const (
+	NavKindRoot    = "root"
+	NavKindPackage = "package"
+	NavKindFile    = "file"
+	NavKindMessage = "message"
+	NavKindEnum    = "enum"
+	NavKindService = "service"
+)
+
+// NavNode is a node of the navigation tree of a template (see Template.Navigation), e.g. for building the sidebar of a
+// documentation site.
+type NavNode struct {
+	Title string `json:"title"`
+	Kind  string `json:"kind"`
+	// Anchor is the anchor of the heading documenting the node (see Template.Slug). It's empty for the root and
+	// packages.
+	Anchor string `json:"anchor,omitempty"`
+	// URL is the page the template is rendered to (see WithPages) followed by the anchor, e.g.
+	// `acme/index.html#acme-Book`, or just `#` and the anchor without page.
+	URL      string     `json:"url,omitempty"`
+	Children []*NavNode `json:"children,omitempty"`
+}
+
+// Navigation returns the navigation tree of the template: packages, then their files, then the messages, enums and
+// services of the files, in the order they're documented. Like the built-in templates, it leaves out excluded
+// packages, internal messages (e.g. map entries) and inlined messages (see WithInlineNested). Files are titled by the
+// `title` of their front-matter (see File.Meta), or their name.
+func (t *Template) Navigation() *NavNode {
+	root := &NavNode{Title: "Protocol Documentation", Kind: NavKindRoot}
+	for _, pkg := range t.Packages {
+		pkgNode := &NavNode{Title: pkg.Name, Kind: NavKindPackage}
+		for _, file := range t.Files {
+			if file.Package != pkg.Name {
+				continue
+			}
+
+			title := file.Name
+			if meta := file.Meta["title"]; meta != "" {
+				title = meta
+			}
+			fileNode := t.navNode(title, NavKindFile, file.Anchor)
+			for _, msg := range file.VisibleMessages() {
+				if !msg.Inlined() {
+					fileNode.Children = append(fileNode.Children, t.navNode(msg.LongName, NavKindMessage, msg.Anchor))
+				}
+			}
+			for _, enum := range file.Enums {
+				fileNode.Children = append(fileNode.Children, t.navNode(enum.LongName, NavKindEnum, enum.Anchor))
+			}
+			for _, service := range file.Services {
+				fileNode.Children = append(fileNode.Children, t.navNode(service.Name, NavKindService, service.Anchor))
+			}
+			pkgNode.Children = append(pkgNode.Children, fileNode)
+		}
+		root.Children = append(root.Children, pkgNode)
+	}
+	return root
+}
+
+func (t *Template) navNode(title, kind, anchor string) *NavNode {
+	return &NavNode{Title: title, Kind: kind, Anchor: anchor, URL: t.page + "#" + anchor}
+}
+
+// GlossaryEntry is an entry of the glossary of a template (see Template.Glossary).
+type GlossaryEntry struct {
+	// Name is the name of the type without its package and parents, e.g. `Category` for `com.example.Vehicle.Category`.
+	Name     string `json:"name"`
+	FullName string `json:"fullName"`
+	// Kind is NavKindMessage, NavKindEnum or NavKindService.
+	Kind string `json:"kind"`
+	// Summary is the first sentence of the description, on one line.
+	Summary string `json:"summary,omitempty"`
+	Anchor  string `json:"anchor"`
+	// URL is the page the template is rendered to followed by the anchor, like the URLs of NavNode.
+	URL string `json:"url"`
+}
+
+// Glossary returns the messages, enums and services of the template sorted alphabetically by name (ignoring case),
+// across packages, with the first sentence of their description, e.g. for an A–Z page of a large API. Types sharing a
+// name are sorted by full name. Like Navigation, it leaves out internal messages (e.g. map entries).
+func (t *Template) Glossary() []*GlossaryEntry {
+	var entries []*GlossaryEntry
+	add := func(name, fullName, kind, description, anchor string) {
+		entries = append(entries, &GlossaryEntry{
+			Name:     name,
+			FullName: fullName,
+			Kind:     kind,
+			Summary:  firstSentence(description),
+			Anchor:   anchor,
+			URL:      t.page + "#" + anchor,
+		})
+	}
+	for _, file := range t.Files {
+		for _, msg := range file.VisibleMessages() {
+			add(msg.Name, msg.FullName, NavKindMessage, msg.Description, msg.Anchor)
+		}
+		for _, enum := range file.Enums {
+			add(enum.Name, enum.FullName, NavKindEnum, enum.Description, enum.Anchor)
+		}
+		for _, service := range file.Services {
+			add(service.Name, service.FullName, NavKindService, service.Description, service.Anchor)
+		}
+	}
+
+	slices.SortStableFunc(entries, func(a, b *GlossaryEntry) int {
+		if c := strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.FullName, b.FullName)
+	})
+	return entries
+}
+
+// firstSentence returns the text of description up to the first period followed by whitespace, with runs of whitespace
+// (e.g. line breaks) collapsed into single spaces.
+func firstSentence(description string) string {
+	text := strings.Join(strings.Fields(description), " ")
+	if i := strings.Index(text, ". "); i >= 0 {
+		return text[:i+1]
+	}
+	return text
+}
+
+type navigationRenderer struct{}
+
+// Apply renders the navigation tree of the template (see Template.Navigation) as JSON. The page of the template is the
+// JSON file itself, so URLs are left relative to the page documenting the types, i.e. `#` followed by the anchor.
+func (r *navigationRenderer) Apply(template *Template) ([]byte, error) {
+	res := *template
+	res.page = ""
+	return json.MarshalIndent(res.Navigation(), "", "  ")
+}
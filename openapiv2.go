@@ -0,0 +1,139 @@
+package gendoc
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// openAPIv2Number is the field number of the grpc-gateway `openapiv2_operation` extension of
+// google.protobuf.MethodOptions and of the `openapiv2_tag` extension of google.protobuf.ServiceOptions (see
+// protoc-gen-openapiv2/options/annotations.proto).
+const openAPIv2Number = 1042
+
+// OpenAPIOperation is the documentation of a method set by the grpc-gateway
+// `grpc.gateway.protoc_gen_openapiv2.options.openapiv2_operation` option.
+type OpenAPIOperation struct {
+	Summary     string   `json:"summary,omitempty"`
+	Description string   `json:"description,omitempty"`
+	OperationID string   `json:"operationId,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Deprecated  bool     `json:"deprecated,omitempty"`
+	// Security lists the alternative security requirements of the operation. Each maps the names of the security
+	// schemes that must all be satisfied to the scopes they require, e.g. `{"OAuth2": ["read", "write"]}`.
+	Security     []map[string][]string `json:"security,omitempty"`
+	ExternalDocs *OpenAPIExternalDocs  `json:"externalDocs,omitempty"`
+}
+
+// OpenAPITag is the documentation of a service set by the grpc-gateway
+// `grpc.gateway.protoc_gen_openapiv2.options.openapiv2_tag` option.
+type OpenAPITag struct {
+	Name         string               `json:"name,omitempty"`
+	Description  string               `json:"description,omitempty"`
+	ExternalDocs *OpenAPIExternalDocs `json:"externalDocs,omitempty"`
+}
+
+// OpenAPIExternalDocs points to additional documentation of an operation or tag.
+type OpenAPIExternalDocs struct {
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"`
+}
+
+// parseOpenAPIOperation returns the openapiv2_operation annotation, read from the unknown fields of the options, or nil
+// if there's none.
+func parseOpenAPIOperation(opts protoreflect.ProtoMessage) *OpenAPIOperation {
+	b, ok := unknownMessage(opts, openAPIv2Number)
+	if !ok {
+		return nil
+	}
+
+	op := new(OpenAPIOperation)
+	forEachField(b, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			op.Tags = append(op.Tags, string(v))
+		case num == 2 && typ == protowire.BytesType:
+			op.Summary = string(v)
+		case num == 3 && typ == protowire.BytesType:
+			op.Description = string(v)
+		case num == 4 && typ == protowire.BytesType:
+			op.ExternalDocs = parseOpenAPIExternalDocs(v)
+		case num == 5 && typ == protowire.BytesType:
+			op.OperationID = string(v)
+		case num == 11 && typ == protowire.VarintType:
+			op.Deprecated = x != 0
+		case num == 12 && typ == protowire.BytesType:
+			op.Security = append(op.Security, parseOpenAPISecurityRequirement(v))
+		}
+	})
+	return op
+}
+
+// parseOpenAPITag returns the openapiv2_tag annotation, read from the unknown fields of the options, or nil if there's
+// none.
+func parseOpenAPITag(opts protoreflect.ProtoMessage) *OpenAPITag {
+	b, ok := unknownMessage(opts, openAPIv2Number)
+	if !ok {
+		return nil
+	}
+
+	tag := new(OpenAPITag)
+	forEachField(b, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) {
+		if typ != protowire.BytesType {
+			return
+		}
+		switch num {
+		case 2:
+			tag.Description = string(v)
+		case 3:
+			tag.ExternalDocs = parseOpenAPIExternalDocs(v)
+		case 5:
+			tag.Name = string(v)
+		}
+	})
+	return tag
+}
+
+// parseOpenAPIExternalDocs decodes an encoded ExternalDocumentation.
+func parseOpenAPIExternalDocs(b []byte) *OpenAPIExternalDocs {
+	docs := new(OpenAPIExternalDocs)
+	forEachField(b, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) {
+		if typ != protowire.BytesType {
+			return
+		}
+		switch num {
+		case 1:
+			docs.Description = string(v)
+		case 2:
+			docs.URL = string(v)
+		}
+	})
+	return docs
+}
+
+// parseOpenAPISecurityRequirement decodes an encoded SecurityRequirement, a map of security scheme names to
+// SecurityRequirementValues listing their scopes.
+func parseOpenAPISecurityRequirement(b []byte) map[string][]string {
+	requirement := map[string][]string{}
+	forEachField(b, func(num protowire.Number, typ protowire.Type, entry []byte, _ uint64) {
+		if num != 1 || typ != protowire.BytesType {
+			return
+		}
+
+		var name string
+		var scopes []string
+		forEachField(entry, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) {
+			switch {
+			case num == 1 && typ == protowire.BytesType:
+				name = string(v)
+			case num == 2 && typ == protowire.BytesType:
+				forEachField(v, func(num protowire.Number, typ protowire.Type, scope []byte, _ uint64) {
+					if num == 1 && typ == protowire.BytesType {
+						scopes = append(scopes, string(scope))
+					}
+				})
+			}
+		})
+		requirement[name] = scopes
+	})
+	return requirement
+}
@@ -0,0 +1,74 @@
+package gendoc
+
+import "sort"
+
+// maxOptionExamples is the number of locations listed per option by Template.UsedOptions.
+const maxOptionExamples = 3
+
+// OptionUsage describes how often an option is set across the documented files, and where.
+type OptionUsage struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	// Kinds are the kinds of entities the option is set on, sorted: `enum`, `enum value`, `field`, `file`, `message`,
+	// `method` and `service`.
+	Kinds []string `json:"kinds"`
+	// Examples are the (full) names of the first few entities the option is set on, e.g. `com.example.Vehicle.id` for a
+	// field.
+	Examples []string `json:"examples"`
+}
+
+// UsedOptions returns every distinct option (standard and custom ones) set on the files, messages, fields, enums, enum
+// values, services and methods of the template, sorted by name. It helps to spot options that are used inconsistently.
+func (t *Template) UsedOptions() []*OptionUsage {
+	usages := make(map[string]*OptionUsage)
+	kinds := make(map[string]map[string]bool)
+	add := func(kind, location string, options map[string]interface{}) {
+		for name := range options {
+			usage, ok := usages[name]
+			if !ok {
+				usage = &OptionUsage{Name: name}
+				usages[name] = usage
+				kinds[name] = make(map[string]bool)
+			}
+			usage.Count++
+			if len(usage.Examples) < maxOptionExamples {
+				usage.Examples = append(usage.Examples, location)
+			}
+			if !kinds[name][kind] {
+				kinds[name][kind] = true
+				usage.Kinds = append(usage.Kinds, kind)
+			}
+		}
+	}
+
+	for _, file := range t.Files {
+		add("file", file.Name, file.Options)
+		for _, msg := range file.VisibleMessages() {
+			add("message", msg.FullName, msg.Options)
+			for _, field := range msg.allFields() {
+				add("field", msg.FullName+"."+field.Name, field.Options)
+			}
+		}
+		for _, enum := range file.Enums {
+			add("enum", enum.FullName, enum.Options)
+			for _, value := range enum.Values {
+				add("enum value", enum.FullName+"."+value.Name, value.Options)
+			}
+		}
+		for _, service := range file.Services {
+			add("service", service.FullName, service.Options)
+			for _, method := range service.Methods {
+				add("method", service.FullName+"."+method.Name, method.Options)
+			}
+		}
+	}
+
+	list := make([]*OptionUsage, 0, len(usages))
+	for _, usage := range usages {
+		sort.Strings(usage.Kinds)
+		list = append(list, usage)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
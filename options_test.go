@@ -0,0 +1,41 @@
+package gendoc_test
+
+import (
+	"testing"
+
+	. "github.com/pseudomuto/protoc-gen-doc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsedOptions(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		options: { go_package: "example.com/test" }
+		message_type: {
+			name: "Book"
+			field: { name: "a" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32 options: { deprecated: true } }
+			field: { name: "b" number: 2 label: LABEL_OPTIONAL type: TYPE_INT32 options: { deprecated: true } }
+			field: { name: "c" number: 3 label: LABEL_OPTIONAL type: TYPE_INT32 options: { deprecated: true } }
+			field: { name: "d" number: 4 label: LABEL_OPTIONAL type: TYPE_INT32 options: { packed: true } }
+		}
+		message_type: { name: "Old" options: { deprecated: true } }
+		service: {
+			name: "Library"
+			method: { name: "Get" input_type: ".test.Book" output_type: ".test.Book" options: { deprecated: true } }
+		}
+	`)
+
+	require.Equal(t, []*OptionUsage{
+		{
+			Name:     "deprecated",
+			Count:    5,
+			Kinds:    []string{"field", "message", "method"},
+			Examples: []string{"test.Book.a", "test.Book.b", "test.Book.c"},
+		},
+		{Name: "goPackage", Count: 1, Kinds: []string{"file"}, Examples: []string{"api.proto"}},
+		{Name: "packed", Count: 1, Kinds: []string{"field"}, Examples: []string{"test.Book.d"}},
+	}, tmpl.UsedOptions())
+
+	require.NotEmpty(t, template.UsedOptions())
+}
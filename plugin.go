@@ -1,26 +1,54 @@
 package gendoc
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/golang/protobuf/proto"
 	plugin_go "github.com/golang/protobuf/protoc-gen-go/plugin"
 	"github.com/pseudomuto/protokit"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 // PluginOptions encapsulates options for the plugin. The type of renderer, template file, and the name of the output
 // file are included.
 type PluginOptions struct {
-	Type            RenderType
-	TemplateFile    string
-	OutputFile      string
-	ExcludePatterns []*regexp.Regexp
-	SourceRelative  bool
+	Type             RenderType
+	TemplateFile     string
+	OutputFile       string
+	ExcludePatterns  []*regexp.Regexp
+	SourceRelative   bool
+	OmitInternal     bool
+	TemplateDir      string
+	EnumHex          bool
+	AnchorMode       AnchorMode
+	ModulesFile      string
+	SnippetsFile     string
+	DiffBaseFile     string
+	TrailingComments bool
+	ExcludePackages  []string
+	ExternalBaseURL  string
+	SourceBaseURL    string
+	OptionNames      map[CustomOption]string
+	Visibility       Visibility
+	InlineNested     bool
+	MapNotes         bool
+	PackageOverview  bool
+	Language         string
+	Direction        TextDirection
+	TypeLanguage     TypeLanguage
+	RenderMode       RenderMode
+	AutoLink         AutoLink
+	HeadingOffset    int
+	LinkStyle        LinkStyle
 }
 
 // SupportedFeatures describes a flag setting for supported features.
@@ -50,10 +78,61 @@ func (p *Plugin) Generate(r *plugin_go.CodeGeneratorRequest) (*plugin_go.CodeGen
 		customTemplate = string(data)
 	}
 
+	templateOptions := append(options.templateOptions(), WithProtoFiles(r.GetProtoFile()))
+	if options.ModulesFile != "" {
+		data, err := ioutil.ReadFile(options.ModulesFile)
+		if err != nil {
+			return nil, err
+		}
+
+		modules := map[string]string{}
+		if err := json.Unmarshal(data, &modules); err != nil {
+			return nil, fmt.Errorf("Invalid modules file %s: %w", options.ModulesFile, err)
+		}
+		templateOptions = append(templateOptions, WithModules(modules))
+	}
+	if options.SnippetsFile != "" {
+		data, err := ioutil.ReadFile(options.SnippetsFile)
+		if err != nil {
+			return nil, err
+		}
+
+		snippets := map[string]string{}
+		if err := json.Unmarshal(data, &snippets); err != nil {
+			return nil, fmt.Errorf("Invalid snippets file %s: %w", options.SnippetsFile, err)
+		}
+		templateOptions = append(templateOptions, WithSnippets(snippets))
+	}
+	var base *plugin_go.CodeGeneratorRequest
+	if options.DiffBaseFile != "" {
+		data, err := ioutil.ReadFile(options.DiffBaseFile)
+		if err != nil {
+			return nil, err
+		}
+
+		base, err = diffBase(data, r)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid diff base %s: %w", options.DiffBaseFile, err)
+		}
+	}
+
 	resp := new(plugin_go.CodeGeneratorResponse)
+	pages := typePages(result, options)
 	fdsGroup := groupProtosByDirectory(result, options.SourceRelative)
+	var baseGroup map[string][]*protokit.FileDescriptor
+	if base != nil {
+		baseResult := excludeUnwantedProtos(protokit.ParseCodeGenRequest(base), options.ExcludePatterns)
+		baseGroup = groupProtosByDirectory(baseResult, options.SourceRelative)
+	}
 	for dir, fds := range fdsGroup {
-		template := NewTemplate(fds)
+		page := filepath.Join(dir, options.OutputFile)
+		opts := append(slices.Clone(templateOptions), WithPages(page, pages))
+		if base != nil {
+			// the previous version of the files of the output only
+			baseOpts := append(slices.Clone(templateOptions), WithProtoFiles(base.GetProtoFile()))
+			opts = append(opts, WithDiffBase(NewTemplate(baseGroup[dir], baseOpts...)))
+		}
+		template := NewTemplate(fds, opts...)
 
 		output, err := RenderTemplate(options.Type, template, customTemplate)
 		if err != nil {
@@ -71,22 +150,133 @@ func (p *Plugin) Generate(r *plugin_go.CodeGeneratorRequest) (*plugin_go.CodeGen
 	return resp, nil
 }
 
+// diffBase creates the request of the previous version of the files from its (serialized) descriptor set. The files
+// the request only imports (e.g. well-known types) aren't documented in either version, so they're left out.
+func diffBase(data []byte, r *plugin_go.CodeGeneratorRequest) (*plugin_go.CodeGeneratorRequest, error) {
+	fds := new(descriptorpb.FileDescriptorSet)
+	if err := proto.Unmarshal(data, fds); err != nil {
+		return nil, err
+	}
+	if _, err := protodesc.NewFiles(fds); err != nil {
+		return nil, err
+	}
+
+	imported := map[string]bool{}
+	for _, file := range r.GetProtoFile() {
+		imported[file.GetName()] = !slices.Contains(r.GetFileToGenerate(), file.GetName())
+	}
+
+	req := &plugin_go.CodeGeneratorRequest{ProtoFile: fds.GetFile()}
+	for _, file := range fds.GetFile() {
+		if !imported[file.GetName()] {
+			req.FileToGenerate = append(req.FileToGenerate, file.GetName())
+		}
+	}
+	return req, nil
+}
+
+func (o *PluginOptions) templateOptions() []TemplateOption {
+	opts := []TemplateOption{
+		WithOmitInternal(o.OmitInternal),
+		WithTemplateDir(o.TemplateDir),
+		WithEnumHex(o.EnumHex),
+		WithAnchorMode(o.AnchorMode),
+		WithTrailingComments(o.TrailingComments),
+		WithExcludedPackages(o.ExcludePackages),
+		WithExternalBaseURL(o.ExternalBaseURL),
+		WithSourceBaseURL(o.SourceBaseURL),
+		WithVisibility(o.Visibility),
+		WithInlineNested(o.InlineNested),
+		WithMapNotes(o.MapNotes),
+		WithPackageOverview(o.PackageOverview),
+		WithLanguage(o.Language),
+		WithDirection(o.Direction),
+		WithTypeLanguage(o.TypeLanguage),
+		WithRenderMode(o.RenderMode),
+		WithAutoLink(o.AutoLink),
+		WithHeadingOffset(o.HeadingOffset),
+		WithLinkStyle(o.LinkStyle),
+	}
+	for option, name := range o.OptionNames {
+		opts = append(opts, WithOptionName(option, name))
+	}
+	return opts
+}
+
 func groupProtosByDirectory(fds []*protokit.FileDescriptor, sourceRelative bool) map[string][]*protokit.FileDescriptor {
 	fdsGroup := make(map[string][]*protokit.FileDescriptor)
 
 	for _, fd := range fds {
-		dir := ""
-		if sourceRelative {
-			dir, _ = filepath.Split(fd.GetName())
-		}
-		if dir == "" {
-			dir = "./"
-		}
+		dir := outputDir(fd, sourceRelative)
 		fdsGroup[dir] = append(fdsGroup[dir], fd)
 	}
 	return fdsGroup
 }
 
+// outputDir returns the directory the documentation of the file is written to.
+func outputDir(fd *protokit.FileDescriptor, sourceRelative bool) string {
+	dir := ""
+	if sourceRelative {
+		dir, _ = filepath.Split(fd.GetName())
+	}
+	if dir == "" {
+		dir = "./"
+	}
+	return dir
+}
+
+// typePages maps the full names of the messages and enums (nested ones included) of the files to the paths of the
+// output files documenting them.
+func typePages(fds []*protokit.FileDescriptor, options *PluginOptions) map[string]string {
+	pages := make(map[string]string)
+	for _, fd := range fds {
+		page := filepath.Join(outputDir(fd, options.SourceRelative), options.OutputFile)
+
+		var addMessages func([]*protokit.Descriptor)
+		addMessages = func(msgs []*protokit.Descriptor) {
+			for _, m := range msgs {
+				pages[m.GetFullName()] = page
+				for _, e := range m.Enums {
+					pages[e.GetFullName()] = page
+				}
+				addMessages(m.Messages)
+			}
+		}
+		addMessages(fd.Messages)
+		for _, e := range fd.Enums {
+			pages[e.GetFullName()] = page
+		}
+	}
+	return pages
+}
+
+// excludePatternsIndex returns the index of the colon separating the exclude patterns from the other parameters, or -1
+// when there's none. Colons of URLs, after their scheme and before their port (e.g. in
+// `external_url=http://localhost:8080/docs`), don't separate them.
+func excludePatternsIndex(params string) int {
+	host := false // whether params[i] is part of the host of a URL
+	for i := 0; i < len(params); i++ {
+		switch {
+		case strings.HasPrefix(params[i:], "://"):
+			host = true
+			i += len("://") - 1
+		case params[i] == ':' && host && isPort(params[i+1:]):
+			host = false
+		case params[i] == ':':
+			return i
+		case strings.ContainsRune("/?#,", rune(params[i])):
+			host = false
+		}
+	}
+	return -1
+}
+
+// isPort tells whether s starts with the port of a URL, i.e. digits up to the end of the host.
+func isPort(s string) bool {
+	digits := len(s) - len(strings.TrimLeft(s, "0123456789"))
+	return digits > 0 && (digits == len(s) || strings.ContainsRune("/?#,:", rune(s[digits])))
+}
+
 func excludeUnwantedProtos(fds []*protokit.FileDescriptor, excludePatterns []*regexp.Regexp) []*protokit.FileDescriptor {
 	descs := make([]*protokit.FileDescriptor, 0)
 
@@ -107,21 +297,55 @@ OUTER:
 // ParseOptions parses plugin options from a CodeGeneratorRequest. It does this by splitting the `Parameter` field from
 // the request object and parsing out the type of renderer to use and the name of the file to be generated.
 //
-// The parameter (`--doc_opt`) must be of the format <TYPE|TEMPLATE_FILE>,<OUTPUT_FILE>[,default|source_relative]:<EXCLUDE_PATTERN>,<EXCLUDE_PATTERN>*.
+// The parameter (`--doc_opt`) must be of the format <TYPE|TEMPLATE_FILE>,<OUTPUT_FILE>[,default|source_relative][,<FLAG>]*:<EXCLUDE_PATTERN>,<EXCLUDE_PATTERN>*.
 // The file will be written to the directory specified with the `--doc_out` argument to protoc.
+//
+// Supported flags are:
+//   - omit_internal: drop internal messages (e.g. map entries) from the JSON output
+//   - template_dir=<DIR>: override named templates with the `<name>.tmpl` files in DIR
+//   - enum_hex: render the numbers of enum values in hexadecimal
+//   - anchors=<MODE>: the anchors of Markdown headings, `default` or `github`
+//   - modules=<FILE>: a JSON object mapping file names to the names of the modules owning them
+//   - snippets=<FILE>: a JSON object mapping the names of the snippets comments include (`@include <name>`) to them
+//   - diff_base=<FILE>: the descriptor set of the previous version of the files, which the `diff` format is rendered
+//     against (required by it)
+//   - trailing_comments: describe fields and enum values by their trailing comments when they have any
+//   - exclude_package=<PACKAGE>: leave out the files of PACKAGE and its sub-packages, may be given multiple times
+//   - external_url=<URL>: link the types that aren't generated (other than well-known types) to URL + full name
+//   - source_url=<URL>: link messages, enums, services and fields to their source by URL, with `{file}` and `{line}`
+//     placeholders
+//   - <CUSTOM>_option=<OPTION>: the option read as a CustomOption (`docs.<CUSTOM>` by default), e.g.
+//     `category_option=acme.category`
+//   - visibility=<LEVEL>: the fields to document by their visibility option, `all` (the default), `public` or `internal`
+//   - inline_nested: document nested messages used by a single field along with the message using them
+//   - map_notes: follow the fields of messages with a note on the key and value types of each map field
+//   - package_overview: introduce every package with the descriptions of its files
+//   - lang=<LANG>: the language of the descriptions, declared by the HTML output (e.g. `ja`)
+//   - dir=<DIR>: the direction of the text of the HTML output, `ltr`, `rtl` or `auto`
+//   - type_lang=<LANG>: show the types of fields in the code generated for LANG next to them, `java` or `csharp`
+//   - mode=<MODE>: `full` (the default) or `compact`, which leaves out descriptions and options
+//   - autolink=<MODE>: link the names of types mentioned in descriptions, `off` (the default), `full` or `short`
+//   - heading_offset=<N>: shift the headings of the Markdown, MDX and API reference outputs down by N levels
+//   - link_style=<STYLE>: link the types of fields and methods in the Markdown output `inline` (the default) or with
+//     `footnote`s
 func ParseOptions(req *plugin_go.CodeGeneratorRequest) (*PluginOptions, error) {
 	options := &PluginOptions{
 		Type:           RenderTypeHTML,
 		TemplateFile:   "",
 		OutputFile:     "index.html",
 		SourceRelative: false,
+		AnchorMode:     AnchorModeDefault,
+		Visibility:     VisibilityAll,
+		RenderMode:     RenderModeFull,
+		AutoLink:       AutoLinkOff,
+		LinkStyle:      LinkStyleInline,
 	}
 
 	params := req.GetParameter()
-	if strings.Contains(params, ":") {
+	if sep := excludePatternsIndex(params); sep >= 0 {
 		// Parse out exclude patterns if any
-		parts := strings.Split(params, ":")
-		for _, pattern := range strings.Split(parts[1], ",") {
+		patterns, _, _ := strings.Cut(params[sep+1:], ":")
+		for _, pattern := range strings.Split(patterns, ",") {
 			r, err := regexp.Compile(pattern)
 			if err != nil {
 				return nil, err
@@ -129,7 +353,7 @@ func ParseOptions(req *plugin_go.CodeGeneratorRequest) (*PluginOptions, error) {
 			options.ExcludePatterns = append(options.ExcludePatterns, r)
 		}
 		// The first part is parsed below
-		params = parts[0]
+		params = params[:sep]
 	}
 	if params == "" {
 		return options, nil
@@ -140,29 +364,141 @@ func ParseOptions(req *plugin_go.CodeGeneratorRequest) (*PluginOptions, error) {
 	}
 
 	parts := strings.Split(params, ",")
-	if len(parts) < 2 || len(parts) > 3 {
+	if len(parts) < 2 {
 		return nil, fmt.Errorf("Invalid parameter: %s", params)
 	}
 
 	options.TemplateFile = parts[0]
 	options.OutputFile = path.Base(parts[1])
-	if len(parts) > 2 {
-		switch parts[2] {
+	for _, flag := range parts[2:] {
+		name, value, _ := strings.Cut(flag, "=")
+		switch name {
 		case "source_relative":
 			options.SourceRelative = true
 		case "default":
 			options.SourceRelative = false
+		case "omit_internal":
+			options.OmitInternal = true
+		case "enum_hex":
+			options.EnumHex = true
+		case "trailing_comments":
+			options.TrailingComments = true
+		case "inline_nested":
+			options.InlineNested = true
+		case "map_notes":
+			options.MapNotes = true
+		case "package_overview":
+			options.PackageOverview = true
+		case "modules":
+			if value == "" {
+				return nil, fmt.Errorf("Invalid parameter: %s", params)
+			}
+			options.ModulesFile = value
+		case "snippets":
+			if value == "" {
+				return nil, fmt.Errorf("Invalid parameter: %s", params)
+			}
+			options.SnippetsFile = value
+		case "diff_base":
+			if value == "" {
+				return nil, fmt.Errorf("Invalid parameter: %s", params)
+			}
+			options.DiffBaseFile = value
+		case "anchors":
+			mode, err := NewAnchorMode(value)
+			if err != nil {
+				return nil, err
+			}
+			options.AnchorMode = mode
+		case "exclude_package":
+			if value == "" {
+				return nil, fmt.Errorf("Invalid parameter: %s", params)
+			}
+			options.ExcludePackages = append(options.ExcludePackages, value)
+		case "external_url":
+			if value == "" {
+				return nil, fmt.Errorf("Invalid parameter: %s", params)
+			}
+			options.ExternalBaseURL = value
+		case "source_url":
+			if value == "" {
+				return nil, fmt.Errorf("Invalid parameter: %s", params)
+			}
+			options.SourceBaseURL = value
+		case "visibility":
+			visibility, err := NewVisibility(value)
+			if err != nil {
+				return nil, err
+			}
+			options.Visibility = visibility
+		case "lang":
+			if value == "" {
+				return nil, fmt.Errorf("Invalid parameter: %s", params)
+			}
+			options.Language = value
+		case "dir":
+			dir, err := NewTextDirection(value)
+			if err != nil {
+				return nil, err
+			}
+			options.Direction = dir
+		case "type_lang":
+			lang, err := NewTypeLanguage(value)
+			if err != nil {
+				return nil, err
+			}
+			options.TypeLanguage = lang
+		case "mode":
+			mode, err := NewRenderMode(value)
+			if err != nil {
+				return nil, err
+			}
+			options.RenderMode = mode
+		case "heading_offset":
+			offset, err := strconv.Atoi(value)
+			if err != nil || offset < 0 {
+				return nil, fmt.Errorf("Invalid heading offset: %s", value)
+			}
+			options.HeadingOffset = offset
+		case "autolink":
+			mode, err := NewAutoLink(value)
+			if err != nil {
+				return nil, err
+			}
+			options.AutoLink = mode
+		case "link_style":
+			style, err := NewLinkStyle(value)
+			if err != nil {
+				return nil, err
+			}
+			options.LinkStyle = style
+		case "template_dir":
+			if value == "" {
+				return nil, fmt.Errorf("Invalid parameter: %s", params)
+			}
+			options.TemplateDir = value
 		default:
-			return nil, fmt.Errorf("Invalid parameter: %s", params)
+			// e.g. `category_option=acme.category`, see CustomOption
+			custom, ok := strings.CutSuffix(name, "_option")
+			option, err := NewCustomOption(custom)
+			if !ok || err != nil || value == "" {
+				return nil, fmt.Errorf("Invalid parameter: %s", params)
+			}
+			if options.OptionNames == nil {
+				options.OptionNames = make(map[CustomOption]string)
+			}
+			options.OptionNames[option] = value
 		}
 	}
-	options.SourceRelative = len(parts) > 2 && parts[2] == "source_relative"
 
 	renderType, err := NewRenderType(options.TemplateFile)
 	if err == nil {
 		options.Type = renderType
 		options.TemplateFile = ""
 	}
+	if options.Type == RenderTypeDiff && options.DiffBaseFile == "" {
+		return nil, fmt.Errorf("The diff format needs the previous version of the files (diff_base=<FILE>): %s", params)
+	}
 
 	return options, nil
 }
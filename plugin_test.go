@@ -1,6 +1,8 @@
 package gendoc_test
 
 import (
+	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
 
@@ -13,10 +15,21 @@ import (
 
 func TestParseOptionsForBuiltinTemplates(t *testing.T) {
 	results := map[string]string{
-		"docbook":  "output.xml",
-		"html":     "output.html",
-		"json":     "output.json",
-		"markdown": "output.md",
+		"apiref":     "output.md",
+		"docbook":    "output.xml",
+		"graph":      "output.graph.json",
+		"grpc":       "output.txt",
+		"html":       "output.html",
+		"json":       "output.json",
+		"jsonschema": "output.schema.json",
+		"markdown":   "output.md",
+		"mdx":        "output.mdx",
+		"navigation": "output.nav.json",
+		"postman":    "output.postman_collection.json",
+		"text":       "output.txt",
+		"tsenums":    "output.ts",
+		"typescript": "output.d.ts",
+		"xlsx":       "output.xlsx",
 	}
 
 	for kind, file := range results {
@@ -53,6 +66,371 @@ func TestParseOptionsForSourceRelative(t *testing.T) {
 	require.Equal(t, options.SourceRelative, false)
 }
 
+func TestParseOptionsForOmitInternal(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("json,index.json,omit_internal")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.True(t, options.OmitInternal)
+	require.False(t, options.SourceRelative)
+
+	req.Parameter = proto.String("json,index.json,source_relative,omit_internal")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.True(t, options.OmitInternal)
+	require.True(t, options.SourceRelative)
+}
+
+func TestParseOptionsForTemplateDir(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("markdown,index.md,template_dir=templates/partials")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, RenderTypeMarkdown, options.Type)
+	require.Equal(t, "templates/partials", options.TemplateDir)
+
+	req.Parameter = proto.String("markdown,index.md,template_dir=")
+	_, err = ParseOptions(req)
+	require.Error(t, err)
+}
+
+func TestParseOptionsForEnumHex(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("markdown,index.md,enum_hex")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.True(t, options.EnumHex)
+
+	req.Parameter = proto.String("markdown,index.md")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.False(t, options.EnumHex)
+}
+
+func TestParseOptionsForTrailingComments(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("markdown,index.md,trailing_comments")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.True(t, options.TrailingComments)
+
+	req.Parameter = proto.String("markdown,index.md")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.False(t, options.TrailingComments)
+}
+
+func TestParseOptionsForInlineNested(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("markdown,index.md,inline_nested")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.True(t, options.InlineNested)
+
+	req.Parameter = proto.String("markdown,index.md")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.False(t, options.InlineNested)
+}
+
+func TestParseOptionsForMapNotes(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("markdown,index.md,map_notes")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.True(t, options.MapNotes)
+
+	req.Parameter = proto.String("markdown,index.md")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.False(t, options.MapNotes)
+}
+
+func TestParseOptionsForPackageOverview(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("html,index.html,package_overview")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.True(t, options.PackageOverview)
+
+	req.Parameter = proto.String("html,index.html")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.False(t, options.PackageOverview)
+}
+
+func TestParseOptionsForExcludePackages(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("markdown,index.md,exclude_package=google.protobuf,exclude_package=grpc")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, []string{"google.protobuf", "grpc"}, options.ExcludePackages)
+
+	req.Parameter = proto.String("markdown,index.md,exclude_package=")
+	_, err = ParseOptions(req)
+	require.Error(t, err)
+}
+
+func TestParseOptionsForExternalBaseURL(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("markdown,index.md,external_url=https://docs.example.com/#")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, "https://docs.example.com/#", options.ExternalBaseURL)
+	require.Empty(t, options.ExcludePatterns)
+
+	req.Parameter = proto.String("markdown,index.md,external_url=https://docs.example.com/#:google/*")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, "https://docs.example.com/#", options.ExternalBaseURL)
+	require.Len(t, options.ExcludePatterns, 1)
+
+	req.Parameter = proto.String("markdown,index.md,external_url=http://localhost:8080/docs#,mode=compact:google/*,other/*")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:8080/docs#", options.ExternalBaseURL)
+	require.Equal(t, RenderModeCompact, options.RenderMode)
+	require.Len(t, options.ExcludePatterns, 2)
+
+	req.Parameter = proto.String("markdown,index.md,external_url=http://localhost:8080:google/*")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:8080", options.ExternalBaseURL)
+	require.Len(t, options.ExcludePatterns, 1)
+
+	req.Parameter = proto.String("markdown,index.md,external_url=")
+	_, err = ParseOptions(req)
+	require.Error(t, err)
+}
+
+func TestParseOptionsForSourceBaseURL(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("markdown,index.md,source_url=https://github.com/org/repo/blob/main/{file}#L{line}:google/*")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, "https://github.com/org/repo/blob/main/{file}#L{line}", options.SourceBaseURL)
+	require.Len(t, options.ExcludePatterns, 1)
+
+	req.Parameter = proto.String("markdown,index.md,source_url=https://git.example:8443/org/repo/{file}#L{line}")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, "https://git.example:8443/org/repo/{file}#L{line}", options.SourceBaseURL)
+	require.Empty(t, options.ExcludePatterns)
+
+	req.Parameter = proto.String("markdown,index.md,source_url=")
+	_, err = ParseOptions(req)
+	require.Error(t, err)
+}
+
+func TestParseOptionsForCustomOptions(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("markdown,index.md")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.Empty(t, options.OptionNames)
+
+	req.Parameter = proto.String("markdown,index.md,category_option=acme.category,order_option=acme.order," +
+		"replaced_by_option=acme.successor,visibility_option=acme.visibility,example_option=acme.docs.example," +
+		"format_option=acme.format,unit_option=acme.unit,tags_option=acme.tags")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, map[CustomOption]string{
+		CategoryOption:   "acme.category",
+		OrderOption:      "acme.order",
+		ReplacedByOption: "acme.successor",
+		VisibilityOption: "acme.visibility",
+		ExampleOption:    "acme.docs.example",
+		FormatOption:     "acme.format",
+		UnitOption:       "acme.unit",
+		TagsOption:       "acme.tags",
+	}, options.OptionNames)
+
+	for _, param := range []string{"category_option=", "tags_option=", "color_option=acme.color", "_option=acme"} {
+		req.Parameter = proto.String("markdown,index.md," + param)
+		_, err = ParseOptions(req)
+		require.Error(t, err, param)
+	}
+}
+
+func TestParseOptionsForVisibility(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("markdown,index.md")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, VisibilityAll, options.Visibility)
+
+	req.Parameter = proto.String("markdown,index.md,visibility=public")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, VisibilityPublic, options.Visibility)
+
+	req.Parameter = proto.String("markdown,index.md,visibility=secret")
+	_, err = ParseOptions(req)
+	require.Error(t, err)
+}
+
+func TestParseOptionsForLanguageAndDirection(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("html,index.html")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.Empty(t, options.Language)
+	require.Empty(t, options.Direction)
+
+	req.Parameter = proto.String("html,index.html,lang=ar,dir=rtl")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, "ar", options.Language)
+	require.Equal(t, DirectionRTL, options.Direction)
+
+	req.Parameter = proto.String("html,index.html,dir=down")
+	_, err = ParseOptions(req)
+	require.Error(t, err)
+}
+
+func TestParseOptionsForTypeLanguage(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("markdown,index.md")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.Empty(t, options.TypeLanguage)
+
+	req.Parameter = proto.String("markdown,index.md,type_lang=java")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, TypeLanguageJava, options.TypeLanguage)
+
+	req.Parameter = proto.String("markdown,index.md,type_lang=cobol")
+	_, err = ParseOptions(req)
+	require.Error(t, err)
+}
+
+func TestParseOptionsForRenderMode(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("markdown,index.md")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, RenderModeFull, options.RenderMode)
+
+	req.Parameter = proto.String("markdown,index.md,mode=compact")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, RenderModeCompact, options.RenderMode)
+
+	req.Parameter = proto.String("markdown,index.md,mode=tiny")
+	_, err = ParseOptions(req)
+	require.Error(t, err)
+}
+
+func TestParseOptionsForHeadingOffset(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("markdown,index.md")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.Zero(t, options.HeadingOffset)
+
+	req.Parameter = proto.String("markdown,index.md,heading_offset=2")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, 2, options.HeadingOffset)
+
+	for _, value := range []string{"-1", "two", ""} {
+		req.Parameter = proto.String("markdown,index.md,heading_offset=" + value)
+		_, err = ParseOptions(req)
+		require.Error(t, err)
+	}
+}
+
+func TestParseOptionsForAutoLink(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("markdown,index.md")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, AutoLinkOff, options.AutoLink)
+
+	req.Parameter = proto.String("markdown,index.md,autolink=short")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, AutoLinkShort, options.AutoLink)
+
+	req.Parameter = proto.String("markdown,index.md,autolink=all")
+	_, err = ParseOptions(req)
+	require.Error(t, err)
+}
+
+func TestParseOptionsForLinkStyle(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("markdown,index.md")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, LinkStyleInline, options.LinkStyle)
+
+	req.Parameter = proto.String("markdown,index.md,link_style=footnote")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, LinkStyleFootnote, options.LinkStyle)
+
+	req.Parameter = proto.String("markdown,index.md,link_style=reference")
+	_, err = ParseOptions(req)
+	require.Error(t, err)
+}
+
+func TestParseOptionsForAnchors(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("markdown,index.md")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, AnchorModeDefault, options.AnchorMode)
+
+	req.Parameter = proto.String("markdown,index.md,anchors=github")
+	options, err = ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, AnchorModeGitHub, options.AnchorMode)
+
+	req.Parameter = proto.String("markdown,index.md,anchors=gitlab")
+	_, err = ParseOptions(req)
+	require.Error(t, err)
+}
+
+func TestParseOptionsForModules(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("json,index.json,modules=modules.json")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, "modules.json", options.ModulesFile)
+
+	req.Parameter = proto.String("json,index.json,modules=")
+	_, err = ParseOptions(req)
+	require.Error(t, err)
+}
+
+func TestParseOptionsForSnippets(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("markdown,index.md,snippets=snippets.json")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, "snippets.json", options.SnippetsFile)
+
+	req.Parameter = proto.String("markdown,index.md,snippets=")
+	_, err = ParseOptions(req)
+	require.Error(t, err)
+}
+
+func TestParseOptionsForDiffBase(t *testing.T) {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.Parameter = proto.String("diff,CHANGELOG.md,diff_base=base.binpb")
+	options, err := ParseOptions(req)
+	require.NoError(t, err)
+	require.Equal(t, RenderTypeDiff, options.Type)
+	require.Equal(t, "base.binpb", options.DiffBaseFile)
+
+	for _, param := range []string{"diff,CHANGELOG.md", "diff,CHANGELOG.md,diff_base="} {
+		req.Parameter = proto.String(param)
+		_, err = ParseOptions(req)
+		require.Error(t, err)
+	}
+}
+
 func TestParseOptionsForCustomTemplate(t *testing.T) {
 	req := new(plugin_go.CodeGeneratorRequest)
 	req.Parameter = proto.String("/path/to/template.tmpl,/base/name/only/output.md")
@@ -98,7 +476,8 @@ func TestParseOptionsWithInvalidValues(t *testing.T) {
 }
 
 func TestRunPluginForBuiltinTemplate(t *testing.T) {
-	set, _ := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
 	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto", "nested/Book.proto")
 	req.Parameter = proto.String("markdown,/base/name/only/output.md")
 
@@ -111,7 +490,8 @@ func TestRunPluginForBuiltinTemplate(t *testing.T) {
 }
 
 func TestRunPluginForCustomTemplate(t *testing.T) {
-	set, _ := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
 	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto", "nested/Book.proto")
 	req.Parameter = proto.String("resources/html.tmpl,/base/name/only/output.html")
 
@@ -123,6 +503,127 @@ func TestRunPluginForCustomTemplate(t *testing.T) {
 	require.NotEmpty(t, resp.File[0].GetContent())
 }
 
+func TestRunPluginWithModules(t *testing.T) {
+	dir := t.TempDir()
+	modules := filepath.Join(dir, "modules.json")
+	require.NoError(t, os.WriteFile(modules, []byte(`{"Booking.proto": "buf.build/example/booking"}`), 0o644))
+
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto")
+	req.Parameter = proto.String("json,output.json,modules=" + modules)
+
+	plugin := new(Plugin)
+	resp, err := plugin.Generate(req)
+	require.NoError(t, err)
+	require.Contains(t, resp.File[0].GetContent(), `"module": "buf.build/example/booking"`)
+
+	require.NoError(t, os.WriteFile(modules, []byte(`not json`), 0o644))
+	_, err = plugin.Generate(req)
+	require.Error(t, err)
+}
+
+func TestRunPluginWithSnippets(t *testing.T) {
+	dir := t.TempDir()
+	snippets := filepath.Join(dir, "snippets.json")
+	require.NoError(t, os.WriteFile(snippets, []byte(`{"pagination": "Results are paginated."}`), 0o644))
+
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto")
+	req.Parameter = proto.String("markdown,output.md,snippets=" + snippets)
+
+	plugin := new(Plugin)
+	_, err = plugin.Generate(req)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(snippets, []byte(`not json`), 0o644))
+	_, err = plugin.Generate(req)
+	require.Error(t, err)
+
+	req.Parameter = proto.String("markdown,output.md,snippets=" + filepath.Join(dir, "missing.json"))
+	_, err = plugin.Generate(req)
+	require.Error(t, err)
+}
+
+func TestRunPluginWithCustomOptions(t *testing.T) {
+	req := newTestRequest(t, docsProto("MessageOptions string category"), `
+		name: "api.proto"
+		package: "test"
+		dependency: "docs.proto"
+		message_type: { name: "Invoice" options: { [docs.category]: "Billing" } }
+	`)
+	req.Parameter = proto.String("json,output.json")
+
+	resp, err := new(Plugin).Generate(req)
+	require.NoError(t, err)
+	require.Contains(t, resp.File[0].GetContent(), `"docs.category": "Billing"`)
+}
+
+func TestRunPluginWithDiffBase(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+	data, err := proto.Marshal(set)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.binpb")
+	require.NoError(t, os.WriteFile(base, data, 0o644))
+
+	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto")
+	req.Parameter = proto.String("diff,CHANGELOG.md,diff_base=" + base)
+
+	plugin := new(Plugin)
+	resp, err := plugin.Generate(req)
+	require.NoError(t, err)
+	require.Contains(t, resp.File[0].GetContent(), "# API Changes\n")
+	require.NotContains(t, resp.File[0].GetContent(), "com.example.Booking`")
+
+	require.NoError(t, os.WriteFile(base, []byte("not a descriptor set"), 0o644))
+	_, err = plugin.Generate(req)
+	require.Error(t, err)
+}
+
+func TestRunPluginWithDiffBaseAndExcludePatterns(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+	data, err := proto.Marshal(set)
+	require.NoError(t, err)
+
+	base := filepath.Join(t.TempDir(), "base.binpb")
+	require.NoError(t, os.WriteFile(base, data, 0o644))
+
+	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto")
+	req.Parameter = proto.String("diff,CHANGELOG.md,diff_base=" + base + ":Vehicle.*")
+
+	resp, err := new(Plugin).Generate(req)
+	require.NoError(t, err)
+	require.Len(t, resp.File, 1)
+	require.NotContains(t, resp.File[0].GetContent(), "Removed")
+	require.NotContains(t, resp.File[0].GetContent(), "Vehicle")
+}
+
+func TestRunPluginWithDiffBaseForSourceRelative(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+	data, err := proto.Marshal(set)
+	require.NoError(t, err)
+
+	base := filepath.Join(t.TempDir(), "base.binpb")
+	require.NoError(t, os.WriteFile(base, data, 0o644))
+
+	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto", "nested/Book.proto")
+	req.Parameter = proto.String("diff,CHANGELOG.md,source_relative,diff_base=" + base)
+
+	resp, err := new(Plugin).Generate(req)
+	require.NoError(t, err)
+	require.Len(t, resp.File, 2)
+	for _, file := range resp.File {
+		require.NotContains(t, file.GetContent(), "Removed", file.GetName())
+		require.NotContains(t, file.GetContent(), "Added", file.GetName())
+	}
+}
+
 func TestRunPluginWithInvalidOptions(t *testing.T) {
 	req := new(plugin_go.CodeGeneratorRequest)
 	req.Parameter = proto.String("html")
@@ -133,7 +634,8 @@ func TestRunPluginWithInvalidOptions(t *testing.T) {
 }
 
 func TestRunPluginForSourceRelative(t *testing.T) {
-	set, _ := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
 	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto", "nested/Book.proto")
 	req.Parameter = proto.String("markdown,index.md,source_relative")
 
@@ -0,0 +1,128 @@
+package gendoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// postmanCollection is the subset of the Postman collection format (v2.1) used to describe HTTP bindings.
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []*postmanItem    `json:"item"`
+	Variable []postmanVariable `json:"variable"`
+}
+
+type postmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+// postmanItem is either a folder (with items) or a request.
+type postmanItem struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Item        []*postmanItem  `json:"item,omitempty"`
+	Request     *postmanRequest `json:"request,omitempty"`
+}
+
+type postmanRequest struct {
+	Method string          `json:"method"`
+	Header []postmanHeader `json:"header"`
+	URL    postmanURL      `json:"url"`
+	Body   *postmanBody    `json:"body,omitempty"`
+}
+
+type postmanHeader struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Variable []postmanVariable `json:"variable,omitempty"`
+}
+
+type postmanVariable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type postmanBody struct {
+	Mode    string             `json:"mode"`
+	Raw     string             `json:"raw"`
+	Options postmanBodyOptions `json:"options"`
+}
+
+type postmanBodyOptions struct {
+	Raw struct {
+		Language string `json:"language"`
+	} `json:"raw"`
+}
+
+type postmanRenderer struct{}
+
+// Apply renders a Postman collection with a folder per service and a request per HTTP binding (google.api.http) of its
+// methods. URLs are relative to the `baseUrl` collection variable and path variables become Postman ones (`:name`).
+// Requests with a body get a JSON skeleton of the request message (or of the field mapped to the body). Methods without
+// HTTP bindings are left out.
+func (r *postmanRenderer) Apply(template *Template) ([]byte, error) {
+	collection := &postmanCollection{
+		Info:     postmanInfo{Name: "Protocol Documentation", Schema: postmanSchema},
+		Item:     []*postmanItem{},
+		Variable: []postmanVariable{{Key: "baseUrl"}},
+	}
+	if len(template.Packages) == 1 && template.Packages[0].Name != "" {
+		collection.Info.Name = template.Packages[0].Name
+	}
+
+	for _, file := range template.Files {
+		for _, service := range file.Services {
+			folder := &postmanItem{Name: service.FullName, Description: service.Description}
+			for _, method := range service.Methods {
+				for i, rule := range method.HTTPRules {
+					name := method.Name
+					if i > 0 {
+						name = fmt.Sprintf("%s (%d)", method.Name, i+1)
+					}
+					folder.Item = append(folder.Item, &postmanItem{
+						Name:        name,
+						Description: method.Description,
+						Request:     postmanRequestFor(method, rule),
+					})
+				}
+			}
+			if len(folder.Item) > 0 {
+				collection.Item = append(collection.Item, folder)
+			}
+		}
+	}
+
+	return json.MarshalIndent(collection, "", "  ")
+}
+
+func postmanRequestFor(method *ServiceMethod, rule *HTTPRule) *postmanRequest {
+	req := &postmanRequest{Method: rule.Method, Header: []postmanHeader{}}
+
+	bound := make(map[string]bool)
+	path := pathVariablePattern.ReplaceAllStringFunc(rule.Path, func(v string) string {
+		name := pathVariablePattern.FindStringSubmatch(v)[1]
+		bound[name] = true
+		req.URL.Variable = append(req.URL.Variable, postmanVariable{Key: name})
+		return ":" + name
+	})
+	req.URL.Raw = "{{baseUrl}}" + path
+	req.URL.Host = []string{"{{baseUrl}}"}
+	req.URL.Path = strings.Split(strings.TrimPrefix(path, "/"), "/")
+
+	if body, ok := httpBodyExample(method.RequestMessage, rule.Body, bound); ok {
+		req.Header = append(req.Header, postmanHeader{Key: "Content-Type", Value: "application/json"})
+		req.Body = &postmanBody{Mode: "raw", Raw: body}
+		req.Body.Options.Raw.Language = "json"
+	}
+	return req
+}
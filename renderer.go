@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	html_template "html/template"
+	"os"
+	"path/filepath"
+	"strings"
 	text_template "text/template"
 
 	"github.com/Masterminds/sprig/v3"
@@ -20,20 +23,56 @@ const (
 	RenderTypeHTML
 	RenderTypeJSON
 	RenderTypeMarkdown
+	RenderTypeText
+	RenderTypeJSONSchema
+	RenderTypeTypeScript
+	RenderTypeMDX
+	RenderTypePostman
+	RenderTypeXLSX
+	RenderTypeGRPC
+	RenderTypeNavigation
+	RenderTypeAPIReference
+	RenderTypeGraph
+	RenderTypeTypeScriptEnums
+	RenderTypeDiff
 )
 
 // NewRenderType creates a RenderType from the supplied string. If the type is not known, (0, error) is returned. It is
 // assumed (by the plugin) that invalid render type simply means that the path to a custom template was supplied.
 func NewRenderType(renderType string) (RenderType, error) {
 	switch renderType {
+	case "apiref":
+		return RenderTypeAPIReference, nil
+	case "diff":
+		return RenderTypeDiff, nil
 	case "docbook":
 		return RenderTypeDocBook, nil
+	case "graph":
+		return RenderTypeGraph, nil
+	case "grpc":
+		return RenderTypeGRPC, nil
 	case "html":
 		return RenderTypeHTML, nil
 	case "json":
 		return RenderTypeJSON, nil
+	case "jsonschema":
+		return RenderTypeJSONSchema, nil
 	case "markdown":
 		return RenderTypeMarkdown, nil
+	case "mdx":
+		return RenderTypeMDX, nil
+	case "navigation":
+		return RenderTypeNavigation, nil
+	case "postman":
+		return RenderTypePostman, nil
+	case "text":
+		return RenderTypeText, nil
+	case "tsenums":
+		return RenderTypeTypeScriptEnums, nil
+	case "typescript":
+		return RenderTypeTypeScript, nil
+	case "xlsx":
+		return RenderTypeXLSX, nil
 	}
 
 	return 0, errors.New("Invalid render type")
@@ -46,14 +85,38 @@ func (rt RenderType) renderer() (Processor, error) {
 	}
 
 	switch rt {
+	case RenderTypeAPIReference:
+		return &htmlRenderer{inputTemplate: string(tmpl), markdown: true}, nil
+	case RenderTypeDiff:
+		return new(diffRenderer), nil
 	case RenderTypeDocBook:
-		return &textRenderer{string(tmpl)}, nil
+		return &textRenderer{inputTemplate: string(tmpl), kind: rt}, nil
+	case RenderTypeGraph:
+		return new(graphRenderer), nil
+	case RenderTypeGRPC:
+		return new(grpcRenderer), nil
 	case RenderTypeHTML:
-		return &htmlRenderer{string(tmpl)}, nil
+		return &htmlRenderer{inputTemplate: string(tmpl)}, nil
 	case RenderTypeJSON:
 		return new(jsonRenderer), nil
+	case RenderTypeJSONSchema:
+		return new(jsonSchemaRenderer), nil
 	case RenderTypeMarkdown:
-		return &htmlRenderer{string(tmpl)}, nil
+		return &htmlRenderer{inputTemplate: string(tmpl), markdown: true}, nil
+	case RenderTypeMDX:
+		return &textRenderer{inputTemplate: string(tmpl), kind: rt}, nil
+	case RenderTypeNavigation:
+		return new(navigationRenderer), nil
+	case RenderTypePostman:
+		return new(postmanRenderer), nil
+	case RenderTypeText:
+		return &textRenderer{inputTemplate: string(tmpl), kind: rt}, nil
+	case RenderTypeTypeScript:
+		return new(typeScriptRenderer), nil
+	case RenderTypeTypeScriptEnums:
+		return new(typeScriptEnumsRenderer), nil
+	case RenderTypeXLSX:
+		return new(xlsxRenderer), nil
 	}
 
 	return nil, errors.New("Unable to create a processor")
@@ -61,34 +124,52 @@ func (rt RenderType) renderer() (Processor, error) {
 
 func (rt RenderType) template() ([]byte, error) {
 	switch rt {
+	case RenderTypeAPIReference:
+		return apirefTmpl, nil
 	case RenderTypeDocBook:
 		return docbookTmpl, nil
 	case RenderTypeHTML:
 		return htmlTmpl, nil
-	case RenderTypeJSON:
+	case RenderTypeDiff, RenderTypeGraph, RenderTypeGRPC, RenderTypeJSON, RenderTypeJSONSchema, RenderTypeNavigation, RenderTypePostman, RenderTypeTypeScript,
+		RenderTypeTypeScriptEnums, RenderTypeXLSX:
 		return nil, nil
 	case RenderTypeMarkdown:
 		return markdownTmpl, nil
+	case RenderTypeMDX:
+		return mdxTmpl, nil
+	case RenderTypeText:
+		return textTmpl, nil
 	}
 
 	return nil, errors.New("Couldn't find template for render type")
 }
 
 var funcMap = map[string]interface{}{
-	"p":      PFilter,
-	"para":   ParaFilter,
-	"nobr":   NoBrFilter,
-	"anchor": AnchorFilter,
-	"md":     MDFilter,
+	"p":       PFilter,
+	"para":    ParaFilter,
+	"nobr":    NoBrFilter,
+	"inline":  InlineFilter,
+	"blocks":  BlocksFilter,
+	"anchor":  AnchorFilter,
+	"slug":    SlugFilter,
+	"md":      MDFilter,
+	"mdx":     MDXFilter,
+	"classes": ClassesFilter,
+
+	"pluralize":    PluralizeFilter,
+	"titleCase":    TitleCaseFilter,
+	"snakeToTitle": SnakeToTitleFilter,
 }
 
-// Processor is an interface that is satisfied by all built-in processors (text, html, and json).
+// Processor is an interface that is satisfied by all built-in processors (diff, text, html, grpc, json, jsonschema,
+// navigation, postman, tsenums, typescript and xlsx).
 type Processor interface {
 	Apply(template *Template) ([]byte, error)
 }
 
 // RenderTemplate renders the template based on the render type. It supports overriding the default input templates by
-// supplying a non-empty string as the last parameter.
+// supplying a non-empty string as the last parameter. Custom templates are rendered as text, but the typeRef function
+// still links types as in the output of the given render type (the plugin uses RenderTypeHTML for custom templates).
 //
 // Example: generating an HTML template (assuming you've got a Template object)
 //
@@ -99,7 +180,7 @@ type Processor interface {
 //	data, err := RenderTemplate(RenderTypeHTML, &template, "{{range .Files}}{{.Name}}{{end}}")
 func RenderTemplate(kind RenderType, template *Template, inputTemplate string) ([]byte, error) {
 	if inputTemplate != "" {
-		processor := &textRenderer{inputTemplate}
+		processor := &textRenderer{inputTemplate: inputTemplate, kind: kind}
 		return processor.Apply(template)
 	}
 
@@ -113,21 +194,47 @@ func RenderTemplate(kind RenderType, template *Template, inputTemplate string) (
 
 type textRenderer struct {
 	inputTemplate string
+	kind          RenderType
 }
 
 func (mr *textRenderer) Apply(template *Template) ([]byte, error) {
+	anchors := newAnchors(template)
+	notes := newFootnotes(template, anchors)
 	tmpl, err := text_template.New("Text Template").
 		Funcs(funcMap).
 		Funcs(sprig.TxtFuncMap()).
 		Funcs(map[string]any{
-			"isLink": IsLinkFn(template),
-			"link":   LinkFn(template),
+			"isLink":          IsLinkFn(template),
+			"link":            LinkFn(template),
+			"headingAnchor":   anchors.heading,
+			"anchorRef":       anchors.ref,
+			"slug":            template.Slug,
+			"compact":         template.Compact,
+			"mapNotes":        template.MapNotes,
+			"packageOverview": template.PackageOverview,
+			"footnoteLinks":   template.FootnoteLinks,
+			"sourceURL":       func(s *Source) string { return s.URL(template.SourceBaseURL()) },
+			"footnote":        notes.mark,
+			"footnotes":       notes.flush,
+			"heading":         template.Heading,
+			"wbr":             WbrTextFilter,
+			"autoLink":        autoLinkFn(template, mr.kind, anchors),
+			"typeRef":         typeRefFn(template, mr.kind, anchors),
+			"relLink":         relLinkFn(template, mr.kind, anchors),
 		}).
 		Parse(mr.inputTemplate)
 	if err != nil {
 		return nil, err
 	}
 
+	err = parseOverrides(template.templateDir, func(name, text string) error {
+		_, err := tmpl.New(name).Parse(text)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	var buf bytes.Buffer
 	if err = tmpl.Execute(&buf, template); err != nil {
 		return nil, err
@@ -138,21 +245,51 @@ func (mr *textRenderer) Apply(template *Template) ([]byte, error) {
 
 type htmlRenderer struct {
 	inputTemplate string
+	markdown      bool
 }
 
 func (mr *htmlRenderer) Apply(template *Template) ([]byte, error) {
+	anchors := newAnchors(template)
+	notes := newFootnotes(template, anchors)
+	kind := RenderTypeHTML
+	if mr.markdown {
+		kind = RenderTypeMarkdown
+	}
 	tmpl, err := html_template.New("Text Template").
 		Funcs(funcMap).
 		Funcs(sprig.HtmlFuncMap()).
 		Funcs(map[string]any{
-			"isLink": IsLinkFn(template),
-			"link":   LinkFn(template),
+			"isLink":          IsLinkFn(template),
+			"link":            LinkFn(template),
+			"headingAnchor":   anchors.heading,
+			"anchorRef":       anchors.ref,
+			"slug":            template.Slug,
+			"compact":         template.Compact,
+			"mapNotes":        template.MapNotes,
+			"packageOverview": template.PackageOverview,
+			"footnoteLinks":   template.FootnoteLinks,
+			"sourceURL":       func(s *Source) string { return s.URL(template.SourceBaseURL()) },
+			"footnote":        notes.mark,
+			"footnotes":       notes.flush,
+			"heading":         template.Heading,
+			"wbr":             WbrFilter,
+			"autoLink":        htmlAutoLinkFn(template, kind, anchors),
+			"typeRef":         typeRefFn(template, kind, anchors),
+			"relLink":         relLinkFn(template, kind, anchors),
 		}).
 		Parse(mr.inputTemplate)
 	if err != nil {
 		return nil, err
 	}
 
+	err = parseOverrides(template.templateDir, func(name, text string) error {
+		_, err := tmpl.New(name).Parse(text)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	var buf bytes.Buffer
 	if err = tmpl.Execute(&buf, template); err != nil {
 		return nil, err
@@ -161,8 +298,59 @@ func (mr *htmlRenderer) Apply(template *Template) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// parseOverrides hands every `<name>.tmpl` file found in dir to parse, so that it replaces the named template (e.g.
+// `field.tmpl` replaces `{{define "field"}}`) of the template being rendered. Nothing happens when dir is empty.
+func parseOverrides(dir string, parse func(name, text string) error) error {
+	if dir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".tmpl" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+
+		if err := parse(strings.TrimSuffix(entry.Name(), ".tmpl"), string(data)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 type jsonRenderer struct{}
 
 func (r *jsonRenderer) Apply(template *Template) ([]byte, error) {
+	if template.omitInternal {
+		template = withoutInternal(template)
+	}
 	return json.MarshalIndent(template, "", "  ")
 }
+
+// withoutInternal returns a shallow copy of the template where files and packages only list visible messages.
+func withoutInternal(template *Template) *Template {
+	res := *template
+	res.Files = make([]*File, 0, len(template.Files))
+	for _, f := range template.Files {
+		file := *f
+		file.Messages = f.VisibleMessages()
+		res.Files = append(res.Files, &file)
+	}
+	res.Packages = make([]*Package, 0, len(template.Packages))
+	for _, p := range template.Packages {
+		pkg := *p
+		pkg.Messages = p.VisibleMessages()
+		res.Packages = append(res.Packages, &pkg)
+	}
+	return &res
+}
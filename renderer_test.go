@@ -1,13 +1,24 @@
 package gendoc_test
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin_go "github.com/golang/protobuf/protoc-gen-go/plugin"
 	. "github.com/pseudomuto/protoc-gen-doc"
 	"github.com/pseudomuto/protokit"
 	"github.com/pseudomuto/protokit/utils"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/prototext"
 )
 
 func TestRenderers(t *testing.T) {
@@ -21,25 +32,1325 @@ func TestRenderers(t *testing.T) {
 	template := NewTemplate(result)
 
 	for _, r := range []RenderType{
+		RenderTypeAPIReference,
 		RenderTypeDocBook,
+		RenderTypeGraph,
+		RenderTypeGRPC,
 		RenderTypeHTML,
 		RenderTypeJSON,
+		RenderTypeJSONSchema,
 		RenderTypeMarkdown,
+		RenderTypeMDX,
+		RenderTypeNavigation,
+		RenderTypePostman,
+		RenderTypeText,
+		RenderTypeTypeScript,
+		RenderTypeTypeScriptEnums,
+		RenderTypeXLSX,
 	} {
 		_, err := RenderTemplate(r, template, "")
 		require.NoError(t, err)
 	}
 }
 
+func TestJSONRendererOmitInternal(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+
+	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto")
+	result := protokit.ParseCodeGenRequest(req)
+
+	output, err := RenderTemplate(RenderTypeJSON, NewTemplate(result), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `"longName": "Vehicle.PropertiesEntry"`)
+	require.Contains(t, string(output), `"internal": true`)
+	require.Contains(t, string(output), `"internal": false`)
+
+	output, err = RenderTemplate(RenderTypeJSON, NewTemplate(result, WithOmitInternal(true)), "")
+	require.NoError(t, err)
+	require.NotContains(t, string(output), `"longName": "Vehicle.PropertiesEntry"`)
+	require.NotContains(t, string(output), `"internal": true`)
+	require.Contains(t, string(output), `"longName": "Vehicle"`)
+}
+
+func TestJSONRendererOneofs(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+
+	req := utils.CreateGenRequest(set, "Vehicle.proto")
+	output, err := RenderTemplate(RenderTypeJSON, NewTemplate(protokit.ParseCodeGenRequest(req)), "")
+	require.NoError(t, err)
+
+	type field struct {
+		Name      string `json:"name"`
+		IsOneof   bool   `json:"isoneof"`
+		OneofDecl string `json:"oneofdecl"`
+	}
+	var doc struct {
+		Files []struct {
+			Messages []struct {
+				LongName string  `json:"longName"`
+				Fields   []field `json:"fields"`
+				OneOfs   []struct {
+					Name   string  `json:"name"`
+					Fields []field `json:"fields"`
+				} `json:"oneofs"`
+			} `json:"messages"`
+		} `json:"files"`
+	}
+	require.NoError(t, json.Unmarshal(output, &doc))
+
+	for _, msg := range doc.Files[0].Messages {
+		if msg.LongName != "Vehicle" {
+			continue
+		}
+		for _, f := range msg.Fields {
+			require.False(t, f.IsOneof, f.Name)
+		}
+		require.Len(t, msg.OneOfs, 2)
+		require.Equal(t, "travel", msg.OneOfs[0].Name)
+		require.Equal(t, []field{
+			{Name: "kilometers", IsOneof: true, OneofDecl: "travel"},
+			{Name: "lightyears", IsOneof: true, OneofDecl: "travel"},
+		}, msg.OneOfs[0].Fields)
+		return
+	}
+	require.Fail(t, "Vehicle not found")
+}
+
+func TestJSONSchemaRenderer(t *testing.T) {
+	template := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "Book"
+			field: { name: "book_id" number: 1 label: LABEL_REQUIRED type: TYPE_INT64 }
+			field: { name: "tags" number: 2 label: LABEL_REPEATED type: TYPE_STRING }
+			field: { name: "genre" number: 3 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".test.Genre" }
+			field: { name: "shelves" number: 4 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Book.ShelvesEntry" }
+			nested_type: {
+				name: "ShelvesEntry"
+				field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+				field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Shelf" }
+				options: { map_entry: true }
+			}
+		}
+		message_type: { name: "Shelf" field: { name: "cover" number: 1 label: LABEL_OPTIONAL type: TYPE_BYTES } }
+		enum_type: {
+			name: "Genre"
+			value: { name: "GENRE_UNSPECIFIED" number: 0 }
+			value: { name: "FICTION" number: 1 }
+		}
+	`)
+
+	output, err := RenderTemplate(RenderTypeJSONSchema, template, "")
+	require.NoError(t, err)
+
+	var schema struct {
+		Schema string                            `json:"$schema"`
+		Defs   map[string]map[string]interface{} `json:"$defs"`
+	}
+	require.NoError(t, json.Unmarshal(output, &schema))
+	require.Equal(t, "https://json-schema.org/draft/2020-12/schema", schema.Schema)
+	require.Len(t, schema.Defs, 3) // the map entry isn't listed
+
+	book := schema.Defs["test.Book"]
+	require.Equal(t, "test.Book", book["$anchor"])
+	require.Equal(t, []interface{}{"bookId"}, book["required"])
+	require.Equal(t, map[string]interface{}{
+		"bookId": map[string]interface{}{"type": []interface{}{"integer", "string"}, "format": "int64"},
+		"tags":   map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		"genre":  map[string]interface{}{"$ref": "#test.Genre"},
+		"shelves": map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": map[string]interface{}{"$ref": "#test.Shelf"},
+		},
+	}, book["properties"])
+
+	require.Equal(t, map[string]interface{}{
+		"cover": map[string]interface{}{"type": "string", "contentEncoding": "base64"},
+	}, schema.Defs["test.Shelf"]["properties"])
+	require.Equal(t, []interface{}{"GENRE_UNSPECIFIED", "FICTION"}, schema.Defs["test.Genre"]["enum"])
+}
+
+func TestJSONSchemaExamples(t *testing.T) {
+	template := newTestTemplate(t, docsProto("FieldOptions string example"), `
+		name: "api.proto"
+		package: "test"
+		dependency: "docs.proto"
+		message_type: {
+			name: "Book"
+			field: {
+				name: "book_id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: "bookId"
+				options: { [docs.example]: "42" }
+			}
+			field: {
+				name: "title" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "title"
+				options: { [docs.example]: "Dune" }
+			}
+			field: {
+				name: "tags" number: 3 label: LABEL_REPEATED type: TYPE_STRING json_name: "tags"
+				options: { [docs.example]: "sci-fi" }
+			}
+			field: {
+				name: "genre" number: 4 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".test.Genre" json_name: "genre"
+				options: { [docs.example]: "FICTION" }
+			}
+			field: { name: "pages" number: 5 label: LABEL_OPTIONAL type: TYPE_INT32 json_name: "pages" }
+			field: { name: "shelf" number: 6 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Shelf" json_name: "shelf" }
+		}
+		message_type: { name: "Shelf" field: { name: "name" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "name" } }
+		enum_type: {
+			name: "Genre"
+			value: { name: "GENRE_UNSPECIFIED" number: 0 }
+			value: { name: "FICTION" number: 1 }
+		}
+	`)
+	require.Equal(t, "Dune", findField("title", findMessage("Book", template.Files[0])).Example())
+
+	output, err := RenderTemplate(RenderTypeJSONSchema, template, "")
+	require.NoError(t, err)
+
+	var schema struct {
+		Defs map[string]struct {
+			Properties map[string]struct{ Examples []interface{} }
+			Examples   []interface{}
+		} `json:"$defs"`
+	}
+	require.NoError(t, json.Unmarshal(output, &schema))
+
+	book := schema.Defs["test.Book"]
+	require.Equal(t, []interface{}{float64(42)}, book.Properties["bookId"].Examples)
+	require.Equal(t, []interface{}{"Dune"}, book.Properties["title"].Examples)
+	require.Equal(t, []interface{}{[]interface{}{"sci-fi"}}, book.Properties["tags"].Examples)
+	require.Equal(t, []interface{}{"FICTION"}, book.Properties["genre"].Examples)
+	require.Empty(t, book.Properties["pages"].Examples)
+
+	// fields without example fall back to their zero values
+	require.Equal(t, []interface{}{map[string]interface{}{
+		"bookId": float64(42),
+		"title":  "Dune",
+		"tags":   []interface{}{"sci-fi"},
+		"genre":  "FICTION",
+		"pages":  float64(0),
+		"shelf":  map[string]interface{}{"name": ""},
+	}}, book.Examples)
+	require.Equal(t, []interface{}{map[string]interface{}{"name": ""}}, schema.Defs["test.Shelf"].Examples)
+}
+
+func TestRenderDescriptionTables(t *testing.T) {
+	template := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Book"
+			field: { name: "format" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+		}
+		source_code_info: {
+			location: {
+				path: [4, 0, 2, 0]
+				span: [1, 0, 1]
+				leading_comments: " The format.\n\n | Format | Notes |\n | ------ | ----- |\n | pdf | <b>printable</b> |\n"
+			}
+		}
+	`)
+
+	output, err := RenderTemplate(RenderTypeMarkdown, template, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "| format | [string](#string) |  | The format. |\n")
+	require.Contains(t, string(output), "**format**\n\n| Format | Notes |\n| ------ | ----- |\n| pdf | &lt;b&gt;printable&lt;/b&gt; |\n")
+
+	output, err = RenderTemplate(RenderTypeHTML, template, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "<td><p>The format. </p></td>")
+	require.Contains(t, string(output), `<tr class="description-block"><td colspan="4"><pre>| Format | Notes |
+| ------ | ----- |
+| pdf | &lt;b&gt;printable&lt;/b&gt; |</pre></td></tr>`)
+}
+
+func TestRenderFileOptions(t *testing.T) {
+	template := newTestTemplate(t, `
+		name: "old.proto"
+		package: "test"
+		options: { go_package: "example.com/old" deprecated: true }
+	`)
+
+	output, err := RenderTemplate(RenderTypeMarkdown, template, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "> **Deprecated.** Everything defined in this file is deprecated.\n")
+	require.Contains(t, string(output), "### Options\n| Option | Value |\n| ------ | ----- |\n| go_package | example.com/old |\n")
+
+	output, err = RenderTemplate(RenderTypeHTML, template, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "<p><strong>Deprecated.</strong> Everything defined in this file is deprecated.</p>")
+	require.Contains(t, string(output), "<td>go_package</td>")
+}
+
+func TestRenderEmptySections(t *testing.T) {
+	template := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: { name: "Empty" }
+		service: { name: "Idle" }
+	`)
+
+	for _, r := range []RenderType{RenderTypeMarkdown, RenderTypeMDX, RenderTypeHTML, RenderTypeDocBook} {
+		output, err := RenderTemplate(r, template, "")
+		require.NoError(t, err)
+		require.Contains(t, string(output), "Empty")
+		require.Contains(t, string(output), "Idle")
+		require.NotContains(t, string(output), "Extension")
+		require.NotContains(t, string(output), "| Field |")
+		require.NotContains(t, string(output), "Method Name")
+		require.NotContains(t, string(output), `<table class="field-table">`)
+		require.NotContains(t, string(output), "Fields</title>")
+	}
+}
+
+func TestRenderOneofFields(t *testing.T) {
+	template := newTestTemplate(t, `
+		name: "book.proto"
+		package: "lib"
+		syntax: "proto2"
+		message_type: {
+			name: "Book"
+			field: { name: "title" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "isbn" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 }
+			field: { name: "pages" number: 3 label: LABEL_OPTIONAL type: TYPE_INT32 }
+			oneof_decl: { name: "id" }
+		}
+	`)
+
+	table := "| title | [string](#string) | optional |  |\n" +
+		"| isbn | [string](#string) | optional |  |\n" +
+		"| pages | [int32](#int32) | optional |  |\n"
+
+	tests := map[RenderType][]string{
+		RenderTypeMarkdown:     {table},
+		RenderTypeMDX:          {table},
+		RenderTypeAPIReference: {table},
+		RenderTypeHTML: {
+			"title<a class=\"permalink\"", "isbn<a class=\"permalink\"", "pages<a class=\"permalink\"",
+		},
+		RenderTypeDocBook: {"<entry>title</entry>", "<entry>isbn</entry>", "<entry>pages</entry>"},
+		RenderTypeText: {
+			"  optional string title = 1\n  optional int32 pages = 3\n  oneof id\n    optional string isbn = 2\n",
+		},
+	}
+
+	for r, fragments := range tests {
+		output, err := RenderTemplate(r, template, "")
+		require.NoError(t, err)
+		for _, fragment := range fragments {
+			require.Contains(t, string(output), fragment, "render type %d", r)
+		}
+	}
+}
+
+func TestRenderReserved(t *testing.T) {
+	template := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Book"
+			field: { name: "title" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+			reserved_range: { start: 5 end: 6 }
+			reserved_range: { start: 10 end: 16 }
+			reserved_name: ["isbn", "author"]
+		}
+		enum_type: {
+			name: "Genre"
+			value: { name: "GENRE_UNSPECIFIED" number: 0 }
+			reserved_range: { start: 2 end: 2 }
+		}
+	`)
+
+	for _, r := range []RenderType{RenderTypeMarkdown, RenderTypeMDX} {
+		output, err := RenderTemplate(r, template, "")
+		require.NoError(t, err)
+		require.Contains(t, string(output), "| Reserved Numbers | Reserved Names |\n| ---------------- | -------------- |\n"+
+			"| 5, 10-15 | `isbn`, `author` |\n")
+		require.Contains(t, string(output), "| 2 |  |\n")
+	}
+
+	output, err := RenderTemplate(RenderTypeHTML, template, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "<td>5, 10-15</td>\n              <td><code>isbn</code>, <code>author</code></td>")
+	require.Contains(t, string(output), "<td>2</td>\n              <td></td>")
+}
+
+func TestMDXRenderer(t *testing.T) {
+	pages := map[string]string{"other.Shelf": "other/index.mdx", "test.v1.Book": "test/index.mdx"}
+	template := newTestTemplateWithOptions(t, []TemplateOption{WithPages("test/index.mdx", pages)},
+		`name: "other/shelf.proto" package: "other" message_type: { name: "Shelf" }`,
+		`name: "test/api.proto"
+		package: "test.v1"
+		dependency: ["other/shelf.proto"]
+		message_type: {
+			name: "Book"
+			field: { name: "shelf" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".other.Shelf" }
+			field: { name: "next" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.v1.Book" }
+		}
+		source_code_info: {
+			location: { path: [4, 0, 2, 0] span: [1, 0, 1] leading_comments: " Where it's at, e.g. {\"id\": 1} or <unknown>, see `+"`{}`"+`.\n" }
+		}`,
+	)
+
+	output, err := RenderTemplate(RenderTypeMDX, template, "")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(output), "---\ntitle: test.v1\nslug: /test/v1\n---\n"))
+	require.Contains(t, string(output), "### Book {#test-v1-Book}")
+	require.Contains(t, string(output), "| shelf | [other.Shelf](../other/index.mdx#other-Shelf) | optional | "+
+		"Where it's at, e.g. \\{\"id\": 1\\} or \\<unknown>, see `{}`. |")
+	require.Contains(t, string(output), "| next | [Book](#test-v1-Book) |")
+}
+
+func TestPostmanRenderer(t *testing.T) {
+	fd := new(descriptor.FileDescriptorProto)
+	require.NoError(t, prototext.Unmarshal([]byte(`
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Book"
+			field: { name: "name" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "name" }
+			field: { name: "page_count" number: 2 label: LABEL_OPTIONAL type: TYPE_INT64 json_name: "pageCount" }
+			field: { name: "tags" number: 3 label: LABEL_REPEATED type: TYPE_STRING json_name: "tags" }
+		}
+		message_type: {
+			name: "UpdateBookRequest"
+			field: { name: "book" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Book" json_name: "book" }
+		}
+		service: {
+			name: "Library"
+			method: { name: "GetBook" input_type: ".test.Book" output_type: ".test.Book" options: {} }
+			method: { name: "UpdateBook" input_type: ".test.UpdateBookRequest" output_type: ".test.Book" options: {} }
+			method: { name: "Ping" input_type: ".test.Book" output_type: ".test.Book" }
+		}
+		source_code_info: {
+			location: { path: [6, 0, 2, 0] span: [1, 0, 1] leading_comments: " Gets a book.\n" }
+		}
+	`), fd))
+	methods := fd.Service[0].Method
+	methods[0].Options.ProtoReflect().SetUnknown(encodeFields(72295728, encodeFields(
+		2, "/v1/{name=books/*}",
+		11, encodeFields(4, "/v1/{name=books/*}:get", 7, "*"),
+	)))
+	methods[1].Options.ProtoReflect().SetUnknown(encodeFields(72295728, encodeFields(6, "/v1/books", 7, "book")))
+
+	output, err := RenderTemplate(RenderTypePostman, newTestTemplateFromFiles(fd), "")
+	require.NoError(t, err)
+
+	var collection struct {
+		Info struct{ Name string }
+		Item []struct {
+			Name string
+			Item []struct {
+				Name        string
+				Description string
+				Request     struct {
+					Method string
+					URL    struct {
+						Raw      string
+						Variable []struct{ Key string }
+					}
+					Body *struct{ Raw string }
+				}
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal(output, &collection))
+	require.Equal(t, "test", collection.Info.Name)
+	require.Len(t, collection.Item, 1)
+	require.Equal(t, "test.Library", collection.Item[0].Name)
+
+	requests := collection.Item[0].Item
+	require.Len(t, requests, 3)
+
+	require.Equal(t, "GetBook", requests[0].Name)
+	require.Equal(t, "Gets a book.", requests[0].Description)
+	require.Equal(t, "GET", requests[0].Request.Method)
+	require.Equal(t, "{{baseUrl}}/v1/:name", requests[0].Request.URL.Raw)
+	require.Equal(t, "name", requests[0].Request.URL.Variable[0].Key)
+	require.Nil(t, requests[0].Request.Body)
+
+	require.Equal(t, "GetBook (2)", requests[1].Name)
+	require.Equal(t, "POST", requests[1].Request.Method)
+	require.Equal(t, "{{baseUrl}}/v1/:name:get", requests[1].Request.URL.Raw)
+	require.Equal(t, "{\n  \"pageCount\": \"0\",\n  \"tags\": [\"\"]\n}", requests[1].Request.Body.Raw)
+
+	require.Equal(t, "UpdateBook", requests[2].Name)
+	require.Equal(t, "PATCH", requests[2].Request.Method)
+	require.Equal(t, "{\n  \"name\": \"\",\n  \"pageCount\": \"0\",\n  \"tags\": [\"\"]\n}", requests[2].Request.Body.Raw)
+}
+
+func TestXLSXRenderer(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+
+	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto")
+	template := NewTemplate(protokit.ParseCodeGenRequest(req))
+
+	output, err := RenderTemplate(RenderTypeXLSX, template, "")
+	require.NoError(t, err)
+
+	again, err := RenderTemplate(RenderTypeXLSX, template, "")
+	require.NoError(t, err)
+	require.Equal(t, output, again, "output must be deterministic")
+
+	reader, err := zip.NewReader(bytes.NewReader(output), int64(len(output)))
+	require.NoError(t, err)
+
+	parts := make(map[string]string)
+	for _, f := range reader.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.NoError(t, xml.Unmarshal(data, new(interface{})), f.Name)
+		parts[f.Name] = string(data)
+	}
+
+	require.Contains(t, parts, "[Content_Types].xml")
+	require.Contains(t, parts, "_rels/.rels")
+	require.Contains(t, parts["xl/workbook.xml"], `<sheet name="Messages" sheetId="1" r:id="rId1"/>`+
+		`<sheet name="Enums" sheetId="2" r:id="rId2"/><sheet name="RPCs" sheetId="3" r:id="rId3"/>`)
+
+	messages := parts["xl/worksheets/sheet1.xml"]
+	require.Contains(t, messages, `<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">Message</t></is></c>`)
+	require.Contains(t, messages, `<t xml:space="preserve">com.example.Booking</t>`)
+	require.NotContains(t, messages, "PropertiesEntry")
+
+	// the type of Booking.status links to the row of BookingStatus
+	row := strings.Count(messages[:strings.Index(messages, `<t xml:space="preserve">com.example.BookingStatus</t>`)], "<row ")
+	require.Regexp(t, `<hyperlink ref="D\d+" location="&#39;Messages&#39;!A`+fmt.Sprint(row)+`"/>`, messages)
+
+	require.Contains(t, parts["xl/worksheets/sheet2.xml"], `<t xml:space="preserve">com.example.BookingStatus.StatusCode</t>`)
+	require.Contains(t, parts["xl/worksheets/sheet3.xml"], `<t xml:space="preserve">BookVehicle</t>`)
+}
+
+func TestTypeScriptRenderer(t *testing.T) {
+	template := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Book"
+			field: { name: "book_id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT64 }
+			field: { name: "tags" number: 2 label: LABEL_REPEATED type: TYPE_STRING }
+			field: { name: "genre" number: 3 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".test.Book.Genre" }
+			field: { name: "shelf" number: 4 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Shelf" }
+			field: { name: "subtitle" number: 5 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 proto3_optional: true }
+			field: { name: "ratings" number: 6 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Book.RatingsEntry" }
+			field: { name: "cover" number: 7 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Any" }
+			nested_type: {
+				name: "RatingsEntry"
+				field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_UINT32 }
+				field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_DOUBLE }
+				options: { map_entry: true }
+			}
+			enum_type: {
+				name: "Genre"
+				value: { name: "GENRE_UNSPECIFIED" number: 0 }
+				value: { name: "FICTION" number: 1 }
+			}
+			oneof_decl: { name: "_subtitle" }
+		}
+		message_type: { name: "Shelf" options: { deprecated: true } }
+		source_code_info: {
+			location: { path: [4, 0] span: [1, 0, 1] leading_comments: " A book.\n\n It's on a shelf.\n" }
+			location: { path: [4, 0, 2, 0] span: [2, 0, 1] leading_comments: " The id.\n" }
+		}
+	`)
+
+	output, err := RenderTemplate(RenderTypeTypeScript, template, "")
+	require.NoError(t, err)
+	require.Equal(t, `// Code generated by protoc-gen-doc. DO NOT EDIT.
+
+// api.proto
+
+export type Book_Genre = "GENRE_UNSPECIFIED" | "FICTION";
+
+/**
+ * A book.
+ *
+ * It's on a shelf.
+ */
+export interface Book {
+  /** The id. */
+  bookId: bigint;
+  tags: string[];
+  genre: Book_Genre;
+  shelf?: Shelf;
+  subtitle?: string;
+  ratings: Record<number, number>;
+  cover?: unknown;
+}
+
+/** @deprecated */
+export interface Shelf {
+}
+`, string(output))
+}
+
+func TestTypeScriptEnumsRenderer(t *testing.T) {
+	template := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Book"
+			enum_type: {
+				name: "Status"
+				value: { name: "STATUS_UNSPECIFIED" number: 0 }
+				value: { name: "STARTED" number: 1 }
+				value: { name: "RUNNING" number: 1 }
+				value: { name: "DONE" number: 2 options: { deprecated: true } }
+				value: { name: "FAILED" number: -1 }
+				options: { allow_alias: true }
+			}
+		}
+		enum_type: {
+			name: "Genre"
+			value: { name: "GENRE_UNSPECIFIED" number: 0 }
+			value: { name: "FICTION" number: 1 }
+		}
+		source_code_info: {
+			location: { path: [5, 0] span: [1, 0, 1] leading_comments: " A genre.\n" }
+			location: { path: [5, 0, 2, 1] span: [2, 0, 1] leading_comments: " Made up.\n" }
+		}
+	`)
+
+	output, err := RenderTemplate(RenderTypeTypeScriptEnums, template, "")
+	require.NoError(t, err)
+	require.Equal(t, `// Code generated by protoc-gen-doc. DO NOT EDIT.
+
+// api.proto
+
+export enum Book_Status {
+  STATUS_UNSPECIFIED = 0,
+  STARTED = 1,
+  RUNNING = 1,
+  /** @deprecated */
+  DONE = 2,
+  FAILED = -1,
+}
+
+export const Book_Status_name = {
+  0: "STATUS_UNSPECIFIED",
+  1: "STARTED",
+  2: "DONE",
+  "-1": "FAILED",
+} as const;
+
+/** A genre. */
+export enum Genre {
+  GENRE_UNSPECIFIED = 0,
+  /** Made up. */
+  FICTION = 1,
+}
+
+export const Genre_name = {
+  0: "GENRE_UNSPECIFIED",
+  1: "FICTION",
+} as const;
+`, string(output))
+}
+
+func TestTypeScriptQualifiedNames(t *testing.T) {
+	req := newTestRequest(t, `
+		name: "a.proto"
+		package: "a.v1"
+		syntax: "proto3"
+		message_type: { name: "Request" }
+		enum_type: { name: "Status" value: { name: "STATUS_UNSPECIFIED" number: 0 } }
+	`, `
+		name: "b.proto"
+		package: "b.v1"
+		syntax: "proto3"
+		dependency: "a.proto"
+		message_type: {
+			name: "Request"
+			field: { name: "previous" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".a.v1.Request" }
+			field: { name: "status" number: 2 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".b.v1.Status" }
+			field: { name: "page" number: 3 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".b.v1.Page" }
+		}
+		message_type: { name: "Page" }
+		enum_type: { name: "Status" value: { name: "STATUS_UNSPECIFIED" number: 0 } }
+	`)
+	req.FileToGenerate = []string{"a.proto", "b.proto"}
+	template := NewTemplate(protokit.ParseCodeGenRequest(req))
+
+	output, err := RenderTemplate(RenderTypeTypeScript, template, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "export type a_v1_Status = \"STATUS_UNSPECIFIED\";\n")
+	require.Contains(t, string(output), "export interface a_v1_Request {\n}\n")
+	require.Contains(t, string(output), "export type b_v1_Status = \"STATUS_UNSPECIFIED\";\n")
+	require.Contains(t, string(output), `export interface b_v1_Request {
+  previous?: a_v1_Request;
+  status: b_v1_Status;
+  page?: Page;
+}
+`)
+	require.Contains(t, string(output), "export interface Page {\n}\n")
+
+	output, err = RenderTemplate(RenderTypeTypeScriptEnums, template, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "export enum a_v1_Status {\n")
+	require.Contains(t, string(output), "export const a_v1_Status_name = {\n")
+	require.Contains(t, string(output), "export enum b_v1_Status {\n")
+}
+
+func TestAPIReferenceRenderer(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "GetBookRequest"
+			field: { name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32 }
+		}
+		message_type: { name: "WatchBooksRequest" }
+		message_type: {
+			name: "Book"
+			field: { name: "title" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "genre" number: 2 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".test.Genre" }
+		}
+		enum_type: { name: "Genre" value: { name: "FICTION" number: 0 } }
+		service: {
+			name: "Library"
+			method: { name: "GetBook" input_type: ".test.GetBookRequest" output_type: ".test.Book" }
+			method: { name: "WatchBooks" input_type: ".test.WatchBooksRequest" output_type: ".test.Book" server_streaming: true }
+		}
+		source_code_info: {
+			location: { path: [4, 0] span: [1, 0, 1] leading_comments: " Asks for a book.\n" }
+			location: { path: [6, 0, 2, 0] span: [2, 0, 1] leading_comments: " Gets a book.\n" }
+		}
+	`)
+
+	file := tmpl.Files[0]
+	require.Equal(t, []*Message{findMessage("Book", file)}, tmpl.SharedMessages())
+	require.False(t, tmpl.IsSharedMessage(findMessage("GetBookRequest", file)))
+
+	output, err := RenderTemplate(RenderTypeAPIReference, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `## Table of Contents
+
+- [Library](#test-Library)
+  - [GetBook](#test-Library-GetBook)
+  - [WatchBooks](#test-Library-WatchBooks)
+- [Shared Messages](#shared-messages)
+  - [Book](#test-Book)
+- [Enums](#enums)
+  - [Genre](#test-Genre)
+- [Scalar Value Types](#scalar-value-types)
+`)
+	require.Contains(t, string(output), `### GetBook
+Gets a book.
+
+<details>
+<summary><code>GetBook(GetBookRequest) returns (Book)</code></summary>
+
+#### Request: [GetBookRequest](#test-GetBookRequest)
+
+<a name="test-GetBookRequest"></a>
+Asks for a book.
+
+| Field | Type | Label | Description |
+| ----- | ---- | ----- | ----------- |
+| id | [int32](#int32) | optional |  |
+
+#### Response: [Book](#test-Book)
+
+</details>
+`)
+	require.Contains(t, string(output), "<summary><code>WatchBooks(WatchBooksRequest) returns (stream Book)</code></summary>")
+	require.Contains(t, string(output), "#### Request: [WatchBooksRequest](#test-WatchBooksRequest)\n\n<a name=\"test-WatchBooksRequest\"></a>\n\n\nNo fields.\n")
+	require.Contains(t, string(output), `## Shared Messages
+
+<a name="test-Book"></a>
+
+### Book
+
+
+| Field | Type | Label | Description |
+| ----- | ---- | ----- | ----------- |
+| title | [string](#string) | optional |  |
+| genre | [Genre](#test-Genre) | optional |  |
+`)
+}
+
+func TestRelLink(t *testing.T) {
+	proto := `
+		name: "acme/v1/api.proto"
+		package: "acme.v1"
+		message_type: {
+			name: "Book"
+			field: { name: "shelf" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".acme.v2.Shelf" }
+		}
+	`
+	pages := map[string]string{"acme.v1.Book": "acme/v1/index.md", "acme.v2.Shelf": "acme/v2/index.md"}
+	tmpl := newTestTemplateWithOptions(t, []TemplateOption{WithPages("acme/v1/index.md", pages)}, proto)
+
+	input := `{{relLink "acme.v1.Book"}} {{relLink "acme.v2.Shelf"}} {{relLink "google.protobuf.Empty"}} {{relLink "int32"}} [{{relLink "acme.Missing"}}]`
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, input)
+	require.NoError(t, err)
+	require.Equal(t, "#acme-v1-Book ../v2/index.md#acme-v2-Shelf https://protobuf.dev/reference/protobuf/google.protobuf/#empty #int32 []", string(output))
+
+	output, err = RenderTemplate(RenderTypeHTML, tmpl, input)
+	require.NoError(t, err)
+	require.Equal(t, "#acme-v1-Book ../v2/index.md#acme-v2-Shelf https://protobuf.dev/reference/protobuf/google.protobuf/#empty #int32 []", string(output))
+}
+
+func TestGRPCRenderer(t *testing.T) {
+	files := make([]*descriptor.FileDescriptorProto, 0, 2)
+	for _, text := range []string{`
+		name: "library.proto"
+		package: "test.library"
+		message_type: { name: "Book" }
+		service: {
+			name: "Library"
+			method: { name: "WatchBooks" input_type: ".test.library.Book" output_type: ".test.library.Book" server_streaming: true }
+			method: { name: "GetBook" input_type: ".test.library.Book" output_type: ".test.library.Book" }
+		}
+	`, `
+		name: "admin.proto"
+		package: "test.admin"
+		dependency: "library.proto"
+		service: {
+			name: "Admin"
+			method: { name: "Import" input_type: ".test.library.Book" output_type: ".test.library.Book" client_streaming: true server_streaming: true }
+		}
+	`} {
+		fd := new(descriptor.FileDescriptorProto)
+		require.NoError(t, prototext.Unmarshal([]byte(text), fd))
+		files = append(files, fd)
+	}
+
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.ProtoFile = files
+	req.FileToGenerate = []string{"library.proto", "admin.proto"}
+	template := NewTemplate(protokit.ParseCodeGenRequest(req))
+
+	output, err := RenderTemplate(RenderTypeGRPC, template, "")
+	require.NoError(t, err)
+	require.Equal(t, `test.admin.Admin.Import(stream test.library.Book) returns (stream test.library.Book)
+test.library.Library.GetBook(test.library.Book) returns (test.library.Book)
+test.library.Library.WatchBooks(test.library.Book) returns (stream test.library.Book)
+`, string(output))
+}
+
+func TestHTMLLanguageAndDirection(t *testing.T) {
+	proto := `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "Book"
+			field: { name: "title" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+		}
+		source_code_info: {
+			location: { path: [4, 0] span: [1, 0, 1] leading_comments: " 図書館の本。\n" }
+			location: { path: [4, 0, 2, 0] span: [2, 0, 1] leading_comments: " 本の題名（全角文字を含む長い説明文）。\n" }
+		}
+	`
+
+	output, err := RenderTemplate(RenderTypeHTML, newTestTemplate(t, proto), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "<html>")
+
+	tmpl := newTestTemplateWithOptions(t, []TemplateOption{WithLanguage("ja"), WithDirection(DirectionAuto)}, proto)
+	output, err = RenderTemplate(RenderTypeHTML, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `<html lang="ja" dir="auto">`)
+	require.Contains(t, string(output), "<p>図書館の本。</p>")
+	require.Contains(t, string(output), "<td><p>本の題名（全角文字を含む長い説明文）。 </p></td>")
+	require.Contains(t, string(output), "overflow-wrap: anywhere;")
+	require.Contains(t, string(output), "max-width: 60em;")
+}
+
+func TestCompactRenderMode(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+
+	req := utils.CreateGenRequest(set, "Booking.proto")
+	result := protokit.ParseCodeGenRequest(req)
+	for _, kind := range []RenderType{RenderTypeHTML, RenderTypeMarkdown, RenderTypeMDX} {
+		output, err := RenderTemplate(kind, NewTemplate(result), "")
+		require.NoError(t, err)
+		require.Contains(t, string(output), "Unique booking status ID.")
+		require.Contains(t, string(output), "Used to book a vehicle.")
+		require.Contains(t, string(output), "Description")
+
+		output, err = RenderTemplate(kind, NewTemplate(result, WithRenderMode(RenderModeCompact)), "")
+		require.NoError(t, err)
+		require.NotContains(t, string(output), "Unique booking status ID.")
+		require.NotContains(t, string(output), "Used to book a vehicle.")
+		require.NotContains(t, string(output), "Description")
+		require.Contains(t, string(output), "BAD_REQUEST")
+	}
+
+	output, err := RenderTemplate(RenderTypeMarkdown, NewTemplate(result, WithRenderMode(RenderModeCompact)), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `| Field | Type | Label |
+| ----- | ---- | ----- |
+| id | [int32](#int32) | **required** |`)
+	require.Contains(t, string(output), "| BAD_REQUEST | 400 |\n")
+
+	output, err = RenderTemplate(RenderTypeHTML, NewTemplate(result, WithRenderMode(RenderModeCompact)), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "<tr><td>Name</td><td>Number</td></tr>")
+	require.NotContains(t, string(output), "Fields with")
+}
+
+func TestAutoLink(t *testing.T) {
+	proto := `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "Book"
+			field: { name: "shelf" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+		}
+		message_type: { name: "Shelf" }
+		source_code_info: {
+			location: { path: [4, 0] span: [1, 0, 1] leading_comments: " Sits on a test.Shelf, see Shelf or ` + "`test.Shelf`" + `.\n" }
+			location: { path: [4, 0, 2, 0] span: [1, 0, 1] leading_comments: " The name of the test.Shelf (e.g. test.Shelves).\n" }
+		}`
+
+	output, err := RenderTemplate(RenderTypeMarkdown, newTestTemplate(t, proto), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "Sits on a test.Shelf, see Shelf or `test.Shelf`.")
+
+	output, err = RenderTemplate(RenderTypeMarkdown, newTestTemplateWithOptions(t, []TemplateOption{WithAutoLink(AutoLinkFull)}, proto), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "Sits on a [test.Shelf](#test-Shelf), see Shelf or `test.Shelf`.")
+	require.Contains(t, string(output), "| The name of the [test.Shelf](#test-Shelf) (e.g. test.Shelves). |")
+
+	output, err = RenderTemplate(RenderTypeMarkdown, newTestTemplateWithOptions(t, []TemplateOption{WithAutoLink(AutoLinkShort)}, proto), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "Sits on a [test.Shelf](#test-Shelf), see [Shelf](#test-Shelf) or `test.Shelf`.")
+
+	output, err = RenderTemplate(RenderTypeHTML, newTestTemplateWithOptions(t, []TemplateOption{WithAutoLink(AutoLinkFull)}, proto), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `<p>Sits on a <a href="#test-Shelf">test.Shelf</a>, see Shelf or `+"`test.Shelf`.</p>")
+	require.Contains(t, string(output), `<td><p>The name of the <a href="#test-Shelf">test.Shelf</a> (e.g. test.Shelves). </p></td>`)
+
+	output, err = RenderTemplate(RenderTypeMDX, newTestTemplateWithOptions(t, []TemplateOption{WithAutoLink(AutoLinkFull)}, proto), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "Sits on a [test.Shelf](#test-Shelf), see Shelf or `test.Shelf`.")
+}
+
+func TestHeadingOffset(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+
+	req := utils.CreateGenRequest(set, "Booking.proto")
+	result := protokit.ParseCodeGenRequest(req)
+
+	output, err := RenderTemplate(RenderTypeMarkdown, NewTemplate(result, WithHeadingOffset(2)), "")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(output), "### Protocol Documentation\n"))
+	require.Contains(t, string(output), "\n#### Booking.proto\n")
+	require.Contains(t, string(output), "\n##### Booking\n")
+
+	// levels are capped at 6
+	output, err = RenderTemplate(RenderTypeAPIReference, NewTemplate(result, WithHeadingOffset(4)), "")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(output), "##### API Reference\n"))
+	require.Contains(t, string(output), "\n###### Request: ")
+	require.NotContains(t, string(output), "#######")
+
+	output, err = RenderTemplate(RenderTypeMDX, NewTemplate(result, WithHeadingOffset(1)), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "\n### Booking.proto {#Booking-proto}\n")
+}
+
+func TestFootnoteLinks(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+
+	req := utils.CreateGenRequest(set, "Booking.proto")
+	result := protokit.ParseCodeGenRequest(req)
+
+	output, err := RenderTemplate(RenderTypeMarkdown, NewTemplate(result, WithLinkStyle(LinkStyleFootnote)), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "| status | BookingStatus[^2] | **required** | Status of the booking. |\n")
+	require.Contains(t, string(output), "| payment_received | bool[^3] |")
+	require.Contains(t, string(output), "\n\n[^1]: [int32](#int32)\n[^2]: [com.example.BookingStatus](#com-example-BookingStatus)\n")
+	require.Contains(t, string(output), "| BookVehicle | Booking[^8] | BookingStatus[^9] |")
+
+	// types are defined once per section, and numbered across the document
+	require.Equal(t, 1, strings.Count(string(output), "[^3]: [bool](#bool)\n"))
+	require.Contains(t, string(output), "[^9]: [com.example.BookingStatus](#com-example-BookingStatus)\n")
+	require.NotContains(t, string(output), "](#com-example-BookingStatus) |")
+
+	output, err = RenderTemplate(RenderTypeMarkdown, NewTemplate(result), "")
+	require.NoError(t, err)
+	require.NotContains(t, string(output), "[^")
+	require.Contains(t, string(output), "| status | [BookingStatus](#com-example-BookingStatus) |")
+}
+
+func TestPackageOverview(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+
+	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto")
+	result := protokit.ParseCodeGenRequest(req)
+
+	output, err := RenderTemplate(RenderTypeMarkdown, NewTemplate(result), "")
+	require.NoError(t, err)
+	require.NotContains(t, string(output), "Package com.example")
+
+	output, err = RenderTemplate(RenderTypeMarkdown, NewTemplate(result, WithPackageOverview(true)), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "\n## Package com.example\n\n**Booking.proto**\n\nBooking related messages.\n")
+	require.Contains(t, string(output), "\n**Vehicle.proto**\n\nMessages describing manufacturers / vehicles.\n")
+	require.Less(t, strings.Index(string(output), "## Package com.example"), strings.Index(string(output), "## Booking.proto"))
+
+	output, err = RenderTemplate(RenderTypeHTML, NewTemplate(result, WithPackageOverview(true)), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `<h2 id="package-com-example">Package com.example</h2>`)
+	require.Contains(t, string(output), `<p class="package-file"><strong>Vehicle.proto</strong></p>`)
+
+	output, err = RenderTemplate(RenderTypeMarkdown, NewTemplate(result, WithPackageOverview(true),
+		WithRenderMode(RenderModeCompact)), "")
+	require.NoError(t, err)
+	require.NotContains(t, string(output), "Package com.example")
+}
+
+func TestFieldFormatAndUnit(t *testing.T) {
+	docs := docsProto("FieldOptions string format", "FieldOptions string measure")
+	tmpl := newTestTemplateWithOptions(t, []TemplateOption{WithOptionName(UnitOption, "docs.measure")}, docs, `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		dependency: "docs.proto"
+		message_type: {
+			name: "Book"
+			field: { name: "published" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING options: { [docs.format]: "RFC3339" } }
+			field: { name: "size" number: 2 label: LABEL_OPTIONAL type: TYPE_INT64 options: { [docs.measure]: "bytes" } }
+			field: { name: "title" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING }
+		}
+	`)
+
+	book := findMessage("Book", tmpl.Files[0])
+	require.Equal(t, "RFC3339", findField("published", book).Format())
+	require.Empty(t, findField("published", book).Unit())
+	require.Equal(t, "bytes", findField("size", book).Unit())
+	require.Empty(t, findField("title", book).Format())
+	require.Empty(t, findField("title", book).Unit())
+
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "| published | [string](#string)<br>format: `RFC3339` |")
+	require.Contains(t, string(output), "| size | [int64](#int64)<br>unit: bytes |")
+	require.Contains(t, string(output), "| title | [string](#string) |")
+
+	output, err = RenderTemplate(RenderTypeHTML, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `<br><span class="field-hint">format: RFC3339</span></td>`)
+	require.Contains(t, string(output), `<br><span class="field-hint">unit: bytes</span></td>`)
+}
+
+func TestSourceLinks(t *testing.T) {
+	text := `
+		name: "acme/api.proto"
+		package: "test"
+		message_type: {
+			name: "Book"
+			field: { name: "title" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+		}
+		enum_type: { name: "Genre" value: { name: "GENRE_UNSPECIFIED" number: 0 } }
+		service: {
+			name: "Library"
+			method: { name: "GetBook" input_type: ".test.Book" output_type: ".test.Book" }
+		}
+		source_code_info: {
+			location: { path: [4, 0] span: [3, 0, 5, 1] }
+			location: { path: [4, 0, 2, 0] span: [4, 2, 30] }
+			location: { path: [5, 0] span: [7, 0, 9, 1] }
+			location: { path: [6, 0] span: [11, 0, 13, 1] }
+		}
+	`
+	base := "https://github.com/org/repo/blob/main/{file}#L{line}"
+	tmpl := newTestTemplateWithOptions(t, []TemplateOption{WithSourceBaseURL(base)}, text)
+
+	book := findMessage("Book", tmpl.Files[0])
+	require.Equal(t, "https://github.com/org/repo/blob/main/acme/api.proto#L4", book.Source.URL(base))
+	require.Equal(t, "https://github.com/org/repo/blob/main/acme/api.proto#L5", findField("title", book).Source.URL(base))
+	require.Empty(t, book.Source.URL(""))
+	require.Equal(t, "https://example.com/acme/api.proto", (&Source{File: "acme/api.proto"}).URL("https://example.com/{file}"))
+	require.Empty(t, (&Source{File: "acme/api.proto"}).URL(base))
+
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "### Book\n[View source](https://github.com/org/repo/blob/main/acme/api.proto#L4)\n")
+	require.Contains(t, string(output), "### Genre\n[View source](https://github.com/org/repo/blob/main/acme/api.proto#L8)\n")
+	require.Contains(t, string(output), "### Library\n[View source](https://github.com/org/repo/blob/main/acme/api.proto#L12)\n")
+	require.Contains(t, string(output), "| [title](https://github.com/org/repo/blob/main/acme/api.proto#L5) | [string](#string) |")
+
+	output, err = RenderTemplate(RenderTypeMDX, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "[View source](https://github.com/org/repo/blob/main/acme/api.proto#L4)\n")
+	require.Contains(t, string(output), "| [title](https://github.com/org/repo/blob/main/acme/api.proto#L5) |")
+
+	output, err = RenderTemplate(RenderTypeHTML, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output),
+		`<p class="source-link"><a href="https://github.com/org/repo/blob/main/acme/api.proto#L4">View source</a></p>`)
+	require.Contains(t, string(output), `<a href="https://github.com/org/repo/blob/main/acme/api.proto#L5">title</a>`)
+
+	output, err = RenderTemplate(RenderTypeMarkdown, newTestTemplate(t, text), "")
+	require.NoError(t, err)
+	require.NotContains(t, string(output), "View source")
+	require.Contains(t, string(output), "| title | [string](#string) |")
+}
+
+func TestMapNotes(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+
+	req := utils.CreateGenRequest(set, "Vehicle.proto")
+	result := protokit.ParseCodeGenRequest(req)
+
+	output, err := RenderTemplate(RenderTypeMarkdown, NewTemplate(result), "")
+	require.NoError(t, err)
+	require.NotContains(t, string(output), "maps `string` keys")
+
+	output, err = RenderTemplate(RenderTypeMarkdown, NewTemplate(result, WithMapNotes(true)), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "\n> `properties` maps `string` keys to [string](#string) values.\n")
+
+	output, err = RenderTemplate(RenderTypeHTML, NewTemplate(result, WithMapNotes(true)), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `<p class="map-note"><code>properties</code> maps <code>string</code> keys to `)
+}
+
+func TestHTMLPermalinks(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+
+	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto")
+	output, err := RenderTemplate(RenderTypeHTML, NewTemplate(protokit.ParseCodeGenRequest(req)), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `<h3 id="com-example-Vehicle" class="pgd-message">Vehicle<a class="permalink" href="#com-example-Vehicle">#</a></h3>`)
+	require.Contains(t, string(output), `<tr id="com-example-Vehicle--id" class="pgd-field">
+                  <td><span id="com-example-Vehicle--1"></span>id<a class="permalink" href="#com-example-Vehicle--id">#</a></td>`)
+}
+
+func TestBreadcrumbs(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+
+	req := utils.CreateGenRequest(set, "Vehicle.proto")
+	template := NewTemplate(protokit.ParseCodeGenRequest(req))
+
+	output, err := RenderTemplate(RenderTypeHTML, template, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `<p class="breadcrumb"><a href="#com-example-Vehicle">Vehicle</a> › `+
+		`<a href="#com-example-Vehicle-Engine">Engine</a> › Stats</p>`)
+
+	output, err = RenderTemplate(RenderTypeMarkdown, template, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "### Vehicle.Engine.Stats\n"+
+		"[Vehicle](#com-example-Vehicle) › [Engine](#com-example-Vehicle-Engine) › Stats\n\n")
+	require.NotContains(t, string(output), "### Vehicle\n[")
+}
+
+func TestHTMLClasses(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+
+	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto")
+	output, err := RenderTemplate(RenderTypeHTML, NewTemplate(protokit.ParseCodeGenRequest(req)), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `<div class="file-heading pgd-file">`)
+	require.Contains(t, string(output), `<tr id="com-example-Booking--color_preference" class="pgd-field pgd-field-deprecated">`)
+	require.Contains(t, string(output), `<h3 id="com-example-BookingStatus-StatusCode" class="pgd-enum">`)
+	require.Contains(t, string(output), `<tr class="pgd-enum-value">`)
+	require.Contains(t, string(output), `<h3 id="com-example-VehicleService" class="pgd-service">`)
+	require.Contains(t, string(output), `<tr class="pgd-method">`)
+	require.Contains(t, string(output), `<tr class="pgd-extension">`)
+}
+
+func TestTypeRef(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+
+	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto")
+	template := NewTemplate(protokit.ParseCodeGenRequest(req))
+	input := `{{typeRef "com.example.Vehicle" "Vehicle"}}
+{{typeRef "string" "string"}}
+{{typeRef "google.protobuf.Empty" "Empty"}}
+{{typeRef "other.Missing" "<Missing>"}}`
+
+	tests := map[RenderType]string{
+		RenderTypeHTML: `<a href="#com-example-Vehicle">Vehicle</a>
+<a href="#string">string</a>
+<a href="https://protobuf.dev/reference/protobuf/google.protobuf/#empty">Empty</a>
+&lt;Missing&gt;`,
+		RenderTypeMarkdown: `[Vehicle](#com-example-Vehicle)
+[string](#string)
+[Empty](https://protobuf.dev/reference/protobuf/google.protobuf/#empty)
+&lt;Missing&gt;`,
+		RenderTypeDocBook: `<link linkend="com-example-Vehicle">Vehicle</link>
+<link linkend="string">string</link>
+<ulink url="https://protobuf.dev/reference/protobuf/google.protobuf/#empty">Empty</ulink>
+&lt;Missing&gt;`,
+		RenderTypeText: "Vehicle\nstring\nEmpty\n<Missing>",
+	}
+
+	for kind, expected := range tests {
+		output, err := RenderTemplate(kind, template, input)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(output))
+	}
+}
+
+func TestRenderExternalTypes(t *testing.T) {
+	template := newTestTemplate(t, `
+		name: "google/protobuf/timestamp.proto"
+		package: "google.protobuf"
+		message_type: { name: "Timestamp" }
+	`, `
+		name: "google/protobuf/empty.proto"
+		package: "google.protobuf"
+		message_type: { name: "Empty" }
+	`, `
+		name: "api.proto"
+		package: "test"
+		dependency: "google/protobuf/timestamp.proto"
+		dependency: "google/protobuf/empty.proto"
+		message_type: {
+			name: "Book"
+			field: { name: "created" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Timestamp" }
+		}
+		service: {
+			name: "Library"
+			method: { name: "Clear" input_type: ".test.Book" output_type: ".google.protobuf.Empty" }
+		}
+	`)
+
+	timestamp := "https://protobuf.dev/reference/protobuf/google.protobuf/#timestamp"
+	empty := "https://protobuf.dev/reference/protobuf/google.protobuf/#empty"
+	tests := map[RenderType][]string{
+		RenderTypeMarkdown: {
+			"| created | [google.protobuf.Timestamp](" + timestamp + ") |",
+			"| Clear | [Book](#test-Book) | [.google.protobuf.Empty](" + empty + ") |",
+		},
+		RenderTypeAPIReference: {
+			"| created | [google.protobuf.Timestamp](" + timestamp + ") |",
+			"Response: [.google.protobuf.Empty](" + empty + ")",
+		},
+		RenderTypeHTML: {
+			`<td><a href="` + timestamp + `">google.<wbr>protobuf.<wbr>Timestamp</a></td>`,
+			`<td><a href="` + empty + `">.<wbr>google.<wbr>protobuf.<wbr>Empty</a></td>`,
+		},
+		RenderTypeDocBook: {
+			`<entry><ulink url="` + timestamp + `">google.protobuf.Timestamp</ulink></entry>`,
+			`<entry><ulink url="` + empty + `">.google.protobuf.Empty</ulink></entry>`,
+		},
+	}
+
+	for r, fragments := range tests {
+		output, err := RenderTemplate(r, template, "")
+		require.NoError(t, err)
+		for _, fragment := range fragments {
+			require.Contains(t, string(output), fragment, "render type %d", r)
+		}
+		require.NotContains(t, string(output), "google-protobuf-")
+	}
+}
+
+func TestRenderTemplateWithTemplateDir(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+
+	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto")
+	result := protokit.ParseCodeGenRequest(req)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "field.tmpl"), []byte("| {{.Name}} | overridden |"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("{{ignored"), 0644))
+
+	for _, r := range []RenderType{RenderTypeMarkdown, RenderTypeHTML, RenderTypeDocBook} {
+		output, err := RenderTemplate(r, NewTemplate(result, WithTemplateDir(dir)), "")
+		require.NoError(t, err)
+		require.Contains(t, string(output), "| reg_number | overridden |")
+	}
+
+	// blocks that weren't overridden still come from the bundled template
+	output, err := RenderTemplate(RenderTypeMarkdown, NewTemplate(result, WithTemplateDir(dir)), "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "| Method Name | Request Type | Response Type | Description |")
+
+	_, err = RenderTemplate(RenderTypeMarkdown, NewTemplate(result, WithTemplateDir(filepath.Join(dir, "missing"))), "")
+	require.Error(t, err)
+}
+
+func TestMarkdownGitHubAnchors(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+
+	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto")
+	template := NewTemplate(protokit.ParseCodeGenRequest(req), WithAnchorMode(AnchorModeGitHub))
+
+	output, err := RenderTemplate(RenderTypeMarkdown, template, "")
+	require.NoError(t, err)
+
+	md := string(output)
+	require.Contains(t, md, "- [Booking.proto](#bookingproto)")
+	require.Contains(t, md, "  - [Vehicle.Category](#vehiclecategory)")
+	require.Contains(t, md, "<a name=\"vehiclecategory\"></a>\n\n### Vehicle.Category")
+	require.Contains(t, md, "| category | [Vehicle.Category](#vehiclecategory) |")
+	require.Contains(t, md, "<a href=\"#protocol-documentation\">Top</a>")
+	require.Contains(t, md, "[File-level Extensions](#file-level-extensions)")
+	require.NotContains(t, md, "(#com-example-Vehicle-Category)")
+
+	template = NewTemplate(protokit.ParseCodeGenRequest(req))
+	output, err = RenderTemplate(RenderTypeMarkdown, template, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "  - [Vehicle.Category](#com-example-Vehicle-Category)")
+}
+
+func TestTextRenderer(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+
+	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto")
+	template := NewTemplate(protokit.ParseCodeGenRequest(req))
+
+	output, err := RenderTemplate(RenderTypeText, template, "")
+	require.NoError(t, err)
+
+	text := string(output)
+	require.Contains(t, text, "# package com.example\n")
+	require.Contains(t, text, "\nservice com.example.VehicleService - The vehicle service. Manages vehicles and such...\n")
+	require.Contains(t, text, "\n  rpc AddModels(stream com.example.Model) returns (stream com.example.Model) - creates models\n")
+	require.Contains(t, text, "\nmessage com.example.Vehicle - Represents a vehicle that can be hired.\n")
+	require.Contains(t, text, "\n  map<string, string> properties = 7 - bag of properties related to the vehicle.\n")
+	require.Contains(t, text, "\n  oneof travel\n    int32 kilometers = 8\n")
+	require.Contains(t, text, "\n  optional string color_preference = 6 [deprecated] - Color preference of the customer.\n")
+	require.Contains(t, text, "\nenum com.example.Type - The type of model.\n  COUPE = 0 - The type is coupe.\n")
+	require.NotContains(t, text, "PropertiesEntry")
+
+	again, err := RenderTemplate(RenderTypeText, template, "")
+	require.NoError(t, err)
+	require.Equal(t, output, again)
+}
+
 func TestNewRenderType(t *testing.T) {
 	expected := []RenderType{
 		RenderTypeDocBook,
 		RenderTypeHTML,
 		RenderTypeJSON,
 		RenderTypeMarkdown,
+		RenderTypeText,
 	}
 
-	supplied := []string{"docbook", "html", "json", "markdown"}
+	supplied := []string{"docbook", "html", "json", "markdown", "text"}
 
 	for idx, input := range supplied {
 		rt, err := NewRenderType(input)
@@ -5,12 +5,18 @@ import (
 )
 
 var (
+	//go:embed resources/apiref.tmpl
+	apirefTmpl []byte
 	//go:embed resources/docbook.tmpl
 	docbookTmpl []byte
 	//go:embed resources/html.tmpl
 	htmlTmpl []byte
 	//go:embed resources/markdown.tmpl
 	markdownTmpl []byte
+	//go:embed resources/mdx.tmpl
+	mdxTmpl []byte
 	//go:embed resources/scalars.json
 	scalarsJSON []byte
+	//go:embed resources/text.tmpl
+	textTmpl []byte
 )
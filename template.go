@@ -1,17 +1,27 @@
 package gendoc
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"regexp"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin_go "github.com/golang/protobuf/protoc-gen-go/plugin"
 	"github.com/pseudomuto/protoc-gen-doc/extensions"
 	"github.com/pseudomuto/protokit"
+	"gopkg.in/yaml.v3"
 )
 
 var scalarTypes = []string{
@@ -33,19 +43,36 @@ var scalarTypes = []string{
 }
 
 var wellKnownTypes = map[string]string{
-	"Any":         "any",
-	"Api":         "api",
-	"BoolValue":   "bool-value",
-	"BytesValue":  "bytes-value",
-	"DoubleValue": "double-value",
-	"Duration":    "duration",
-	"Empty":       "empty",
-	"Enum":        "enum",
-	"EnumValue":   "enum-value",
-	"Field":       "field",
-	"Cardinality": "cardinality",
-	"Kind":        "kind",
-	"FieldMask":   "field-mask",
+	"Any":           "any",
+	"Api":           "api",
+	"BoolValue":     "bool-value",
+	"BytesValue":    "bytes-value",
+	"DoubleValue":   "double-value",
+	"Duration":      "duration",
+	"Empty":         "empty",
+	"Enum":          "enum",
+	"EnumValue":     "enum-value",
+	"Field":         "field",
+	"Cardinality":   "cardinality",
+	"Kind":          "kind",
+	"FieldMask":     "field-mask",
+	"FloatValue":    "float-value",
+	"Int32Value":    "int32-value",
+	"Int64Value":    "int64-value",
+	"ListValue":     "list-value",
+	"Method":        "method",
+	"Mixin":         "mixin",
+	"NullValue":     "null-value",
+	"Option":        "option",
+	"SourceContext": "source-context",
+	"StringValue":   "string-value",
+	"Struct":        "struct",
+	"Syntax":        "syntax",
+	"Timestamp":     "timestamp",
+	"Type":          "type",
+	"UInt32Value":   "uint32-value",
+	"UInt64Value":   "uint64-value",
+	"Value":         "value",
 }
 
 // Template is a type for encapsulating all the parsed files, messages, fields, enums, services, extensions, etc. into
@@ -58,20 +85,414 @@ type Template struct {
 
 	Packages []*Package
 
-	links map[string]*Link
+	links    map[string]*Link
+	messages map[string]*Message
+	enums    map[string]*Enum
+	slugs    map[string]string
+	fileSlug map[string]string
+
+	// typePackages are the packages of the messages and enums of all the files, imported ones included.
+	typePackages map[string]string
+
+	omitInternal     bool
+	templateDir      string
+	enumHex          bool
+	anchorMode       AnchorMode
+	modules          map[string]string
+	protoFiles       []*descriptorpb.FileDescriptorProto
+	snippets         map[string]string
+	diffBase         *Template
+	trailingComments bool
+	excludedPackages []string
+	externalBaseURL  string
+	sourceBaseURL    string
+	imported         map[string]*Link
+	page             string
+	pages            map[string]string
+	mutators         []TemplateMutator
+	optionNames      map[CustomOption]string
+	visibility       Visibility
+	inlineNested     bool
+	mapNotes         bool
+	pkgOverview      bool
+	lang             string
+	dir              TextDirection
+	typeLang         TypeLanguage
+	mode             RenderMode
+	autoLink         AutoLink
+	headingOffset    int
+	linkStyle        LinkStyle
+}
+
+// TemplateOption configures how NewTemplate builds (and renderers output) a Template.
+type TemplateOption func(*Template)
+
+// WithOmitInternal drops internal messages (e.g. synthetic map entries) from the JSON output.
+func WithOmitInternal(omit bool) TemplateOption {
+	return func(t *Template) { t.omitInternal = omit }
+}
+
+// WithTemplateDir overrides named templates of the rendered template with the `<name>.tmpl` files found in dir.
+func WithTemplateDir(dir string) TemplateOption {
+	return func(t *Template) { t.templateDir = dir }
+}
+
+// WithEnumHex renders the numbers of all enum values in hexadecimal. Without it, only enums with a `hex` option set
+// to true are rendered in hexadecimal.
+func WithEnumHex(hex bool) TemplateOption {
+	return func(t *Template) { t.enumHex = hex }
+}
+
+// WithAnchorMode selects how the anchors of headings are generated (see AnchorMode).
+func WithAnchorMode(mode AnchorMode) TemplateOption {
+	return func(t *Template) { t.anchorMode = mode }
+}
+
+// WithTrailingComments prefers the trailing comments of fields and enum values (e.g. `int32 id = 1; // the id`) over
+// their leading comments when both are present. Without it, the description is made of both, leading comments first.
+func WithTrailingComments(prefer bool) TemplateOption {
+	return func(t *Template) { t.trailingComments = prefer }
+}
+
+// WithExcludedPackages leaves the files of the given packages (e.g. `google.protobuf`) and their sub-packages (e.g.
+// `grpc.reflection.v1` for `grpc`) out of Files and Packages. Types of excluded packages are still linked, externally.
+func WithExcludedPackages(packages []string) TemplateOption {
+	return func(t *Template) { t.excludedPackages = packages }
+}
+
+// WithExternalBaseURL links the types that aren't part of the template, other than well-known types, to the given URL
+// followed by their full name (e.g. `https://docs.example.com/#` links `acme.Money` to
+// `https://docs.example.com/#acme.Money`). These are the types of excluded packages, and those of imported files that
+// weren't generated. Without it, they're rendered as plain names.
+func WithExternalBaseURL(url string) TemplateOption {
+	return func(t *Template) { t.externalBaseURL = url }
+}
+
+// WithSourceBaseURL links the messages, enums, services and fields to their definition in the repository, by the given
+// URL template with `{file}` and `{line}` placeholders (e.g. `https://github.com/org/repo/blob/main/{file}#L{line}`,
+// see Source.URL). The HTML, Markdown and MDX outputs then link them as "View source".
+func WithSourceBaseURL(url string) TemplateOption {
+	return func(t *Template) { t.sourceBaseURL = url }
+}
+
+// SourceBaseURL returns the URL template linking entities to their source (see WithSourceBaseURL), or an empty string.
+func (t *Template) SourceBaseURL() string { return t.sourceBaseURL }
+
+// WithPages sets the path of the page the template is rendered to (e.g. `acme/v1/index.mdx`) and the paths of the pages
+// documenting other types, keyed by full name. The MDX output links the types of other pages relative to its own.
+func WithPages(page string, pages map[string]string) TemplateOption {
+	return func(t *Template) {
+		t.page = page
+		t.pages = pages
+	}
+}
+
+// Uncategorized is the category ByCategory files the messages, enums and services without category under.
+const Uncategorized = "Uncategorized"
+
+// Visibility selects the fields documented by a Template, according to the value of their visibility option (see
+// WithVisibility).
+type Visibility string
+
+const (
+	// VisibilityAll documents all fields, whatever their visibility.
+	VisibilityAll Visibility = "all"
+	// VisibilityPublic leaves out the fields that aren't public.
+	VisibilityPublic Visibility = "public"
+	// VisibilityInternal leaves out the fields that are neither public nor internal.
+	VisibilityInternal Visibility = "internal"
+)
+
+// NewVisibility returns the Visibility with the given name.
+func NewVisibility(visibility string) (Visibility, error) {
+	switch Visibility(visibility) {
+	case VisibilityAll, VisibilityPublic, VisibilityInternal:
+		return Visibility(visibility), nil
+	}
+	return "", fmt.Errorf("Invalid visibility: %s", visibility)
+}
+
+// WithVisibility leaves out the fields that aren't visible at the given level, according to their visibility option
+// (see VisibilityOption), e.g. `option (docs.visibility) = INTERNAL;`. Option values are matched by their last
+// word, so both `INTERNAL` and `VISIBILITY_INTERNAL` make a field internal. Fields without the option, or with a
+// `PUBLIC` or `UNSPECIFIED` value, are public.
+func WithVisibility(visibility Visibility) TemplateOption {
+	return func(t *Template) { t.visibility = visibility }
+}
+
+// Untagged is the tag Service.MethodsByTag files the methods without tags under.
+const Untagged = "Untagged"
+
+// WithInlineNested documents the nested messages returned by Template.InlineCandidates below the fields of the message
+// using them, rather than in sections of their own (see Message.Inlined). Only the HTML, Markdown and MDX templates
+// support it; other outputs are unchanged.
+func WithInlineNested(inline bool) TemplateOption {
+	return func(t *Template) { t.inlineNested = inline }
+}
+
+// WithMapNotes makes the HTML, Markdown and MDX templates follow the fields of messages with a note for each map field,
+// naming its key and value types and linking the value type (see MessageField.MapDoc). Custom templates can check
+// `{{mapNotes}}` (see Template.MapNotes).
+func WithMapNotes(notes bool) TemplateOption {
+	return func(t *Template) { t.mapNotes = notes }
+}
+
+// MapNotes reports whether the map fields of messages are followed by notes (see WithMapNotes).
+func (t *Template) MapNotes() bool { return t.mapNotes }
+
+// WithPackageOverview makes the HTML, Markdown and MDX templates introduce every package, before the files, with the
+// descriptions of its files (see Package.Overview). Custom templates can check `{{packageOverview}}` (see
+// Template.PackageOverview).
+func WithPackageOverview(overview bool) TemplateOption {
+	return func(t *Template) { t.pkgOverview = overview }
+}
+
+// PackageOverview reports whether packages are introduced by the descriptions of their files (see
+// WithPackageOverview).
+func (t *Template) PackageOverview() bool { return t.pkgOverview }
+
+// TextDirection is the direction of the text of the HTML output (see WithDirection).
+type TextDirection string
+
+const (
+	// DirectionLTR renders text left to right.
+	DirectionLTR TextDirection = "ltr"
+	// DirectionRTL renders text right to left, e.g. for Arabic or Hebrew descriptions.
+	DirectionRTL TextDirection = "rtl"
+	// DirectionAuto lets browsers guess the direction from the text.
+	DirectionAuto TextDirection = "auto"
+)
+
+// NewTextDirection returns the TextDirection with the given name.
+func NewTextDirection(dir string) (TextDirection, error) {
+	switch TextDirection(dir) {
+	case DirectionLTR, DirectionRTL, DirectionAuto:
+		return TextDirection(dir), nil
+	}
+	return "", fmt.Errorf("Invalid text direction: %s", dir)
+}
+
+// WithLanguage sets the language of the descriptions (a BCP 47 tag, e.g. `ja` or `ar`), which the HTML output declares
+// on its root element so that browsers pick fonts, line breaking and hyphenation accordingly (see Template.Lang).
+func WithLanguage(lang string) TemplateOption {
+	return func(t *Template) { t.lang = lang }
+}
+
+// WithDirection sets the direction of the text of the HTML output, declared on its root element (see Template.Dir).
+func WithDirection(dir TextDirection) TemplateOption {
+	return func(t *Template) { t.dir = dir }
+}
+
+// Lang returns the language of the descriptions set by WithLanguage, empty if unknown.
+func (t *Template) Lang() string { return t.lang }
+
+// Dir returns the direction of the text set by WithDirection, empty if unset.
+func (t *Template) Dir() TextDirection { return t.dir }
+
+// TypeLanguage is a language protoc generates code for, whose types are shown next to the types of fields (see
+// WithTypeLanguage).
+type TypeLanguage string
+
+const (
+	// TypeLanguageCSharp shows the C# types of fields, e.g. `ulong` for `uint64` or `Vehicle.Types.Category`.
+	TypeLanguageCSharp TypeLanguage = "csharp"
+	// TypeLanguageJava shows the Java types of fields, e.g. `long` for `uint64` or `VehicleProto.Vehicle.Category`.
+	TypeLanguageJava TypeLanguage = "java"
+)
+
+// NewTypeLanguage returns the TypeLanguage with the given name.
+func NewTypeLanguage(lang string) (TypeLanguage, error) {
+	switch TypeLanguage(lang) {
+	case TypeLanguageCSharp, TypeLanguageJava:
+		return TypeLanguage(lang), nil
+	}
+	return "", fmt.Errorf("Invalid type language: %s", lang)
+}
+
+// WithTypeLanguage sets the language whose types are shown next to the types of fields (see MessageField.LanguageType).
+// The HTML, Markdown and MDX templates render them in the type column.
+func WithTypeLanguage(lang TypeLanguage) TemplateOption {
+	return func(t *Template) { t.typeLang = lang }
+}
+
+// TypeLanguage returns the language set by WithTypeLanguage, empty if unset.
+func (t *Template) TypeLanguage() TypeLanguage { return t.typeLang }
+
+// RenderMode is how much the bundled templates document (see WithRenderMode).
+type RenderMode string
+
+const (
+	// RenderModeFull documents everything. It's the default.
+	RenderModeFull RenderMode = "full"
+	// RenderModeCompact leaves out descriptions and options, for a quick reference of the names, types and numbers.
+	RenderModeCompact RenderMode = "compact"
+)
+
+// NewRenderMode returns the RenderMode with the given name.
+func NewRenderMode(mode string) (RenderMode, error) {
+	switch RenderMode(mode) {
+	case RenderModeFull, RenderModeCompact:
+		return RenderMode(mode), nil
+	}
+	return "", fmt.Errorf("Invalid render mode: %s", mode)
+}
+
+// WithRenderMode sets how much the HTML, Markdown and MDX templates document. In compact mode, they leave out the
+// descriptions of all entities, the columns holding them and the options of files, messages and services. Custom
+// templates can do the same with `{{if not compact}}` (see Template.Compact).
+func WithRenderMode(mode RenderMode) TemplateOption {
+	return func(t *Template) { t.mode = mode }
+}
+
+// Compact reports whether the template is rendered in compact mode (see WithRenderMode).
+func (t *Template) Compact() bool { return t.mode == RenderModeCompact }
+
+// AutoLink is how strictly the bundled templates link the names of types mentioned in descriptions (see WithAutoLink).
+type AutoLink string
+
+const (
+	// AutoLinkOff leaves descriptions as they are. It's the default.
+	AutoLinkOff AutoLink = "off"
+	// AutoLinkFull links the full names of types, e.g. `com.example.Vehicle`.
+	AutoLinkFull AutoLink = "full"
+	// AutoLinkShort links the names of types relative to their package as well (e.g. `Vehicle` or `Vehicle.Category`),
+	// unless several types share the name.
+	AutoLinkShort AutoLink = "short"
+)
+
+// NewAutoLink returns the AutoLink with the given name.
+func NewAutoLink(mode string) (AutoLink, error) {
+	switch AutoLink(mode) {
+	case AutoLinkOff, AutoLinkFull, AutoLinkShort:
+		return AutoLink(mode), nil
+	}
+	return "", fmt.Errorf("Invalid auto link mode: %s", mode)
+}
+
+// WithAutoLink makes the HTML, Markdown and MDX templates link the bare names of the types of the template mentioned in
+// descriptions, e.g. "see com.example.Vehicle". Names in code spans aren't linked. Custom templates can do the same
+// with the autoLink function (e.g. `{{autoLink .Description}}`).
+func WithAutoLink(mode AutoLink) TemplateOption {
+	return func(t *Template) { t.autoLink = mode }
+}
+
+// WithHeadingOffset shifts the headings of the Markdown, MDX and API reference outputs down by the given number of
+// levels, e.g. for embedding them into a larger document: with an offset of 2, the `#` title becomes `###`. Levels are
+// capped at 6. Custom templates can do the same with the heading function (see Template.Heading).
+func WithHeadingOffset(offset int) TemplateOption {
+	return func(t *Template) { t.headingOffset = offset }
+}
+
+// Heading returns the Markdown marker of a heading of the given level, shifted by the heading offset (see
+// WithHeadingOffset), e.g. `###` for a level 2 heading with an offset of 1.
+func (t *Template) Heading(level int) string {
+	return strings.Repeat("#", min(max(level+t.headingOffset, 1), 6))
+}
+
+// LinkStyle is how the Markdown output links the types of fields and methods (see WithLinkStyle).
+type LinkStyle string
+
+const (
+	// LinkStyleInline links the types in the tables, e.g. `[Vehicle](#com-example-Vehicle)`. It's the default.
+	LinkStyleInline LinkStyle = "inline"
+	// LinkStyleFootnote marks the types with footnotes, e.g. `Vehicle[^1]`, defined below the table of each message and
+	// service.
+	LinkStyleFootnote LinkStyle = "footnote"
+)
+
+// NewLinkStyle returns the LinkStyle with the given name.
+func NewLinkStyle(style string) (LinkStyle, error) {
+	switch LinkStyle(style) {
+	case LinkStyleInline, LinkStyleFootnote:
+		return LinkStyle(style), nil
+	}
+	return "", fmt.Errorf("Invalid link style: %s", style)
+}
+
+// WithLinkStyle sets how the Markdown output links the types of fields and methods. Footnotes keep the tables narrow.
+// Custom templates can do the same with the footnote and footnotes functions (see Template.FootnoteLinks).
+func WithLinkStyle(style LinkStyle) TemplateOption {
+	return func(t *Template) { t.linkStyle = style }
+}
+
+// FootnoteLinks reports whether types are linked with footnotes (see WithLinkStyle). Templates mark a type with
+// `{{footnote <FULL TYPE> <TEXT>}}`, and `{{footnotes}}` defines the footnotes marked since the previous call, e.g. at
+// the end of each section.
+func (t *Template) FootnoteLinks() bool { return t.linkStyle == LinkStyleFootnote }
+
+// TemplateMutator adjusts a Template (e.g. renames, filters or annotates entities) once NewTemplate has built it, before
+// it's rendered.
+//
+// Mutators must keep the template consistent: entities they add must be sorted like the others (files in input order,
+// the entities of files and packages by name) and must appear both in their file and in their package. Links are
+// indexed again after the mutators ran, so types are linked by the full names the mutators leave them with.
+type TemplateMutator func(*Template)
+
+// WithMutators runs the mutators, in order, at the end of NewTemplate.
+func WithMutators(mutators ...TemplateMutator) TemplateOption {
+	return func(t *Template) { t.mutators = append(t.mutators, mutators...) }
+}
+
+// WithModules sets File.Module from the given mapping of file names (e.g. `acme/v1/api.proto`) to the names of the
+// modules owning them (e.g. Buf modules like `buf.build/acme/api`).
+func WithModules(modules map[string]string) TemplateOption {
+	return func(t *Template) { t.modules = modules }
+}
+
+// WithProtoFiles sets the files the custom options of the documented files are resolved with, i.e. the ProtoFile of the
+// CodeGeneratorRequest, which includes the imported files declaring the options (e.g. `docs/options.proto` for
+// `option (docs.category) = "Billing";`). Without it, only options declared by the documented files are resolved.
+// Options whose extensions are registered in the running binary are known either way.
+func WithProtoFiles(files []*descriptorpb.FileDescriptorProto) TemplateOption {
+	return func(t *Template) { t.protoFiles = files }
+}
+
+// WithSnippets sets the snippets that comments include with `@include <name>` lines, e.g. the documentation of pagination
+// shared by many messages. Each line is replaced with the snippet of the given name before descriptions are rendered.
+// Lines naming unknown snippets are left as they are (see Template.Validate).
+func WithSnippets(snippets map[string]string) TemplateOption {
+	return func(t *Template) { t.snippets = snippets }
+}
+
+// WithDiffBase sets the previous version of the files, which the diff output compares the template with (see
+// DiffTemplates), e.g. the template of the last release.
+func WithDiffBase(base *Template) TemplateOption {
+	return func(t *Template) { t.diffBase = base }
 }
 
 // NewTemplate creates a Template object from a set of descriptors.
-func NewTemplate(descs []*protokit.FileDescriptor) *Template {
+func NewTemplate(descs []*protokit.FileDescriptor, opts ...TemplateOption) *Template {
+	res := &Template{
+		Scalars: makeScalars(),
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+
+	protoFiles := res.protoFiles
+	if protoFiles == nil {
+		for _, f := range descs {
+			protoFiles = append(protoFiles, f.FileDescriptorProto)
+		}
+	}
+	options := newOptionResolver(protoFiles)
+	res.typePackages = typePackages(protoFiles)
+
 	files := make([]*File, 0, len(descs))
 	packagesByName := map[string]*Package{}
-	messagesByName := map[string]*Message{}
 
 	for _, f := range descs {
+		if res.isExcludedPackage(f.GetPackage()) {
+			continue
+		}
+
 		file := &File{
 			Name:          f.GetName(),
 			Description:   description(f.GetSyntaxComments().String()),
 			Package:       f.GetPackage(),
+			Checksum:      checksum(f.FileDescriptorProto),
+			Module:        res.modules[f.GetName()],
+			Deprecated:    f.GetOptions().GetDeprecated(),
 			HasEnums:      len(f.Enums) > 0,
 			HasExtensions: len(f.Extensions) > 0,
 			HasMessages:   len(f.Messages) > 0,
@@ -80,9 +501,12 @@ func NewTemplate(descs []*protokit.FileDescriptor) *Template {
 			Extensions:    make(orderedExtensions, 0, len(f.Extensions)),
 			Messages:      make(orderedMessages, 0, len(f.Messages)),
 			Services:      make(orderedServices, 0, len(f.Services)),
-			Options:       mergeOptions(extractOptions(f.GetOptions()), extensions.Transform(f.OptionExtensions)),
+			Options:       mergeOptions(extractOptions(options.resolve(f.GetOptions())), extensions.Transform(f.OptionExtensions)),
+			Features:      fileFeatures(f),
 			FDS:           f,
 		}
+		file.Description, file.frontMatter = frontMatter(file.Description)
+		file.Meta = frontMatterMeta(file.frontMatter)
 
 		pkg, ok := packagesByName[file.Package]
 		if !ok {
@@ -97,7 +521,7 @@ func NewTemplate(descs []*protokit.FileDescriptor) *Template {
 		}
 
 		for i, e := range f.Enums {
-			file.Enums = append(file.Enums, parseEnum(f, []int32{5, int32(i)}, e))
+			file.Enums = append(file.Enums, parseEnum(f, []int32{5, int32(i)}, e, options, res.trailingComments))
 		}
 
 		for _, e := range f.Extensions {
@@ -106,25 +530,39 @@ func NewTemplate(descs []*protokit.FileDescriptor) *Template {
 		}
 
 		// Recursively add nested types from messages
-		var addFromMessage func([]int32, *protokit.Descriptor)
-		addFromMessage = func(acc []int32, m *protokit.Descriptor) {
-			file.Messages = append(file.Messages, parseMessage(f, acc, m))
+		var addFromMessage func([]int32, *protokit.Descriptor) *Message
+		addFromMessage = func(acc []int32, m *protokit.Descriptor) *Message {
+			msg := parseMessage(f, acc, m, options, res.trailingComments)
+			res.hideFields(msg)
+			file.Messages = append(file.Messages, msg)
 			for j, e := range m.Enums {
-				file.Enums = append(file.Enums, parseEnum(f, append(acc, []int32{4, int32(j)}...), e))
+				enum := parseEnum(f, append(acc, []int32{4, int32(j)}...), e, options, res.trailingComments)
+				file.Enums = append(file.Enums, enum)
+				msg.NestedEnums = append(msg.NestedEnums, enum)
 			}
 			for j, n := range m.Messages {
-				addFromMessage(append(acc, []int32{3, int32(j)}...), n)
+				nested := addFromMessage(append(acc, []int32{3, int32(j)}...), n)
+				nested.Parent = msg
+				msg.NestedMessages = append(msg.NestedMessages, nested)
 			}
+			resolveMapFields(msg)
+			return msg
 		}
 		for i, m := range f.Messages {
 			addFromMessage([]int32{4, int32(i)}, m)
 		}
-		for _, m := range file.Messages {
-			messagesByName[m.FullName] = m
-		}
 
 		for i, s := range f.Services {
-			file.Services = append(file.Services, parseService(f, []int32{6, int32(i)}, s))
+			file.Services = append(file.Services, parseService(f, []int32{6, int32(i)}, s, options))
+		}
+
+		for _, enum := range file.Enums {
+			// hex numbers
+			if res.enumHex || hasHexOption(enum.Options) {
+				for _, value := range enum.Values {
+					value.hex = true
+				}
+			}
 		}
 
 		sort.Sort(file.Enums)
@@ -139,12 +577,9 @@ func NewTemplate(descs []*protokit.FileDescriptor) *Template {
 		files = append(files, file)
 	}
 
-	res := &Template{
-		Files:   files,
-		Scalars: makeScalars(),
-		links:   map[string]*Link{},
-	}
+	res.Files = files
 
+	res.Packages = make([]*Package, 0, len(packagesByName))
 	for _, pkg := range packagesByName {
 		sort.Slice(pkg.Services, func(i, j int) bool {
 			return pkg.Services[i].FullName < pkg.Services[j].FullName
@@ -156,50 +591,21 @@ func NewTemplate(descs []*protokit.FileDescriptor) *Template {
 			return pkg.Enums[i].FullName < pkg.Enums[j].FullName
 		})
 
-		for _, msg := range pkg.Messages {
-			// links
-			res.links[msg.FullName] = &Link{
-				Package:  pkg.Name,
-				FullName: msg.FullName,
-			}
-
-			// maps
-			var fields []*MessageField
-			fields = append(fields, msg.Fields...)
-			for _, oneOf := range msg.OneOfs {
-				fields = append(fields, oneOf.Fields...)
-			}
-
-			for _, field := range fields {
-				if field.IsMap {
-					mType := messagesByName[field.FullType]
-					mType.Internal = true
-					for _, mtf := range mType.Fields {
-						if mtf.Name == "key" {
-							field.MapKeyType = mtf.FullType
-							continue
-						}
-						if mtf.Name == "value" {
-							field.MapValueType = mtf.FullType
-							continue
-						}
-					}
-				}
-			}
-		}
-		for _, enum := range pkg.Enums {
-			res.links[enum.FullName] = &Link{
-				Package:  pkg.Name,
-				FullName: enum.FullName,
-			}
-		}
-
 		res.Packages = append(res.Packages, pkg)
 	}
 	sort.Slice(res.Packages, func(i, j int) bool {
 		return res.Packages[i].Name < res.Packages[j].Name
 	})
 
+	res.expandIncludes()
+	res.index()
+	if len(res.mutators) > 0 {
+		for _, mutate := range res.mutators {
+			mutate(res)
+		}
+		res.index()
+	}
+
 	//for _, scalarType := range scalarTypes {
 	//	res.links[scalarType] = &Link{
 	//		External:     true,
@@ -210,6 +616,474 @@ func NewTemplate(descs []*protokit.FileDescriptor) *Template {
 	return res
 }
 
+// NewTemplateFromFileDescriptorSet creates a Template documenting all the files of a descriptor set, e.g. one written by
+// `protoc --descriptor_set_out` or `buf build`, without running protoc. Descriptions are empty unless the set includes
+// source info (`--include_source_info`). The set is invalid when files depend on files that aren't part of it, so it
+// should include imports (`--include_imports`); excluded packages (see WithExcludedPackages) leave them out again.
+func NewTemplateFromFileDescriptorSet(fds *descriptorpb.FileDescriptorSet, opts ...TemplateOption) (*Template, error) {
+	if _, err := protodesc.NewFiles(fds); err != nil {
+		return nil, fmt.Errorf("Invalid descriptor set: %w", err)
+	}
+
+	req := &plugin_go.CodeGeneratorRequest{ProtoFile: fds.GetFile()}
+	for _, file := range fds.GetFile() {
+		req.FileToGenerate = append(req.FileToGenerate, file.GetName())
+	}
+	return NewTemplate(protokit.ParseCodeGenRequest(req), opts...), nil
+}
+
+// index (re)builds the links and messages by full name, and resolves the types of fields and the request and response
+// types of methods with them. Entities are (re)ordered by their order option first (see OrderOption).
+func (t *Template) index() {
+	t.sortByOrder()
+	t.indexSlugs()
+
+	t.links = map[string]*Link{}
+	t.messages = map[string]*Message{}
+	t.enums = map[string]*Enum{}
+	for _, file := range t.Files {
+		for _, msg := range file.Messages {
+			t.links[msg.FullName] = &Link{Package: file.Package, FullName: msg.FullName, Anchor: msg.Anchor}
+			t.messages[msg.FullName] = msg
+		}
+		for _, enum := range file.Enums {
+			t.links[enum.FullName] = &Link{Package: file.Package, FullName: enum.FullName, Anchor: enum.Anchor}
+			t.enums[enum.FullName] = enum
+		}
+	}
+	t.indexImported()
+
+	for _, file := range t.Files {
+		for _, msg := range file.Messages {
+			msg.category = t.category(msg.Options)
+			msg.SeeAlso = t.resolveSeeAlso(msg.seeRefs)
+			msg.ReplacedBy = t.replacedBy(file.Package, msg.Options)
+			for _, ext := range msg.Extensions {
+				ext.enumDefault = t.enumDefault(ext.FullType, ext.DefaultValue)
+				ext.typePackage = t.typePackage(ext.FullType)
+			}
+			for _, field := range msg.allFields() {
+				field.ReplacedBy = t.replacedBy(file.Package, field.Options)
+				field.typePackage = t.typePackage(field.FullType)
+				field.messageType = t.messages[field.FullType]
+				field.enumType = t.enums[field.FullType]
+				field.SeeAlso = t.resolveSeeAlso(field.seeRefs)
+				field.AnyTypes = t.resolveSeeAlso(field.anyRefs)
+				field.LanguageType = t.languageType(field)
+				field.example, _ = t.customOption(field.Options, ExampleOption).(string)
+				field.format, _ = t.customOption(field.Options, FormatOption).(string)
+				field.unit, _ = t.customOption(field.Options, UnitOption).(string)
+				field.enumDefault = t.enumDefault(field.FullType, field.DefaultValue)
+				field.mapValueLink = nil
+				if field.IsMap {
+					field.mapValueLink = t.resolveLink(field.MapValueType)
+				}
+			}
+		}
+		for _, ext := range file.Extensions {
+			ext.enumDefault = t.enumDefault(ext.FullType, ext.DefaultValue)
+			ext.typePackage = t.typePackage(ext.FullType)
+		}
+		for _, enum := range file.Enums {
+			enum.category = t.category(enum.Options)
+			enum.SeeAlso = t.resolveSeeAlso(enum.seeRefs)
+			for _, value := range enum.Values {
+				value.ReplacedBy = t.replacedBy(file.Package, value.Options)
+			}
+		}
+	}
+
+	for _, file := range t.Files {
+		for _, service := range file.Services {
+			service.category = t.category(service.Options)
+			for _, method := range service.Methods {
+				method.RequestLink = t.resolveLink(method.RequestFullType)
+				method.ResponseLink = t.resolveLink(method.ResponseFullType)
+				method.RequestMessage = t.messages[method.RequestFullType]
+				method.ResponseMessage = t.messages[method.ResponseFullType]
+				method.SeeAlso = t.resolveSeeAlso(method.seeRefs)
+				method.ReplacedBy = t.replacedBy(file.Package, method.Options)
+				tags, _ := t.customOption(method.Options, TagsOption).(string)
+				method.Tags = splitList(tags)
+			}
+		}
+	}
+
+	for _, file := range t.Files {
+		for _, msg := range file.Messages {
+			msg.inlined = false
+			for _, field := range msg.allFields() {
+				field.inlineMessage = nil
+			}
+		}
+	}
+	if t.inlineNested {
+		for _, msg := range t.InlineCandidates() {
+			msg.inlined = true
+			t.references(msg.FullName)[0].inlineMessage = msg
+		}
+	}
+}
+
+// enumDefault returns the default value of a field typed with the enum fullType along with its number, e.g.
+// `ACTIVE (1)`. It's empty when there's no default, or the enum or the value can't be resolved.
+func (t *Template) enumDefault(fullType, value string) string {
+	if enum := t.enums[fullType]; enum != nil && value != "" {
+		for _, v := range enum.Values {
+			if v.Name == value {
+				return fmt.Sprintf("%s (%s)", v.Name, v.Number)
+			}
+		}
+	}
+	return ""
+}
+
+// indexSlugs (re)assigns the anchors of the files, messages, enums and services of the template (see Slug).
+func (t *Template) indexSlugs() {
+	t.slugs, t.fileSlug = map[string]string{}, map[string]string{}
+	used := map[string]bool{}
+	add := func(kind, name string) string {
+		slug := SlugFor(name)
+		if used[slug] {
+			base := slug + "-" + kind
+			slug = base
+			for i := 1; used[slug]; i++ {
+				slug = fmt.Sprintf("%s-%d", base, i)
+			}
+		}
+		used[slug] = true
+		if kind == "file" {
+			t.fileSlug[name] = slug
+		} else {
+			t.slugs[name] = slug
+		}
+		return slug
+	}
+
+	for _, scalar := range scalarTypes {
+		add("scalar", scalar)
+	}
+	for _, file := range t.Files {
+		for _, msg := range file.Messages {
+			msg.Anchor = add("message", msg.FullName)
+			for _, field := range msg.allFields() {
+				field.messageAnchor = msg.Anchor
+			}
+		}
+		for _, enum := range file.Enums {
+			enum.Anchor = add("enum", enum.FullName)
+		}
+		for _, service := range file.Services {
+			service.Anchor = add("service", service.FullName)
+		}
+	}
+	for _, file := range t.Files {
+		file.Anchor = add("file", file.Name)
+	}
+}
+
+// Slug returns the anchor of the file, message, enum or service with the given full name (the name of files), which
+// every output format uses. It's SlugFor, except for files whose anchor would be the one of a type (e.g. `acme.proto`
+// and the message `acme.proto`), which are suffixed with their kind (`acme-proto-file`). Other names get SlugFor.
+func (t *Template) Slug(kind, fullName string) string {
+	slugs := t.slugs
+	if kind == "file" {
+		slugs = t.fileSlug
+	}
+	if slug, ok := slugs[fullName]; ok {
+		return slug
+	}
+	return SlugFor(fullName)
+}
+
+// InlineCandidates returns the nested (non-internal) messages that are used exactly once, as the type of a field of a
+// message other than a map entry, and that don't contain themselves (recursively). Such helper messages can be
+// documented along with the message using them (see WithInlineNested).
+func (t *Template) InlineCandidates() []*Message {
+	uses, _ := t.uses()
+
+	var candidates []*Message
+	for _, file := range t.Files {
+		for _, msg := range file.VisibleMessages() {
+			if msg.Parent == nil || uses[msg.FullName] != 1 || containsMessage(msg, msg.FullName, nil) {
+				continue
+			}
+			if refs := t.references(msg.FullName); len(refs) == 1 && !t.messages[refs[0].message].Internal {
+				candidates = append(candidates, msg)
+			}
+		}
+	}
+	return candidates
+}
+
+// SharedMessages returns the visible messages that aren't only the request or response of a single method: those used
+// by several methods or fields, and those no method nor field uses. The `apiref` output documents them in an appendix,
+// and the others along with the method using them.
+func (t *Template) SharedMessages() []*Message {
+	uses, methodUses := t.uses()
+
+	var shared []*Message
+	for _, file := range t.Files {
+		for _, msg := range file.VisibleMessages() {
+			if uses[msg.FullName] != 1 || methodUses[msg.FullName] != 1 {
+				shared = append(shared, msg)
+			}
+		}
+	}
+	return shared
+}
+
+// IsSharedMessage returns whether msg is one of the SharedMessages.
+func (t *Template) IsSharedMessage(msg *Message) bool {
+	uses, methodUses := t.uses()
+	return uses[msg.FullName] != 1 || methodUses[msg.FullName] != 1
+}
+
+// uses counts the uses of the types of the template by full name: as the type of fields, extensions, and method
+// requests or responses. The uses by methods are counted separately as well.
+func (t *Template) uses() (map[string]int, map[string]int) {
+	uses := make(map[string]int)
+	methodUses := make(map[string]int)
+	for _, file := range t.Files {
+		for _, msg := range file.Messages {
+			for _, field := range msg.allFields() {
+				uses[field.FullType]++
+			}
+			for _, ext := range msg.Extensions {
+				uses[ext.FullType]++
+			}
+		}
+		for _, ext := range file.Extensions {
+			uses[ext.FullType]++
+		}
+		for _, service := range file.Services {
+			for _, method := range service.Methods {
+				for _, fullType := range []string{method.RequestFullType, method.ResponseFullType} {
+					uses[fullType]++
+					methodUses[fullType]++
+				}
+			}
+		}
+	}
+	return uses, methodUses
+}
+
+// references returns the fields of the messages of the template typed with the given message.
+func (t *Template) references(fullName string) []*MessageField {
+	var refs []*MessageField
+	for _, file := range t.Files {
+		for _, msg := range file.Messages {
+			for _, field := range msg.allFields() {
+				if field.FullType == fullName {
+					refs = append(refs, field)
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// containsMessage returns whether the fields of msg, or of the messages they're typed with (recursively), are typed
+// with the given message.
+func containsMessage(msg *Message, fullName string, seen map[string]bool) bool {
+	if seen == nil {
+		seen = make(map[string]bool)
+	}
+	seen[msg.FullName] = true
+	for _, field := range msg.allFields() {
+		if field.FullType == fullName {
+			return true
+		}
+		if nested := field.messageType; nested != nil && !seen[nested.FullName] && containsMessage(nested, fullName, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// hideFields removes the fields that aren't visible (see WithVisibility) from the message and its oneofs.
+func (t *Template) hideFields(msg *Message) {
+	if t.visibility == "" || t.visibility == VisibilityAll {
+		return
+	}
+
+	hidden := func(field *MessageField) bool { return !t.isVisible(field.Options) }
+	msg.Fields = slices.DeleteFunc(msg.Fields, hidden)
+	oneOfs := msg.OneOfs[:0]
+	for _, oneOf := range msg.OneOfs {
+		if oneOf.Fields = slices.DeleteFunc(oneOf.Fields, hidden); len(oneOf.Fields) > 0 {
+			oneOfs = append(oneOfs, oneOf)
+		}
+	}
+	msg.OneOfs = oneOfs
+
+	fields := msg.allFields()
+	msg.HasFields = len(fields) > 0
+	msg.HasOneofs = len(msg.OneOfs) > 0
+	behaviorColumn := msg.HasFieldBehaviors()
+	for _, field := range fields {
+		field.behaviorColumn = behaviorColumn
+	}
+}
+
+// isVisible returns whether a field with the given options is visible at the visibility of the template.
+func (t *Template) isVisible(options map[string]interface{}) bool {
+	value := t.customOption(options, VisibilityOption)
+	if value == nil {
+		return true
+	}
+
+	level := strings.ToUpper(fmt.Sprint(value))
+	if i := strings.LastIndex(level, "_"); i >= 0 {
+		level = level[i+1:]
+	}
+	switch level {
+	case "PUBLIC", "UNSPECIFIED":
+		return true
+	case "INTERNAL":
+		return t.visibility == VisibilityInternal
+	}
+	return t.visibility == VisibilityAll
+}
+
+// category returns the value of the category option among the options, if it's set.
+func (t *Template) category(options map[string]interface{}) string {
+	category, _ := t.customOption(options, CategoryOption).(string)
+	return category
+}
+
+// replacedBy returns the link to the successor named by the replaced-by option among the options, if it's set. The
+// name is resolved as a full name first, then relative to the package. Fields (e.g. `acme.Book.title`) are linked by
+// the package and full name of the field.
+func (t *Template) replacedBy(pkg string, options map[string]interface{}) *Link {
+	name, _ := t.customOption(options, ReplacedByOption).(string)
+	if name = strings.TrimPrefix(strings.TrimSpace(name), "."); name == "" {
+		return nil
+	}
+
+	candidates := []string{name}
+	if pkg != "" && !strings.HasPrefix(name, pkg+".") {
+		candidates = append(candidates, pkg+"."+name)
+	}
+	for _, fullName := range candidates {
+		if l := t.resolveLink(fullName); l != nil {
+			return l
+		}
+		if i := strings.LastIndex(fullName, "."); i >= 0 {
+			if msg, ok := t.messages[fullName[:i]]; ok && msg.fieldNamed(fullName[i+1:]) != nil {
+				return &Link{Package: t.links[msg.FullName].Package, FullName: fullName}
+			}
+		}
+	}
+	return &Link{FullName: name}
+}
+
+// order returns the value of the order option among the options, if it's set to an integer.
+func (t *Template) order(options map[string]interface{}) (int64, bool) {
+	switch v := t.customOption(options, OrderOption).(type) {
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	case int:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+// sortByOrder moves the messages, enums, services and fields with an order option (see OrderOption) first, by
+// increasing value. The others, and ties, keep their order.
+func (t *Template) sortByOrder() {
+	for _, file := range t.Files {
+		sortByOrder(t, file.Messages, func(m *Message) map[string]interface{} { return m.Options })
+		sortByOrder(t, file.Enums, func(e *Enum) map[string]interface{} { return e.Options })
+		sortByOrder(t, file.Services, func(s *Service) map[string]interface{} { return s.Options })
+		for _, msg := range file.Messages {
+			sortByOrder(t, msg.Fields, func(f *MessageField) map[string]interface{} { return f.Options })
+			for _, oneOf := range msg.OneOfs {
+				sortByOrder(t, oneOf.Fields, func(f *MessageField) map[string]interface{} { return f.Options })
+			}
+		}
+	}
+	for _, pkg := range t.Packages {
+		sortByOrder(t, pkg.Messages, func(m *Message) map[string]interface{} { return m.Options })
+		sortByOrder(t, pkg.Enums, func(e *Enum) map[string]interface{} { return e.Options })
+		sortByOrder(t, pkg.Services, func(s *Service) map[string]interface{} { return s.Options })
+	}
+}
+
+func sortByOrder[T any](t *Template, entities []T, options func(T) map[string]interface{}) {
+	sort.SliceStable(entities, func(i, j int) bool {
+		a, okA := t.order(options(entities[i]))
+		b, okB := t.order(options(entities[j]))
+		return okA && (!okB || a < b)
+	})
+}
+
+// ByCategory groups the (non-internal) messages, enums and services of the template by category (see
+// CategoryOption), e.g. for rendering a landing page per category. Entities without category are grouped under
+// Uncategorized. Within a category, entities are listed file by file: messages, enums, then services.
+func (t *Template) ByCategory() map[string][]interface{} {
+	groups := make(map[string][]interface{})
+	add := func(category string, entity interface{}) {
+		if category == "" {
+			category = Uncategorized
+		}
+		groups[category] = append(groups[category], entity)
+	}
+
+	for _, file := range t.Files {
+		for _, msg := range file.VisibleMessages() {
+			add(msg.category, msg)
+		}
+		for _, enum := range file.Enums {
+			add(enum.category, enum)
+		}
+		for _, service := range file.Services {
+			add(service.category, service)
+		}
+	}
+	return groups
+}
+
+// checksum hashes the deterministic encoding of the descriptor, so equal descriptors always have equal checksums.
+func checksum(fd *descriptor.FileDescriptorProto) string {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(fd)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// resolveMapFields sets the key and value types of the map fields of msg and marks their (nested) entry messages as
+// internal.
+func resolveMapFields(msg *Message) {
+	for _, field := range msg.Fields {
+		if !field.IsMap {
+			continue
+		}
+		for _, entry := range msg.NestedMessages {
+			if entry.FullName != field.FullType {
+				continue
+			}
+			entry.Internal = true
+			for _, mtf := range entry.Fields {
+				switch mtf.Name {
+				case "key":
+					field.MapKeyType, field.mapKeyLabel = mtf.FullType, mtf.LongType
+				case "value":
+					field.MapValueType, field.mapValueLabel = mtf.FullType, mtf.LongType
+				}
+			}
+		}
+	}
+}
+
 func makeScalars() []*ScalarValue {
 	var scalars []*ScalarValue
 	json.Unmarshal(scalarsJSON, &scalars)
@@ -217,7 +1091,20 @@ func makeScalars() []*ScalarValue {
 	return scalars
 }
 
-func mergeOptions(opts ...map[string]interface{}) map[string]interface{} {
+func mergeOptions(opts ...map[string]interface{}) map[string]interface{} {
+	// Most entities have options from a single source at most, these don't need to be copied.
+	var single map[string]interface{}
+	sources := 0
+	for _, opts := range opts {
+		if len(opts) > 0 {
+			single = opts
+			sources++
+		}
+	}
+	if sources <= 1 {
+		return single
+	}
+
 	out := make(map[string]interface{})
 	for _, opts := range opts {
 		for k, v := range opts {
@@ -239,38 +1126,221 @@ type commonOptions interface {
 }
 
 func extractOptions(opts protoreflect.ProtoMessage) map[string]interface{} {
-	out := make(map[string]any)
-	if opts.(commonOptions).GetDeprecated() {
-		out["deprecated"] = true
+	var out map[string]any
+	if c, ok := opts.(commonOptions); ok && c.GetDeprecated() {
+		out = addOption(out, "deprecated", true)
 	}
 	switch opts := opts.(type) {
 	case *descriptor.MethodOptions:
 		if opts != nil && opts.IdempotencyLevel != nil {
-			out["idempotency_level"] = opts.IdempotencyLevel.String()
+			out = addOption(out, "idempotency_level", opts.IdempotencyLevel.String())
 		}
 	}
 
-	extensionOptionsJson, _ := protojson.Marshal(opts)
-	extMap := make(map[string]any)
-	json.Unmarshal(extensionOptionsJson, &extMap)
+	if opts != nil && opts.ProtoReflect().IsValid() {
+		opts.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+			out = addOption(out, optionKey(fd), optionValue(fd, v))
+			return true
+		})
+	}
+
+	return out
+}
 
-	resMap := map[string]any{}
-	for k, v := range extMap {
-		resMap[strings.Trim(k, "[]")] = v
+// addOption adds the option unless it's already set, allocating the map on first use.
+func addOption(opts map[string]any, name string, value any) map[string]any {
+	if opts == nil {
+		opts = make(map[string]any)
+	}
+	if _, ok := opts[name]; !ok {
+		opts[name] = value
 	}
+	return opts
+}
 
-	out = mergeOptions(out, resMap)
+// optionKey returns the key of an option field, at any level: its JSON name (e.g. `javaPackage`), like protojson, or
+// the full name of extensions (e.g. `google.api.http`).
+func optionKey(fd protoreflect.FieldDescriptor) string {
+	if fd.IsExtension() {
+		return string(fd.FullName())
+	}
+	return fd.JSONName()
+}
 
-	return out
+// optionValue converts the value of an option field into something that can be rendered by a template. Nested
+// messages become maps keyed by optionKey, repeated fields become slices and enums become the names of their values.
+func optionValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch {
+	case fd.IsList():
+		list := v.List()
+		values := make([]interface{}, 0, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			values = append(values, optionScalarValue(fd, list.Get(i)))
+		}
+		return values
+	case fd.IsMap():
+		values := make(map[string]interface{}, v.Map().Len())
+		v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			values[k.String()] = optionScalarValue(fd.MapValue(), v)
+			return true
+		})
+		return values
+	default:
+		return optionScalarValue(fd, v)
+	}
+}
+
+func optionScalarValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		values := map[string]interface{}{}
+		v.Message().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+			values[optionKey(fd)] = optionValue(fd, v)
+			return true
+		})
+		return values
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return int32(v.Enum())
+	default:
+		return v.Interface()
+	}
+}
+
+// Option is a single option of an entity. The value is rendered as a string: strings are kept as is, everything else
+// is JSON encoded.
+type Option struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func optionList(opts map[string]interface{}) []Option {
+	if len(opts) == 0 {
+		return nil
+	}
+
+	list := make([]Option, 0, len(opts))
+	for name, value := range opts {
+		list = append(list, Option{Name: name, Value: optionValueString(value)})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
+
+func optionValueString(value interface{}) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(data)
 }
 
 type Link struct {
-	Package      string
-	FullName     string
-	External     bool
+	Package  string
+	FullName string
+	// Anchor is the anchor of the documentation of types of the template (see Template.Slug).
+	Anchor   string
+	External bool
+	// ExternalHREF is the location of the documentation of external types. It's empty for the types that aren't
+	// well-known types unless an external base URL is set (see WithExternalBaseURL).
 	ExternalHREF string
 }
 
+// isExcludedPackage returns whether pkg is one of the excluded packages or a sub-package of one.
+func (t *Template) isExcludedPackage(pkg string) bool {
+	for _, excluded := range t.excludedPackages {
+		if pkg == excluded || strings.HasPrefix(pkg, excluded+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// indexImported collects the types of the fields, extensions and methods of the template that aren't part of it, nor
+// scalars. These come from imported files that weren't generated, and are linked externally (see WithExternalBaseURL).
+func (t *Template) indexImported() {
+	t.imported = map[string]*Link{}
+	add := func(fullType string) {
+		if _, ok := t.links[fullType]; ok || fullType == "" || slices.Contains(scalarTypes, fullType) {
+			return
+		}
+		if _, ok := t.pages[fullType]; ok {
+			return
+		}
+		t.imported[fullType] = &Link{FullName: fullType, External: true, ExternalHREF: t.externalHREF(fullType)}
+	}
+
+	for _, file := range t.Files {
+		for _, msg := range file.Messages {
+			for _, field := range msg.allFields() {
+				add(field.FullType)
+			}
+			for _, ext := range msg.Extensions {
+				add(ext.FullType)
+			}
+		}
+		for _, ext := range file.Extensions {
+			add(ext.FullType)
+		}
+		for _, service := range file.Services {
+			for _, method := range service.Methods {
+				add(method.RequestFullType)
+				add(method.ResponseFullType)
+			}
+		}
+	}
+}
+
+// externalHREF returns the location of the documentation of a type that isn't part of the template, see
+// WithExternalBaseURL.
+func (t *Template) externalHREF(fullName string) string {
+	if t.externalBaseURL == "" {
+		return ""
+	}
+	return t.externalBaseURL + fullName
+}
+
+// resolveLink returns the link to the given fully qualified type. Types that aren't part of the template are linked
+// externally when they are well-known types, belong to an excluded package or are used by the template (i.e. come
+// from imported files that weren't generated), otherwise nil is returned.
+func (t *Template) resolveLink(fullName string) *Link {
+	if l, ok := t.links[fullName]; ok {
+		return l
+	}
+
+	if name, ok := strings.CutPrefix(fullName, "google.protobuf."); ok {
+		if anchor, ok := wellKnownTypes[name]; ok {
+			return &Link{
+				Package:      "google.protobuf",
+				FullName:     fullName,
+				External:     true,
+				ExternalHREF: "https://protobuf.dev/reference/protobuf/google.protobuf/#" + anchor,
+			}
+		}
+	}
+
+	// the package of the type is unknown, but it must start with an excluded package
+	for _, excluded := range t.excludedPackages {
+		if strings.HasPrefix(fullName, excluded+".") {
+			return &Link{Package: excluded, FullName: fullName, External: true, ExternalHREF: t.externalHREF(fullName)}
+		}
+	}
+
+	if l, ok := t.imported[fullName]; ok {
+		return l
+	}
+
+	return nil
+}
+
 type Package struct {
 	Name         string
 	Services     []*Service
@@ -279,6 +1349,24 @@ type Package struct {
 	Descriptions []*PackageDesc
 }
 
+// VisibleMessages returns the messages in this package excluding internal ones, such as synthetic map entries.
+func (p Package) VisibleMessages() []*Message { return visibleMessages(p.Messages) }
+
+// Overview returns the descriptions of the files of the package for an introduction of the package, in file order.
+// Descriptions shared by several files (e.g. a copied license header) are listed once, with the first of them.
+func (p Package) Overview() []*PackageDesc {
+	var overview []*PackageDesc
+	seen := map[string]bool{}
+	for _, desc := range p.Descriptions {
+		if !seen[desc.Description] {
+			seen[desc.Description] = true
+			overview = append(overview, desc)
+		}
+	}
+	return overview
+}
+
+// PackageDesc is the description of a file of a package (see Package.Descriptions).
 type PackageDesc struct {
 	File        string
 	Description string
@@ -293,6 +1381,17 @@ type Source struct {
 	trailingComments string
 }
 
+// URL returns the location of the source in a repository from the given URL template, whose `{file}` and `{line}`
+// placeholders are replaced with the file and the first line of the source, e.g.
+// `https://github.com/org/repo/blob/main/{file}#L{line}`. It's empty without a URL template, or when the template needs
+// a line but the file has no source info.
+func (s *Source) URL(base string) string {
+	if s == nil || base == "" || (s.Start == 0 && strings.Contains(base, "{line}")) {
+		return ""
+	}
+	return strings.NewReplacer("{file}", s.File, "{line}", fmt.Sprint(s.Start)).Replace(base)
+}
+
 func NewSource(f *protokit.FileDescriptor, acc []int32) *Source {
 	l := &Source{
 		File: f.GetName(),
@@ -316,9 +1415,25 @@ func NewSource(f *protokit.FileDescriptor, acc []int32) *Source {
 //
 // In the case of proto3 files, HasExtensions will always be false, and Extensions will be empty.
 type File struct {
-	Name        string `json:"name"`
+	Name string `json:"name"`
+	// Anchor is the anchor of the documentation of the file, the same in every output format (see Template.Slug).
+	Anchor      string `json:"anchor"`
 	Description string `json:"description"`
 	Package     string `json:"package"`
+	// Checksum is the SHA-256 (hex encoded) of the file's descriptor, including its comments. It only changes when
+	// the file does.
+	Checksum string `json:"checksum"`
+	// Module is the name of the module (e.g. the Buf module) owning the file, if known (see WithModules).
+	Module string `json:"module,omitempty"`
+	// Deprecated is set when the whole file is deprecated (`option deprecated = true;`).
+	Deprecated bool `json:"deprecated"`
+	// Meta is the page metadata (e.g. title, weight or tags) set by YAML front-matter at the top of the file comment,
+	// between `---` lines. The front-matter isn't part of the description. Values other than scalars are kept in YAML
+	// flow style, e.g. `[billing, v1]`. Malformed front-matter is left in the description.
+	Meta map[string]string `json:"meta,omitempty"`
+	// Features are the resolved features of editions files by name, e.g. `field_presence: EXPLICIT`: the defaults of
+	// the edition overridden by `option features.<name> = <value>;`. It's empty for proto2 and proto3 files.
+	Features map[string]string `json:"features,omitempty"`
 
 	HasEnums      bool `json:"hasEnums"`
 	HasExtensions bool `json:"hasExtensions"`
@@ -332,12 +1447,117 @@ type File struct {
 
 	Options map[string]interface{} `json:"options,omitempty"`
 
-	FDS *protokit.FileDescriptor
+	FDS *protokit.FileDescriptor `json:"-"`
+
+	frontMatter *yaml.Node
+}
+
+// FrontMatter returns the front-matter of the file (see Meta) as YAML, without the given keys, e.g. for emitting it
+// along with keys set by the template. It's empty when there's none.
+func (f File) FrontMatter(exclude ...string) string {
+	if f.frontMatter == nil {
+		return ""
+	}
+
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i+1 < len(f.frontMatter.Content); i += 2 {
+		if !slices.Contains(exclude, f.frontMatter.Content[i].Value) {
+			mapping.Content = append(mapping.Content, f.frontMatter.Content[i], f.frontMatter.Content[i+1])
+		}
+	}
+	if len(mapping.Content) == 0 {
+		return ""
+	}
+	out, err := yaml.Marshal(mapping)
+	if err != nil {
+		return ""
+	}
+	return string(out)
 }
 
 // Option returns the named option.
 func (f File) Option(name string) interface{} { return f.Options[name] }
 
+// OptionList returns the options sorted by name.
+func (f File) OptionList() []Option { return optionList(f.Options) }
+
+// StandardOptions returns the options of google.protobuf.FileOptions set on the file (e.g. `go_package` or
+// `java_package`) by their name in proto files, sorted by name. Custom options and `deprecated` (see Deprecated) aren't
+// included.
+func (f File) StandardOptions() []Option {
+	if f.FDS == nil || f.FDS.GetOptions() == nil {
+		return nil
+	}
+
+	var list []Option
+	f.FDS.GetOptions().ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if !fd.IsExtension() && fd.Name() != "deprecated" {
+			list = append(list, Option{Name: string(fd.Name()), Value: optionValueString(optionValue(fd, v))})
+		}
+		return true
+	})
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// fileOptions returns the google.protobuf.FileOptions of the file, which are nil when the file has none.
+func (f File) fileOptions() *descriptor.FileOptions {
+	if f.FDS == nil {
+		return nil
+	}
+	return f.FDS.GetOptions()
+}
+
+// GoPackage returns the `go_package` option of the file, or an empty string.
+func (f File) GoPackage() string { return f.fileOptions().GetGoPackage() }
+
+// JavaPackage returns the `java_package` option of the file, or an empty string.
+func (f File) JavaPackage() string { return f.fileOptions().GetJavaPackage() }
+
+// JavaOuterClassname returns the `java_outer_classname` option of the file, or an empty string.
+func (f File) JavaOuterClassname() string { return f.fileOptions().GetJavaOuterClassname() }
+
+// CcEnableArenas returns the `cc_enable_arenas` option of the file, or false when it's unset (even though protoc
+// defaults it to true).
+func (f File) CcEnableArenas() bool {
+	opts := f.fileOptions()
+	return opts != nil && opts.CcEnableArenas != nil && opts.GetCcEnableArenas()
+}
+
+// OptimizeFor returns the `optimize_for` option of the file (`SPEED`, `CODE_SIZE` or `LITE_RUNTIME`), or an empty
+// string when it's unset.
+func (f File) OptimizeFor() string {
+	if opts := f.fileOptions(); opts != nil && opts.OptimizeFor != nil {
+		return opts.GetOptimizeFor().String()
+	}
+	return ""
+}
+
+// VisibleMessages returns the messages in this file excluding internal ones, such as synthetic map entries.
+func (f File) VisibleMessages() []*Message { return visibleMessages(f.Messages) }
+
+// CustomOptions returns the file-level extensions that define custom options (see FileExtension.IsOptionDefinition).
+func (f File) CustomOptions() []*FileExtension {
+	var options []*FileExtension
+	for _, ext := range f.Extensions {
+		if ext.IsOptionDefinition {
+			options = append(options, ext)
+		}
+	}
+	return options
+}
+
+// TypeExtensions returns the file-level extensions that extend regular messages, i.e. everything but custom options.
+func (f File) TypeExtensions() []*FileExtension {
+	var exts []*FileExtension
+	for _, ext := range f.Extensions {
+		if !ext.IsOptionDefinition {
+			exts = append(exts, ext)
+		}
+	}
+	return exts
+}
+
 // FileExtension contains details about top-level extensions within a proto(2) file.
 type FileExtension struct {
 	Name               string `json:"name"`
@@ -353,24 +1573,68 @@ type FileExtension struct {
 	ContainingType     string `json:"containingType"`
 	ContainingLongType string `json:"containingLongType"`
 	ContainingFullType string `json:"containingFullType"`
+
+	// IsOptionDefinition is set when the extension defines a custom option, i.e. it extends one of the
+	// google.protobuf.*Options messages. OptionName is then the name used to set the option, e.g. `(OptionName)`.
+	IsOptionDefinition bool   `json:"isOptionDefinition"`
+	OptionName         string `json:"optionName,omitempty"`
+	// Retention (RUNTIME or SOURCE) and Targets (e.g. FIELD, MESSAGE) are the retention and target types declared
+	// by an option definition. They're empty when not declared.
+	Retention string   `json:"retention,omitempty"`
+	Targets   []string `json:"targets,omitempty"`
+
+	enumDefault string
+	typePackage string
+}
+
+// RenderedDefault returns the default value formatted by type (see MessageField.RenderedDefault).
+func (e FileExtension) RenderedDefault() string {
+	if e.enumDefault != "" {
+		return e.enumDefault
+	}
+	return renderDefault(e.Type, e.DefaultValue)
+}
+
+// DisplayType returns the type of the extension as seen from fromPackage (see MessageField.DisplayType).
+func (e FileExtension) DisplayType(fromPackage string) string {
+	return displayType(e.FullType, e.typePackage, fromPackage)
 }
 
+// OneOf is a oneof of a message and the fields it's made of (see Message.OneOfs).
 type OneOf struct {
-	Name        string
-	Description string
-	Fields      []*MessageField
-	Source      *Source
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Fields      []*MessageField `json:"fields"`
+	// IsRequired is set when exactly one of the fields must be set, as declared by the protovalidate option
+	// `(buf.validate.oneof).required = true`.
+	IsRequired bool                   `json:"isRequired"`
+	Options    map[string]interface{} `json:"options,omitempty"`
+	Source     *Source
 }
 
+// FieldCount returns the number of fields of the oneof.
+func (o OneOf) FieldCount() int { return len(o.Fields) }
+
+// Option returns the named option.
+func (o OneOf) Option(name string) interface{} { return o.Options[name] }
+
+// OptionList returns the options sorted by name.
+func (o OneOf) OptionList() []Option { return optionList(o.Options) }
+
 // Message contains details about a protobuf message.
 //
 // In the case of proto3 files, HasExtensions will always be false, and Extensions will be empty.
 type Message struct {
-	Internal    bool
+	// Internal is set for messages protoc synthesizes, i.e. the entries of map fields. The bundled templates leave them
+	// out (see File.VisibleMessages), and so does the JSON output with WithOmitInternal.
+	Internal    bool   `json:"internal"`
 	Name        string `json:"name"`
 	LongName    string `json:"longName"`
 	FullName    string `json:"fullName"`
+	File        string `json:"file"`
 	Description string `json:"description"`
+	// Anchor is the anchor of the documentation of the message, the same in every output format (see Template.Slug).
+	Anchor string `json:"anchor"`
 
 	HasExtensions bool `json:"hasExtensions"`
 	HasFields     bool `json:"hasFields"`
@@ -378,20 +1642,197 @@ type Message struct {
 
 	Extensions []*MessageExtension `json:"extensions"`
 	Fields     []*MessageField     `json:"fields"`
-	OneOfs     []*OneOf
+	// OneOfs are the oneofs of the message, in declaration order. Their fields (with IsOneof and OneofDecl set) aren't
+	// part of Fields, neither in templates nor in the JSON output, which lists them under `oneofs`. Proto3 `optional`
+	// fields are part of Fields, their synthetic oneofs aren't listed. The bundled templates list both (see AllFields).
+	OneOfs []*OneOf `json:"oneofs"`
+
+	// ReservedRanges are the field numbers the message reserves, sorted and merged where adjacent (see ReservedRange),
+	// and ReservedNames the field names it reserves, in declaration order.
+	ReservedRanges []*ReservedRange `json:"reservedRanges,omitempty"`
+	ReservedNames  []string         `json:"reservedNames,omitempty"`
+
+	// NestedMessages and NestedEnums are the types declared directly within this message, in declaration order. They
+	// are part of File.Messages and File.Enums as well, so they're left out of the JSON output.
+	NestedMessages []*Message `json:"-"`
+	NestedEnums    []*Enum    `json:"-"`
+	// Parent is the message the message is declared in, nil for top-level messages.
+	Parent *Message `json:"-"`
+
+	// SeeAlso links the types referenced by `@see <full name>` lines of the comment, which aren't part of the
+	// description. Types that can't be resolved are left with a FullName only, and are rendered as plain text.
+	SeeAlso []*Link `json:"seeAlso,omitempty"`
+
+	// ReplacedBy links the successor of the message, named by the replaced-by option (see ReplacedByOption) by its full
+	// name, or relative to the package. It's nil without the option, and has a FullName only when it can't be resolved.
+	ReplacedBy *Link `json:"replacedBy,omitempty"`
 
 	Options map[string]interface{} `json:"options,omitempty"`
 
 	Source *Source
+
+	category string
+	seeRefs  []string
+	inlined  bool
 }
 
+// Category returns the category of the message, set by the category option (see CategoryOption), or an empty
+// string.
+func (m Message) Category() string { return m.category }
+
 // Option returns the named option.
 func (m Message) Option(name string) interface{} { return m.Options[name] }
 
+// OptionList returns the options sorted by name.
+func (m Message) OptionList() []Option { return optionList(m.Options) }
+
+// Breadcrumb returns the messages the message is nested in, from the top-level one down to its parent, e.g. `Outer`
+// and `Outer.Inner` for `Outer.Inner.Leaf`. It's empty for top-level messages.
+func (m Message) Breadcrumb() []*Message {
+	var ancestors []*Message
+	for parent := m.Parent; parent != nil; parent = parent.Parent {
+		ancestors = append([]*Message{parent}, ancestors...)
+	}
+	return ancestors
+}
+
+// Inlined tells whether the message is documented along with the message using it (see WithInlineNested), rather
+// than in a section of its own.
+func (m Message) Inlined() bool { return m.inlined }
+
+// ReferencedEnums returns the distinct enums the fields of the message (including oneof members and map values) are
+// typed with, sorted by full name. Only enums that are part of the template are returned, so enums of excluded
+// packages (e.g. well-known ones with `exclude_package=google.protobuf`) are left out.
+func (m Message) ReferencedEnums() []*Enum {
+	seen := make(map[*Enum]bool)
+	var enums []*Enum
+	add := func(field *MessageField) {
+		if field.enumType != nil && !seen[field.enumType] {
+			seen[field.enumType] = true
+			enums = append(enums, field.enumType)
+		}
+	}
+
+	for _, field := range m.allFields() {
+		add(field)
+		if field.IsMap && field.messageType != nil {
+			for _, entryField := range field.messageType.Fields {
+				add(entryField)
+			}
+		}
+	}
+	sort.Slice(enums, func(i, j int) bool { return enums[i].FullName < enums[j].FullName })
+	return enums
+}
+
+// FieldCount returns the number of fields of the message, oneof members included.
+func (m Message) FieldCount() int { return len(m.allFields()) }
+
+// MaxDepth returns the number of messages of the deepest chain of message fields starting at the message, e.g. 1 for
+// a message without message fields, and 3 for `Order` with an `Item` field typed with a message with a `Price` field.
+// The values of map fields count, their entries don't. A message met again down a chain (a recursive message) ends it.
+func (m Message) MaxDepth() int { return messageDepth(&m, make(map[string]bool)) }
+
+// Complexity returns a rough score of how big the message is to read and to process: a point per field (see
+// FieldCount), another one per repeated or map field, one per oneof, and two per level of nesting below the message
+// (see MaxDepth). It's meant for flagging unusually large or deep messages, e.g. in schema health reports.
+func (m Message) Complexity() int {
+	score := len(m.OneOfs) + 2*(m.MaxDepth()-1)
+	for _, field := range m.allFields() {
+		score++
+		if field.Label == "repeated" {
+			score++
+		}
+	}
+	return score
+}
+
+// messageDepth returns the MaxDepth of msg, leaving out the messages of path, which the chain went through already.
+// Map entries don't count as a level of their own.
+func messageDepth(msg *Message, path map[string]bool) int {
+	path[msg.FullName] = true
+	defer delete(path, msg.FullName)
+
+	deepest := 0
+	for _, field := range msg.allFields() {
+		if nested := field.messageType; nested != nil && !path[nested.FullName] {
+			deepest = max(deepest, messageDepth(nested, path))
+		}
+	}
+	if msg.Internal {
+		return deepest
+	}
+	return deepest + 1
+}
+
+// VisibleNestedMessages returns the nested messages excluding internal ones, such as synthetic map entries.
+func (m Message) VisibleNestedMessages() []*Message { return visibleMessages(m.NestedMessages) }
+
+// HasFieldBehaviors reports whether any field of this message (including oneof fields) has field behaviors.
+func (m Message) HasFieldBehaviors() bool {
+	has := func(fields []*MessageField) bool {
+		for _, field := range fields {
+			if len(field.FieldBehaviors) > 0 {
+				return true
+			}
+		}
+		return false
+	}
+	if has(m.Fields) {
+		return true
+	}
+	for _, oneOf := range m.OneOfs {
+		if has(oneOf.Fields) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldNamed returns the field (oneof fields included) with the given name, nil if there's none.
+func (m Message) fieldNamed(name string) *MessageField {
+	for _, field := range m.allFields() {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// AllFields returns the fields of the message along with the fields of its oneofs, in declaration order. Unlike Fields
+// followed by the fields of OneOfs, the members of oneofs are listed where they're declared, between the other fields.
+func (m Message) AllFields() []*MessageField { return m.allFields() }
+
+func (m Message) allFields() []*MessageField {
+	fields := append([]*MessageField{}, m.Fields...)
+	for _, oneOf := range m.OneOfs {
+		fields = append(fields, oneOf.Fields...)
+	}
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].position < fields[j].position })
+	return fields
+}
+
+// Ungrouped is the group Message.FieldGroups files the fields without group under.
+const Ungrouped = "Ungrouped"
+
+// FieldGroups returns the fields of the message (oneof members included) by group (see MessageField.Group), in
+// declaration order. Fields without a group are listed under Ungrouped.
+func (m Message) FieldGroups() map[string][]*MessageField {
+	groups := make(map[string][]*MessageField)
+	for _, field := range m.allFields() {
+		group := field.Group
+		if group == "" {
+			group = Ungrouped
+		}
+		groups[group] = append(groups[group], field)
+	}
+	return groups
+}
+
 // FieldOptions returns all options that are set on the fields in this message.
 func (m Message) FieldOptions() []string {
 	optionSet := make(map[string]struct{})
-	for _, field := range m.Fields {
+	for _, field := range m.allFields() {
 		for option := range field.Options {
 			optionSet[option] = struct{}{}
 		}
@@ -411,7 +1852,7 @@ func (m Message) FieldOptions() []string {
 // If no single value has the option set, this returns nil.
 func (m Message) FieldsWithOption(optionName string) []*MessageField {
 	fields := make([]*MessageField, 0, len(m.Fields))
-	for _, field := range m.Fields {
+	for _, field := range m.allFields() {
 		if _, ok := field.Options[optionName]; ok {
 			fields = append(fields, field)
 		}
@@ -422,13 +1863,29 @@ func (m Message) FieldsWithOption(optionName string) []*MessageField {
 	return nil
 }
 
+// FieldPresence tells whether a field tracks if it's set (e.g. with a `has_` method), see MessageField.Presence.
+type FieldPresence string
+
+const (
+	// PresenceExplicit fields tell unset values apart from default ones: fields with the `optional` label, messages
+	// and members of oneofs.
+	PresenceExplicit FieldPresence = "explicit"
+	// PresenceImplicit fields are unset when they have the default value, like proto3 scalars without a label. Repeated
+	// fields have implicit presence as well.
+	PresenceImplicit FieldPresence = "implicit"
+	// PresenceRequired fields are proto2 `required` fields, which must always be set.
+	PresenceRequired FieldPresence = "required"
+)
+
 // MessageField contains details about an individual field within a message.
 //
 // In the case of proto3 files, DefaultValue will always be empty. Similarly, label will be empty unless the field is
-// repeated (in which case it'll be "repeated").
+// repeated (in which case it'll be "repeated"). Default values of bytes fields are escaped to printable ASCII, with
+// `\xNN` escapes for everything else.
 type MessageField struct {
 	Index        int
 	Name         string `json:"name"`
+	JSONName     string `json:"jsonName"`
 	Description  string `json:"description"`
 	Label        string `json:"label"`
 	Type         string `json:"type"`
@@ -441,12 +1898,215 @@ type MessageField struct {
 	OneofDecl    string `json:"oneofdecl"`
 	DefaultValue string `json:"defaultValue"`
 
+	// Required is set for proto2 `required` fields. Unlike IsRequired, it ignores the REQUIRED field behavior.
+	Required bool `json:"required"`
+
+	// Presence tells whether the field tracks if it's set (see FieldPresence), HasPresence whether it's explicit or
+	// required. Unlike Label, it tells proto3 fields without a label (implicit for scalars and enums, explicit for
+	// messages) apart.
+	Presence    FieldPresence `json:"presence"`
+	HasPresence bool          `json:"hasPresence"`
+
+	// Features are the resolved features of fields of editions files by name (see File.Features), overridden by the
+	// `features` options of their message and their own. It's empty for proto2 and proto3 files.
+	Features map[string]string `json:"features,omitempty"`
+
+	// Group is the name of the group of related fields the field belongs to, taken from a `group: <name>` line in its
+	// comment. The line isn't part of the description.
+	Group string `json:"group,omitempty"`
+
+	// TypeAlias is the type label shown in docs instead of LongType (see TypeLabel), taken from a `@type-alias <name>`
+	// line in the comment, e.g. `@type-alias Money` on a generic wrapper type. The line isn't part of the description.
+	TypeAlias string `json:"typeAlias,omitempty"`
+
+	// FieldBehaviors are the values of the google.api.field_behavior annotation, e.g. REQUIRED or OUTPUT_ONLY.
+	FieldBehaviors []string `json:"fieldBehaviors,omitempty"`
+
+	// Packed is set for repeated scalar (numeric, bool and enum) fields that use the packed wire encoding: when the
+	// `packed` option is true, or when it's unset in proto3 files, which pack them by default. In editions files, it's
+	// the `repeated_field_encoding` feature (PACKED by default). PackedExplicit tells whether the `packed` option (or
+	// the feature of the field) is set, to either value.
+	Packed         bool `json:"packed"`
+	PackedExplicit bool `json:"packedExplicit"`
+
+	// SeeAlso links the types referenced by `@see <full name>` lines of the comment, which aren't part of the
+	// description. Types that can't be resolved are left with a FullName only, and are rendered as plain text.
+	SeeAlso []*Link `json:"seeAlso,omitempty"`
+
+	// AnyTypes links the types a `google.protobuf.Any` field may contain, listed by an `@any-types <full name>, ...`
+	// line of the comment, which isn't part of the description. Types that can't be resolved are left with a FullName
+	// only, like SeeAlso.
+	AnyTypes []*Link `json:"anyTypes,omitempty"`
+
+	// ReplacedBy links the successor of the field, like Message.ReplacedBy.
+	ReplacedBy *Link `json:"replacedBy,omitempty"`
+
+	// LanguageType is the type of the field in the code generated for the language set by WithTypeLanguage, e.g.
+	// `List<Long>` for a repeated int64 field in Java. It's empty without a language.
+	LanguageType string `json:"languageType,omitempty"`
+
 	Options map[string]interface{} `json:"options,omitempty"`
+
+	// Source is the location of the field in its file, e.g. for linking it (see WithSourceBaseURL). Unlike the Source of
+	// messages, it's left out of the JSON output, which would grow by a location per field.
+	Source *Source `json:"-"`
+
+	behaviorColumn bool
+	seeRefs        []string
+	anyRefs        []string
+	example        string
+	format         string
+	unit           string
+	// position is the index of the field among the fields of its message, oneof members included.
+	position       int
+	proto3Optional bool
+	message        string
+	messageAnchor  string
+	isMessage      bool
+	mapKeyLabel    string
+	mapValueLabel  string
+	mapValueLink   *Link
+	enumDefault    string
+	typePackage    string
+	inlineMessage  *Message
+	// messageType and enumType are the message or enum the field is typed with, if it's part of the template.
+	messageType *Message
+	enumType    *Enum
+}
+
+// Example returns the example value of the field in protojson form, set by the example option (see
+// ExampleOption), or an empty string. The quotes of strings, bytes and enums may be left out, e.g. `ACTIVE`.
+func (f MessageField) Example() string { return f.example }
+
+// Format returns the format of the values of the field set by the format option (see FormatOption), e.g.
+// `RFC3339`, or an empty string.
+func (f MessageField) Format() string { return f.format }
+
+// Unit returns the unit of the values of the field set by the unit option (see UnitOption), e.g. `bytes`, or an
+// empty string.
+func (f MessageField) Unit() string { return f.unit }
+
+// InlineMessage returns the message the field is typed with when it's documented along with the field's message (see
+// WithInlineNested), nil otherwise.
+func (f MessageField) InlineMessage() *Message { return f.inlineMessage }
+
+// MapKeyLabel returns the name of the key type of a map field, e.g. `string` for `map<string, Vehicle>`. Keys are
+// always scalars, so it links to the scalar value types.
+func (f MessageField) MapKeyLabel() string { return f.mapKeyLabel }
+
+// MapValueLabel returns the long name of the value type of a map field, e.g. `Vehicle` for `map<string, Vehicle>`.
+func (f MessageField) MapValueLabel() string { return f.mapValueLabel }
+
+// MapDoc summarizes a map field (see MessageField.MapDoc).
+type MapDoc struct {
+	// Field is the name of the map field.
+	Field string `json:"field"`
+	// KeyType is the scalar type of the keys, e.g. `string`.
+	KeyType string `json:"keyType"`
+	// ValueType and ValueFullType are the long and full names of the type of the values, e.g. `Vehicle` and
+	// `com.example.Vehicle`, or the same scalar type for scalar values.
+	ValueType     string `json:"valueType"`
+	ValueFullType string `json:"valueFullType"`
+	// ValueLink links the documentation of message and enum values. It's nil for scalar values.
+	ValueLink *Link `json:"valueLink,omitempty"`
+}
+
+// MapDoc returns the key and value types of a map field, e.g. for a note clarifying `map<string, ComplexValue>` (see
+// WithMapNotes). It's nil for other fields.
+func (f MessageField) MapDoc() *MapDoc {
+	if !f.IsMap {
+		return nil
+	}
+	return &MapDoc{
+		Field:         f.Name,
+		KeyType:       f.mapKeyLabel,
+		ValueType:     f.mapValueLabel,
+		ValueFullType: f.MapValueType,
+		ValueLink:     f.mapValueLink,
+	}
+}
+
+// Signature returns the field declaration in proto syntax, e.g. `repeated string names = 3` or
+// `map<string, int32> counts = 4`. Like in proto files, members of a oneof have no label.
+func (f MessageField) Signature() string {
+	var sig strings.Builder
+	if f.IsMap {
+		fmt.Fprintf(&sig, "map<%s, %s>", f.MapKeyType, f.MapValueType)
+	} else {
+		if f.Label != "" && (!f.IsOneof || f.proto3Optional) {
+			sig.WriteString(f.Label + " ")
+		}
+		sig.WriteString(f.LongType)
+	}
+	fmt.Fprintf(&sig, " %s = %d", f.Name, f.Index)
+	return sig.String()
+}
+
+// Anchor returns the anchor of the field, which is unique within the documentation: the anchor of its message and the
+// field name separated by two dashes (e.g. `com-example-Vehicle--id`).
+func (f MessageField) Anchor() string { return f.messageAnchor + "--" + f.Name }
+
+// StableAnchor returns an anchor made of the anchor of the message and the number of the field, separated by two dashes
+// (e.g. `com-example-Vehicle--1`). Unlike Anchor, it survives renaming the field, so it's a stable target for external
+// links. msg is the message declaring the field; templates can pass nil to use the message the field was parsed from.
+func (f MessageField) StableAnchor(msg *Message) string {
+	anchor := f.messageAnchor
+	if msg != nil {
+		anchor = msg.Anchor
+	}
+	return anchor + "--" + strconv.Itoa(f.Index)
+}
+
+// IsRequired reports whether the field is required, either by its proto2 label or by the REQUIRED field behavior.
+func (f MessageField) IsRequired() bool {
+	if f.Label == "required" {
+		return true
+	}
+	for _, b := range f.FieldBehaviors {
+		if b == "REQUIRED" {
+			return true
+		}
+	}
+	return false
+}
+
+// BehaviorColumn reports whether the field is rendered with a behavior column, i.e. whether any field of its message
+// has field behaviors.
+func (f MessageField) BehaviorColumn() bool { return f.behaviorColumn }
+
+// RenderedDefault returns the default value formatted by type: strings are quoted, bytes are rendered in hexadecimal
+// (e.g. `0x00ff`), enum values are followed by their number (e.g. `ACTIVE (1)`) and everything else, such as numbers
+// and bools, is kept as is. Enum values are kept as is as well when the enum isn't part of the template. It's empty
+// when the field has no default value.
+func (f MessageField) RenderedDefault() string {
+	if f.enumDefault != "" {
+		return f.enumDefault
+	}
+	return renderDefault(f.Type, f.DefaultValue)
+}
+
+// TypeLabel returns the type label of the field shown in docs: its TypeAlias if it has one, LongType otherwise. Links
+// still point to FullType.
+func (f MessageField) TypeLabel() string {
+	if f.TypeAlias != "" {
+		return f.TypeAlias
+	}
+	return f.LongType
+}
+
+// DisplayType returns the type of the field as seen from fromPackage: types of the same package (and scalars) are
+// rendered without the package, types of other packages are fully qualified, even when their package starts with
+// fromPackage (e.g. `example.Book` isn't a type of `com.example` seen from `com`).
+func (f MessageField) DisplayType(fromPackage string) string {
+	return displayType(f.FullType, f.typePackage, fromPackage)
 }
 
 // Option returns the named option.
 func (f MessageField) Option(name string) interface{} { return f.Options[name] }
 
+// OptionList returns the options sorted by name.
+func (f MessageField) OptionList() []Option { return optionList(f.Options) }
+
 // MessageExtension contains details about message-scoped extensions in proto(2) files.
 type MessageExtension struct {
 	FileExtension
@@ -461,17 +2121,53 @@ type Enum struct {
 	Name        string       `json:"name"`
 	LongName    string       `json:"longName"`
 	FullName    string       `json:"fullName"`
+	File        string       `json:"file"`
 	Description string       `json:"description"`
 	Values      []*EnumValue `json:"values"`
+	// Anchor is the anchor of the documentation of the enum, the same in every output format (see Template.Slug).
+	Anchor string `json:"anchor"`
+
+	// ReservedRanges are the value numbers the enum reserves, sorted and merged where adjacent (see ReservedRange), and
+	// ReservedNames the value names it reserves, in declaration order.
+	ReservedRanges []*ReservedRange `json:"reservedRanges,omitempty"`
+	ReservedNames  []string         `json:"reservedNames,omitempty"`
+
+	// SeeAlso links the types referenced by `@see <full name>` lines of the comment, which aren't part of the
+	// description. Types that can't be resolved are left with a FullName only, and are rendered as plain text.
+	SeeAlso []*Link `json:"seeAlso,omitempty"`
 
 	Options map[string]interface{} `json:"options,omitempty"`
 
 	Source *Source
+
+	category string
+	seeRefs  []string
 }
 
+// Category returns the category of the enum, set by the category option (see CategoryOption), or an empty
+// string.
+func (e Enum) Category() string { return e.category }
+
 // Option returns the named option.
 func (e Enum) Option(name string) interface{} { return e.Options[name] }
 
+// OptionList returns the options sorted by name.
+func (e Enum) OptionList() []Option { return optionList(e.Options) }
+
+// ZeroValue returns the value with number 0, which is the default of the enum (often a `*_UNSPECIFIED` sentinel), or
+// nil if there is none. With aliases, it's the first value declared with number 0.
+func (e Enum) ZeroValue() *EnumValue {
+	for _, value := range e.Values {
+		if value.Number == "0" {
+			return value
+		}
+	}
+	return nil
+}
+
+// HasZeroValue reports whether the enum has a value with number 0. Proto3 enums must have one.
+func (e Enum) HasZeroValue() bool { return e.ZeroValue() != nil }
+
 // ValueOptions returns all options that are set on the values in this enum.
 func (e Enum) ValueOptions() []string {
 	optionSet := make(map[string]struct{})
@@ -506,34 +2202,159 @@ func (e Enum) ValuesWithOption(optionName string) []*EnumValue {
 	return nil
 }
 
+// ReservedRange is a range of field or enum value numbers reserved by a message or an enum. Both ends are included, so
+// a single reserved number has the same Start and End.
+type ReservedRange struct {
+	Start int32 `json:"start"`
+	End   int32 `json:"end"`
+}
+
+// String returns the range as shown in docs, e.g. `5` or `10-15`.
+func (r ReservedRange) String() string {
+	if r.Start == r.End {
+		return fmt.Sprint(r.Start)
+	}
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// reservedRanges returns the given ranges (with both ends included) sorted, and merged where they overlap or are
+// adjacent, e.g. `10-12` and `13-15` into `10-15`.
+func reservedRanges(ranges []ReservedRange) []*ReservedRange {
+	slices.SortFunc(ranges, func(a, b ReservedRange) int { return int(a.Start) - int(b.Start) })
+
+	var merged []*ReservedRange
+	for _, r := range ranges {
+		if last := len(merged) - 1; last >= 0 && int64(r.Start) <= int64(merged[last].End)+1 {
+			merged[last].End = max(merged[last].End, r.End)
+			continue
+		}
+		merged = append(merged, &ReservedRange{Start: r.Start, End: r.End})
+	}
+	return merged
+}
+
 // EnumValue contains details about an individual value within an enumeration.
 type EnumValue struct {
 	Name        string `json:"name"`
 	Number      string `json:"number"`
 	Description string `json:"description"`
 
-	Options map[string]interface{} `json:"options,omitempty"`
+	// ReplacedBy links the successor of the value, like Message.ReplacedBy.
+	ReplacedBy *Link `json:"replacedBy,omitempty"`
+
+	Options map[string]interface{} `json:"options,omitempty"`
+
+	hex bool
+}
+
+// NumberHex returns the number of the value in hexadecimal notation (e.g. `0x10`).
+func (v EnumValue) NumberHex() string {
+	n, err := strconv.ParseInt(v.Number, 10, 32)
+	if err != nil {
+		return v.Number
+	}
+	return fmt.Sprintf("%#x", n)
+}
+
+// DisplayNumber returns the number of the value as it should be rendered, which is NumberHex for enums rendered in
+// hexadecimal and Number otherwise.
+func (v EnumValue) DisplayNumber() string {
+	if v.hex {
+		return v.NumberHex()
+	}
+	return v.Number
+}
+
+// hasHexOption reports whether an enum asks to be rendered in hexadecimal, i.e. whether it has a custom option named
+// `hex` (in any package) that is set to true.
+func hasHexOption(opts map[string]interface{}) bool {
+	for name, value := range opts {
+		if name == "hex" || strings.HasSuffix(name, ".hex") {
+			if hex, ok := value.(bool); ok && hex {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // Option returns the named option.
 func (v EnumValue) Option(name string) interface{} { return v.Options[name] }
 
+// OptionList returns the options sorted by name.
+func (v EnumValue) OptionList() []Option { return optionList(v.Options) }
+
 // Service contains details about a service definition within a proto file.
 type Service struct {
 	Name        string           `json:"name"`
 	LongName    string           `json:"longName"`
 	FullName    string           `json:"fullName"`
+	File        string           `json:"file"`
 	Description string           `json:"description"`
 	Methods     []*ServiceMethod `json:"methods"`
+	// Anchor is the anchor of the documentation of the service, the same in every output format (see Template.Slug).
+	Anchor string `json:"anchor"`
+
+	// OpenAPITag is the documentation of the service from its grpc-gateway openapiv2_tag annotation, nil without one.
+	OpenAPITag *OpenAPITag `json:"openapiTag,omitempty"`
 
 	Options map[string]interface{} `json:"options,omitempty"`
 
 	Source *Source
+
+	category string
 }
 
+// Category returns the category of the service, set by the category option (see CategoryOption), or an empty
+// string.
+func (s Service) Category() string { return s.category }
+
 // Option returns the named option.
 func (s Service) Option(name string) interface{} { return s.Options[name] }
 
+// OptionList returns the options sorted by name.
+func (s Service) OptionList() []Option { return optionList(s.Options) }
+
+// DefaultHost returns the host the service is reachable at by default, as set by the `google.api.default_host` option.
+func (s Service) DefaultHost() string {
+	host, _ := s.Options["google.api.default_host"].(string)
+	return host
+}
+
+// OAuthScopes returns the OAuth scopes needed to call the service, as listed (comma-separated) by the
+// `google.api.oauth_scopes` option.
+func (s Service) OAuthScopes() []string {
+	value, _ := s.Options["google.api.oauth_scopes"].(string)
+	return splitList(value)
+}
+
+// MethodsByTag groups the methods of the service by tag (see ServiceMethod.Tags), e.g. for listing the beta methods
+// together. Methods with several tags are part of each of their groups, and methods without tags are grouped under
+// Untagged. Within a tag, methods are listed in order.
+func (s Service) MethodsByTag() map[string][]*ServiceMethod {
+	groups := make(map[string][]*ServiceMethod)
+	for _, method := range s.Methods {
+		if len(method.Tags) == 0 {
+			groups[Untagged] = append(groups[Untagged], method)
+		}
+		for _, tag := range method.Tags {
+			groups[tag] = append(groups[tag], method)
+		}
+	}
+	return groups
+}
+
+// splitList splits a comma-separated list, trimming the items and dropping empty ones.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // MethodOptions returns all options that are set on the methods in this service.
 func (s Service) MethodOptions() []string {
 	optionSet := make(map[string]struct{})
@@ -581,12 +2402,112 @@ type ServiceMethod struct {
 	ResponseFullType  string `json:"responseFullType"`
 	ResponseStreaming bool   `json:"responseStreaming"`
 
+	// RequestLink and ResponseLink point to the request and response message definitions. They are nil when the
+	// type isn't part of the generated files and isn't a well-known type either.
+	RequestLink  *Link `json:"requestLink,omitempty"`
+	ResponseLink *Link `json:"responseLink,omitempty"`
+
+	// RequestMessage and ResponseMessage are the request and response messages, so that templates can list their fields
+	// right below the method. They are nil for types that aren't part of the generated files (e.g. well-known types).
+	RequestMessage  *Message `json:"-"`
+	ResponseMessage *Message `json:"-"`
+
+	// HTTPRules are the HTTP bindings of the method from its google.api.http annotation, additional bindings included.
+	HTTPRules []*HTTPRule `json:"httpRules,omitempty"`
+
+	// OpenAPIOperation is the documentation of the method from its grpc-gateway openapiv2_operation annotation (e.g. a
+	// summary and tags), nil without one.
+	OpenAPIOperation *OpenAPIOperation `json:"openapiOperation,omitempty"`
+
+	// SeeAlso links the types referenced by `@see <full name>` lines of the comment, which aren't part of the
+	// description. Types that can't be resolved are left with a FullName only, and are rendered as plain text.
+	SeeAlso []*Link `json:"seeAlso,omitempty"`
+
+	// ReplacedBy links the successor of the method, like Message.ReplacedBy.
+	ReplacedBy *Link `json:"replacedBy,omitempty"`
+
+	// Tags are the tags of the method, listed (comma-separated) by the tags option (see TagsOption), e.g. `beta`.
+	Tags []string `json:"tags,omitempty"`
+
 	Options map[string]interface{} `json:"options,omitempty"`
+
+	seeRefs []string
+	service string
+}
+
+// HTTPRule is an HTTP binding of a method (see google/api/http.proto).
+type HTTPRule struct {
+	// Method is the HTTP method, e.g. `GET`, or the kind of a custom pattern.
+	Method string `json:"method"`
+	// Path is the URL template, e.g. `/v1/{name=shelves/*}`.
+	Path string `json:"path"`
+	// Body is the request field mapped to the body: `*` for the whole request message, empty for no body.
+	Body         string `json:"body,omitempty"`
+	ResponseBody string `json:"responseBody,omitempty"`
 }
 
 // Option returns the named option.
 func (m ServiceMethod) Option(name string) interface{} { return m.Options[name] }
 
+// OptionList returns the options sorted by name.
+func (m ServiceMethod) OptionList() []Option { return optionList(m.Options) }
+
+// StubSignature returns the signature of the method in the gRPC client stubs of the given language, named like the
+// columns of the scalar value types (see ScalarValue): `go` or `python`. It's empty for other languages. Streamed
+// requests and responses use the stream types of the language, e.g. for a unary method of `LibraryService`:
+//
+//	func (c *LibraryServiceClient) GetBook(ctx context.Context, in *GetBookRequest, opts ...grpc.CallOption) (*Book, error)
+//	def GetBook(self, request: GetBookRequest) -> Book
+//
+// Messages are named by their long name, with underscores instead of dots in Go (e.g. `Vehicle_Engine`). Messages of
+// other packages are qualified by the last part of their package, e.g. `protobuf.Empty` for `google.protobuf.Empty`.
+func (m ServiceMethod) StubSignature(lang string) string {
+	reqPkg, req := stubType(m.RequestLongType, m.RequestFullType, m.RequestLink)
+	respPkg, resp := stubType(m.ResponseLongType, m.ResponseFullType, m.ResponseLink)
+
+	switch lang {
+	case "go":
+		req = reqPkg + strings.ReplaceAll(req, ".", "_")
+		resp = respPkg + strings.ReplaceAll(resp, ".", "_")
+		params := fmt.Sprintf("ctx context.Context, in *%s, opts ...grpc.CallOption", req)
+		result := "*" + resp
+		switch {
+		case m.RequestStreaming && m.ResponseStreaming:
+			params = "ctx context.Context, opts ...grpc.CallOption"
+			result = fmt.Sprintf("grpc.BidiStreamingClient[%s, %s]", req, resp)
+		case m.RequestStreaming:
+			params = "ctx context.Context, opts ...grpc.CallOption"
+			result = fmt.Sprintf("grpc.ClientStreamingClient[%s, %s]", req, resp)
+		case m.ResponseStreaming:
+			result = fmt.Sprintf("grpc.ServerStreamingClient[%s]", resp)
+		}
+		return fmt.Sprintf("func (c *%sClient) %s(%s) (%s, error)", m.service, m.Name, params, result)
+	case "python":
+		req, resp = reqPkg+req, respPkg+resp
+		param := "request: " + req
+		if m.RequestStreaming {
+			param = fmt.Sprintf("request_iterator: Iterator[%s]", req)
+		}
+		if m.ResponseStreaming {
+			resp = fmt.Sprintf("Iterator[%s]", resp)
+		}
+		return fmt.Sprintf("def %s(self, %s) -> %s", m.Name, param, resp)
+	}
+	return ""
+}
+
+// stubType splits a request or response type into the qualifier of its package (e.g. `protobuf.` for
+// `google.protobuf.Empty`), empty for the package of the method, and its long name. Types of unknown packages are
+// named by their full name.
+func stubType(longType, fullType string, link *Link) (string, string) {
+	// the long type of other packages is the full type with a leading dot
+	longType = strings.TrimPrefix(longType, ".")
+	if longType != fullType || link == nil || link.Package == "" {
+		return "", longType
+	}
+	return link.Package[strings.LastIndex(link.Package, ".")+1:] + ".", strings.TrimPrefix(fullType, link.Package+".")
+}
+
 // ScalarValue contains information about scalar value types in protobuf. The common use case for this type is to know
 // which language specific type maps to the protobuf type.
 //
@@ -602,24 +2523,42 @@ type ScalarValue struct {
 	PhpType    string `json:"phpType"`
 	PythonType string `json:"pythonType"`
 	RubyType   string `json:"rubyType"`
+	TsType     string `json:"tsType"`
+	DartType   string `json:"dartType"`
+
+	// LangNotes are the caveats of the scalar in some languages, keyed by language: `cpp`, `csharp`, `dart`, `go`,
+	// `java`, `php`, `python`, `ruby` or `typescript`. Notes applies to all languages.
+	LangNotes map[string]string `json:"langNotes,omitempty"`
 }
 
-func parseEnum(f *protokit.FileDescriptor, acc []int32, pe *protokit.EnumDescriptor) *Enum {
+// LangNote returns the caveat of the scalar in the given language (see LangNotes), or an empty string.
+func (s ScalarValue) LangNote(lang string) string { return s.LangNotes[lang] }
+
+func parseEnum(f *protokit.FileDescriptor, acc []int32, pe *protokit.EnumDescriptor, options *optionResolver, trailingComments bool) *Enum {
 	enum := &Enum{
 		Name:        pe.GetName(),
 		LongName:    pe.GetLongName(),
 		FullName:    pe.GetFullName(),
+		File:        f.GetName(),
 		Description: description(pe.GetComments().String()),
-		Options:     mergeOptions(extractOptions(pe.GetOptions()), extensions.Transform(pe.OptionExtensions)),
+		Options:     mergeOptions(extractOptions(options.resolve(pe.GetOptions())), extensions.Transform(pe.OptionExtensions)),
 		Source:      NewSource(f, acc),
 	}
+	enum.Description, enum.seeRefs = seeAlso(enum.Description)
+
+	var reserved []ReservedRange
+	for _, r := range pe.GetReservedRange() {
+		reserved = append(reserved, ReservedRange{Start: r.GetStart(), End: r.GetEnd()})
+	}
+	enum.ReservedRanges = reservedRanges(reserved)
+	enum.ReservedNames = pe.GetReservedName()
 
 	for _, val := range pe.GetValues() {
 		enum.Values = append(enum.Values, &EnumValue{
 			Name:        val.GetName(),
 			Number:      fmt.Sprint(val.GetNumber()),
-			Description: description(val.GetComments().String()),
-			Options:     mergeOptions(extractOptions(val.GetOptions()), extensions.Transform(val.OptionExtensions)),
+			Description: description(memberComments(val.GetComments(), trailingComments)),
+			Options:     mergeOptions(extractOptions(options.resolve(val.GetOptions())), extensions.Transform(val.OptionExtensions)),
 		})
 	}
 
@@ -629,7 +2568,7 @@ func parseEnum(f *protokit.FileDescriptor, acc []int32, pe *protokit.EnumDescrip
 func parseFileExtension(pe *protokit.ExtensionDescriptor) *FileExtension {
 	t, lt, ft := parseType(pe)
 
-	return &FileExtension{
+	ext := &FileExtension{
 		Name:               pe.GetName(),
 		LongName:           pe.GetLongName(),
 		FullName:           pe.GetFullName(),
@@ -639,36 +2578,80 @@ func parseFileExtension(pe *protokit.ExtensionDescriptor) *FileExtension {
 		LongType:           lt,
 		FullType:           ft,
 		Number:             int(pe.GetNumber()),
-		DefaultValue:       pe.GetDefaultValue(),
+		DefaultValue:       normalizeDefault(t, pe.GetDefaultValue()),
 		ContainingType:     baseName(pe.GetExtendee()),
 		ContainingLongType: strings.TrimPrefix(pe.GetExtendee(), "."+pe.GetPackage()+"."),
 		ContainingFullType: strings.TrimPrefix(pe.GetExtendee(), "."),
+		IsOptionDefinition: isOptionsType(strings.TrimPrefix(pe.GetExtendee(), ".")),
+	}
+
+	if ext.IsOptionDefinition {
+		scope := pe.GetPackage()
+		if parent := pe.GetParent(); parent != nil {
+			scope = parent.GetFullName()
+		}
+		ext.OptionName = strings.TrimPrefix(scope+"."+pe.GetName(), ".")
+
+		opts := pe.GetOptions()
+		if opts.GetRetention() != descriptorpb.FieldOptions_RETENTION_UNKNOWN {
+			ext.Retention = strings.TrimPrefix(opts.GetRetention().String(), "RETENTION_")
+		}
+		targets := opts.GetTargets()
+		if len(targets) == 0 && opts != nil && opts.Target != nil {
+			targets = append(targets, opts.GetTarget())
+		}
+		for _, target := range targets {
+			ext.Targets = append(ext.Targets, strings.TrimPrefix(target.String(), "TARGET_TYPE_"))
+		}
 	}
+
+	return ext
 }
 
-func parseMessage(f *protokit.FileDescriptor, acc []int32, pm *protokit.Descriptor) *Message {
+// isOptionsType returns whether the given type is one of the google.protobuf.*Options messages.
+func isOptionsType(fullType string) bool {
+	name, ok := strings.CutPrefix(fullType, "google.protobuf.")
+	return ok && strings.HasSuffix(name, "Options") && !strings.Contains(name, ".")
+}
+
+func parseMessage(f *protokit.FileDescriptor, acc []int32, pm *protokit.Descriptor, options *optionResolver, trailingComments bool) *Message {
 	msg := &Message{
 		Name:          pm.GetName(),
 		LongName:      pm.GetLongName(),
 		FullName:      pm.GetFullName(),
+		File:          f.GetName(),
 		Description:   description(pm.GetComments().String()),
 		HasExtensions: len(pm.GetExtensions()) > 0,
 		HasFields:     len(pm.GetMessageFields()) > 0,
 		HasOneofs:     len(pm.GetOneofDecl()) > 0,
 		Extensions:    make([]*MessageExtension, 0, len(pm.Extensions)),
-		Options:       mergeOptions(extractOptions(pm.GetOptions()), extensions.Transform(pm.OptionExtensions)),
+		Options:       mergeOptions(extractOptions(options.resolve(pm.GetOptions())), extensions.Transform(pm.OptionExtensions)),
 		Source:        NewSource(f, acc),
 	}
+	msg.Description, msg.seeRefs = seeAlso(msg.Description)
 
 	for _, ext := range pm.Extensions {
 		msg.Extensions = append(msg.Extensions, parseMessageExtension(ext))
 	}
 
+	// The ends of the reserved ranges of messages are exclusive, unlike those of enums.
+	var reserved []ReservedRange
+	for _, r := range pm.GetReservedRange() {
+		reserved = append(reserved, ReservedRange{Start: r.GetStart(), End: r.GetEnd() - 1})
+	}
+	msg.ReservedRanges = reservedRanges(reserved)
+	msg.ReservedNames = pm.GetReservedName()
+
 	var oneOfNames []string
 	oneOfs := map[string][]*MessageField{}
-	for _, fd := range pm.Fields {
-		field := parseMessageField(fd, pm.GetOneofDecl())
-		if field.Label != "optional" && field.IsOneof {
+	for i, fd := range pm.Fields {
+		field := parseMessageField(fd, pm.GetOneofDecl(), options, trailingComments)
+		field.message = msg.FullName
+		field.position = i
+		field.Source = NewSource(f, append(slices.Clone(acc), 2, int32(i)))
+		// proto3 `optional` fields are wrapped in synthetic oneofs, which aren't documented as such. Members of real oneofs
+		// are labeled `optional` as well in proto2 and editions files, so only the proto3_optional flag tells them apart.
+		if field.IsOneof && !field.proto3Optional {
 			oneOfNames = append(oneOfNames, field.OneofDecl)
 			oneOfs[field.OneofDecl] = append(oneOfs[field.OneofDecl], field)
 			continue
@@ -676,11 +2659,20 @@ func parseMessage(f *protokit.FileDescriptor, acc []int32, pm *protokit.Descript
 		msg.Fields = append(msg.Fields, field)
 	}
 	for i, oon := range slices.Compact(oneOfNames) {
+		var opts *descriptor.OneofOptions
+		for j, decl := range pm.GetOneofDecl() {
+			if decl.GetName() == oon {
+				i, opts = j, decl.GetOptions()
+				break
+			}
+		}
 		oneOf := &OneOf{
-			Name:   oon,
-			Fields: oneOfs[oon],
-			Source: NewSource(f, append(acc, []int32{8, int32(i)}...)),
+			Name:    oon,
+			Fields:  oneOfs[oon],
+			Options: extractOptions(options.resolve(opts)),
+			Source:  NewSource(f, append(acc, []int32{8, int32(i)}...)),
 		}
+		oneOf.IsRequired = parseOneofRequired(opts, oneOf.Options)
 		oneOf.Description = strings.TrimSpace(strings.Join(
 			[]string{
 				oneOf.Source.leadingComments,
@@ -689,6 +2681,12 @@ func parseMessage(f *protokit.FileDescriptor, acc []int32, pm *protokit.Descript
 		msg.OneOfs = append(msg.OneOfs, oneOf)
 	}
 
+	if msg.HasFieldBehaviors() {
+		for _, field := range msg.allFields() {
+			field.behaviorColumn = true
+		}
+	}
+
 	return msg
 }
 
@@ -701,21 +2699,36 @@ func parseMessageExtension(pe *protokit.ExtensionDescriptor) *MessageExtension {
 	}
 }
 
-func parseMessageField(pf *protokit.FieldDescriptor, oneofDecls []*descriptor.OneofDescriptorProto) *MessageField {
+func parseMessageField(pf *protokit.FieldDescriptor, oneofDecls []*descriptor.OneofDescriptorProto, options *optionResolver, trailingComments bool) *MessageField {
 	t, lt, ft := parseType(pf)
 
 	m := &MessageField{
 		Index:        int(pf.FieldDescriptorProto.GetNumber()),
 		Name:         pf.GetName(),
-		Description:  description(pf.GetComments().String()),
+		JSONName:     jsonName(pf.FieldDescriptorProto),
+		Description:  description(memberComments(pf.GetComments(), trailingComments)),
 		Label:        labelName(pf.GetLabel(), pf.IsProto3(), pf.GetProto3Optional()),
 		Type:         t,
 		LongType:     lt,
 		FullType:     ft,
-		DefaultValue: pf.GetDefaultValue(),
-		Options:      mergeOptions(extractOptions(pf.GetOptions()), extensions.Transform(pf.OptionExtensions)),
+		DefaultValue: normalizeDefault(t, pf.GetDefaultValue()),
+		Required:     pf.GetLabel() == descriptor.FieldDescriptorProto_LABEL_REQUIRED,
+		Options:      mergeOptions(extractOptions(options.resolve(pf.GetOptions())), extensions.Transform(pf.OptionExtensions)),
 		IsOneof:      pf.OneofIndex != nil,
+
+		proto3Optional: pf.GetProto3Optional(),
+		isMessage: pf.GetType() == descriptor.FieldDescriptorProto_TYPE_MESSAGE ||
+			pf.GetType() == descriptor.FieldDescriptorProto_TYPE_GROUP,
 	}
+	m.FieldBehaviors = parseFieldBehaviors(pf.GetOptions(), m.Options)
+	m.Presence = fieldPresence(pf)
+	m.HasPresence = m.Presence != PresenceImplicit
+	m.Packed, m.PackedExplicit = packedEncoding(pf)
+	m.Features = fieldFeatures(pf)
+	m.Description, m.Group = fieldGroup(m.Description)
+	m.Description, m.seeRefs = seeAlso(m.Description)
+	m.Description, m.TypeAlias = typeAlias(m.Description)
+	m.Description, m.anyRefs = anyTypes(m.Description)
 
 	if m.IsOneof {
 		m.OneofDecl = oneofDecls[pf.GetOneofIndex()].GetName()
@@ -735,25 +2748,77 @@ func parseMessageField(pf *protokit.FieldDescriptor, oneofDecls []*descriptor.On
 	return m
 }
 
-func parseService(f *protokit.FileDescriptor, acc []int32, ps *protokit.ServiceDescriptor) *Service {
+// fieldPresence returns how the presence of the field is tracked: repeated fields and proto3 scalars and enums without
+// the `optional` label have implicit presence, proto2 `required` fields are required and others are explicit.
+func fieldPresence(pf *protokit.FieldDescriptor) FieldPresence {
+	switch {
+	case pf.GetLabel() == descriptor.FieldDescriptorProto_LABEL_REPEATED:
+		return PresenceImplicit
+	case pf.GetLabel() == descriptor.FieldDescriptorProto_LABEL_REQUIRED:
+		return PresenceRequired
+	case pf.GetType() == descriptor.FieldDescriptorProto_TYPE_MESSAGE,
+		pf.GetType() == descriptor.FieldDescriptorProto_TYPE_GROUP,
+		pf.OneofIndex != nil,
+		!pf.IsProto3():
+		return PresenceExplicit
+	}
+	return PresenceImplicit
+}
+
+// packedEncoding returns whether the repeated scalar field uses the packed encoding and whether that's set explicitly
+// with the `packed` option rather than by the default of the syntax. In editions files, it's the resolved
+// `repeated_field_encoding` feature, explicit when the field sets it.
+func packedEncoding(pf *protokit.FieldDescriptor) (packed, explicit bool) {
+	if pf.GetLabel() != descriptor.FieldDescriptorProto_LABEL_REPEATED {
+		return false, false
+	}
+	switch pf.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_STRING,
+		descriptor.FieldDescriptorProto_TYPE_BYTES,
+		descriptor.FieldDescriptorProto_TYPE_MESSAGE,
+		descriptor.FieldDescriptorProto_TYPE_GROUP:
+		return false, false
+	}
+	if features := fieldFeatures(pf); features != nil {
+		own := map[string]string{}
+		overrideFeatures(own, pf.GetOptions())
+		_, explicit = own["repeated_field_encoding"]
+		return features["repeated_field_encoding"] == "PACKED", explicit
+	}
+	if opts := pf.GetOptions(); opts != nil && opts.Packed != nil {
+		return opts.GetPacked(), true
+	}
+	return pf.IsProto3(), false
+}
+
+func parseService(f *protokit.FileDescriptor, acc []int32, ps *protokit.ServiceDescriptor, options *optionResolver) *Service {
 	service := &Service{
 		Name:        ps.GetName(),
 		LongName:    ps.GetLongName(),
 		FullName:    ps.GetFullName(),
+		File:        f.GetName(),
 		Description: description(ps.GetComments().String()),
-		Options:     mergeOptions(extractOptions(ps.GetOptions()), extensions.Transform(ps.OptionExtensions)),
+		Options:     mergeOptions(extractOptions(options.resolve(ps.GetOptions())), extensions.Transform(ps.OptionExtensions)),
+		OpenAPITag:  parseOpenAPITag(ps.GetOptions()),
 		Source:      NewSource(f, acc),
 	}
+	for name, number := range serviceAPIOptions {
+		if value, ok := unknownString(ps.GetOptions(), number); ok {
+			service.Options = addOption(service.Options, name, value)
+		}
+	}
 
 	for _, sm := range ps.Methods {
-		service.Methods = append(service.Methods, parseServiceMethod(sm))
+		method := parseServiceMethod(sm, options)
+		method.service = service.Name
+		service.Methods = append(service.Methods, method)
 	}
 
 	return service
 }
 
-func parseServiceMethod(pm *protokit.MethodDescriptor) *ServiceMethod {
-	return &ServiceMethod{
+func parseServiceMethod(pm *protokit.MethodDescriptor, options *optionResolver) *ServiceMethod {
+	method := &ServiceMethod{
 		Name:              pm.GetName(),
 		Description:       description(pm.GetComments().String()),
 		RequestType:       baseName(pm.GetInputType()),
@@ -764,13 +2829,165 @@ func parseServiceMethod(pm *protokit.MethodDescriptor) *ServiceMethod {
 		ResponseLongType:  strings.TrimPrefix(pm.GetOutputType(), "."+pm.GetPackage()+"."),
 		ResponseFullType:  strings.TrimPrefix(pm.GetOutputType(), "."),
 		ResponseStreaming: pm.GetServerStreaming(),
-		Options:           mergeOptions(extractOptions(pm.GetOptions()), extensions.Transform(pm.OptionExtensions)),
+		Options:           mergeOptions(extractOptions(options.resolve(pm.GetOptions())), extensions.Transform(pm.OptionExtensions)),
+		HTTPRules:         parseHTTPRules(pm.GetOptions()),
+		OpenAPIOperation:  parseOpenAPIOperation(pm.GetOptions()),
+	}
+	method.Description, method.seeRefs = seeAlso(method.Description)
+	return method
+}
+
+func renderDefault(typ, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	switch typ {
+	case "string":
+		return strconv.Quote(value)
+	case "bytes":
+		return "0x" + hex.EncodeToString(unescapeBytes(value))
+	}
+	return value
+}
+
+// normalizeDefault makes sure default values of bytes fields are printable ASCII (and thus valid UTF-8), whatever the
+// escaping of the descriptor: bytes outside of it are escaped as `\xNN`. Other values are returned as they are.
+func normalizeDefault(typ, value string) string {
+	if typ != "bytes" || value == "" {
+		return value
+	}
+
+	var out strings.Builder
+	for _, b := range unescapeBytes(value) {
+		switch {
+		case b == '\\':
+			out.WriteString(`\\`)
+		case b >= 0x20 && b < 0x7f:
+			out.WriteByte(b)
+		default:
+			fmt.Fprintf(&out, `\x%02x`, b)
+		}
+	}
+	return out.String()
+}
+
+// unescapeBytes reverses the C-style escaping protoc applies to default values of bytes fields.
+func unescapeBytes(value string) []byte {
+	var out []byte
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c != '\\' || i+1 == len(value) {
+			out = append(out, c)
+			continue
+		}
+
+		i++
+		switch c = value[i]; c {
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'x', 'X':
+			n := 0
+			for n < 2 && i+1+n < len(value) && isHexDigit(value[i+1+n]) {
+				n++
+			}
+			b, _ := strconv.ParseUint(value[i+1:i+1+n], 16, 8)
+			out = append(out, byte(b))
+			i += n
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			n := 1
+			for n < 3 && i+n < len(value) && value[i+n] >= '0' && value[i+n] <= '7' {
+				n++
+			}
+			b, _ := strconv.ParseUint(value[i:i+n], 8, 8)
+			out = append(out, byte(b))
+			i += n - 1
+		default: // \\, \', \" and \?
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// displayType returns the full type relative to fromPackage when the type is part of it. The package of types that
+// aren't known is empty, so they're fully qualified.
+func displayType(fullType, typePackage, fromPackage string) string {
+	if typePackage == "" || typePackage != fromPackage {
+		return fullType
+	}
+	return strings.TrimPrefix(fullType, typePackage+".")
+}
+
+// typePackage returns the package of the message or enum with the given full name, or an empty string if it isn't
+// known (e.g. for scalars).
+func (t *Template) typePackage(fullName string) string {
+	if l, ok := t.links[fullName]; ok {
+		return l.Package
+	}
+	return t.typePackages[fullName]
+}
+
+// typePackages maps the full names of the messages and enums (nested ones included) of the files to their packages.
+func typePackages(files []*descriptorpb.FileDescriptorProto) map[string]string {
+	packages := make(map[string]string)
+	var addMessages func(prefix, pkg string, msgs []*descriptorpb.DescriptorProto)
+	addEnums := func(prefix, pkg string, enums []*descriptorpb.EnumDescriptorProto) {
+		for _, enum := range enums {
+			packages[prefix+enum.GetName()] = pkg
+		}
+	}
+	addMessages = func(prefix, pkg string, msgs []*descriptorpb.DescriptorProto) {
+		for _, msg := range msgs {
+			packages[prefix+msg.GetName()] = pkg
+			addMessages(prefix+msg.GetName()+".", pkg, msg.GetNestedType())
+			addEnums(prefix+msg.GetName()+".", pkg, msg.GetEnumType())
+		}
+	}
+
+	for _, file := range files {
+		prefix := ""
+		if file.GetPackage() != "" {
+			prefix = file.GetPackage() + "."
+		}
+		addMessages(prefix, file.GetPackage(), file.GetMessageType())
+		addEnums(prefix, file.GetPackage(), file.GetEnumType())
 	}
+	return packages
 }
 
 func baseName(name string) string {
-	parts := strings.Split(name, ".")
-	return parts[len(parts)-1]
+	return name[strings.LastIndexByte(name, '.')+1:]
+}
+
+// jsonName returns the JSON name of the field. protoc always sets it, descriptors built by hand might not, in which case
+// it's derived from the field name the same way protoc does (`foo_bar` becomes `fooBar`).
+func jsonName(fd *descriptor.FieldDescriptorProto) string {
+	if fd.JsonName != nil {
+		return fd.GetJsonName()
+	}
+
+	var name strings.Builder
+	upper := false
+	for _, r := range fd.GetName() {
+		switch {
+		case r == '_':
+			upper = true
+		case upper:
+			name.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			name.WriteRune(r)
+		}
+	}
+	return name.String()
 }
 
 func labelName(lbl descriptor.FieldDescriptorProto_Label, proto3 bool, proto3Opt bool) string {
@@ -787,18 +3004,39 @@ type typeContainer interface {
 	GetPackage() string
 }
 
+// scalarTypeNames maps the field types to their names, e.g. TYPE_STRING to `string`.
+var scalarTypeNames = func() map[descriptor.FieldDescriptorProto_Type]string {
+	names := make(map[descriptor.FieldDescriptorProto_Type]string, len(descriptorpb.FieldDescriptorProto_Type_name))
+	for value, name := range descriptorpb.FieldDescriptorProto_Type_name {
+		names[descriptor.FieldDescriptorProto_Type(value)] = strings.ToLower(strings.TrimPrefix(name, "TYPE_"))
+	}
+	return names
+}()
+
 func parseType(tc typeContainer) (string, string, string) {
 	name := tc.GetTypeName()
 
-	if strings.HasPrefix(name, ".") {
-		name = strings.TrimPrefix(name, ".")
-		return baseName(name), strings.TrimPrefix(name, tc.GetPackage()+"."), name
+	if full, ok := strings.CutPrefix(name, "."); ok {
+		long := full
+		if pkg := tc.GetPackage(); len(full) > len(pkg) && full[len(pkg)] == '.' && strings.HasPrefix(full, pkg) {
+			long = full[len(pkg)+1:]
+		}
+		return baseName(full), long, full
 	}
 
-	name = strings.ToLower(strings.TrimPrefix(tc.GetType().String(), "TYPE_"))
+	name = scalarTypeNames[tc.GetType()]
 	return name, name, name
 }
 
+// memberComments returns the comments of a field or enum value: the trailing comments if preferred and present, both
+// leading and trailing comments otherwise. Leading comments starting with `@exclude` always exclude the member.
+func memberComments(c *protokit.Comment, preferTrailing bool) string {
+	if preferTrailing && c.GetTrailing() != "" && !strings.HasPrefix(c.GetLeading(), "@exclude") {
+		return c.GetTrailing()
+	}
+	return c.String()
+}
+
 func description(comment string) string {
 	val := strings.TrimLeft(comment, "*/\n ")
 	if strings.HasPrefix(val, "@exclude") {
@@ -808,6 +3046,219 @@ func description(comment string) string {
 	return val
 }
 
+// frontMatter extracts the YAML front-matter from the top of a file description, between `---` lines, and returns the
+// description without it. The description is returned as is when the front-matter isn't a valid YAML mapping.
+func frontMatter(desc string) (string, *yaml.Node) {
+	lines := strings.Split(desc, "\n")
+	if strings.TrimSpace(lines[0]) != "---" {
+		return desc, nil
+	}
+	end := slices.IndexFunc(lines[1:], func(line string) bool { return strings.TrimSpace(line) == "---" }) + 1
+	if end == 0 {
+		return desc, nil
+	}
+
+	// comment lines usually start with the space following `//`
+	source := lines[1:end]
+	if !slices.ContainsFunc(source, func(line string) bool { return line != "" && !strings.HasPrefix(line, " ") }) {
+		for i, line := range source {
+			source[i] = strings.TrimPrefix(line, " ")
+		}
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(source, "\n")), &doc); err != nil {
+		return desc, nil
+	}
+	if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return desc, nil
+	}
+	return strings.TrimSpace(strings.Join(lines[end+1:], "\n")), doc.Content[0]
+}
+
+// frontMatterMeta returns the values of the front-matter by key, see File.Meta.
+func frontMatterMeta(node *yaml.Node) map[string]string {
+	if node == nil {
+		return nil
+	}
+
+	meta := make(map[string]string, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		value := node.Content[i+1]
+		if value.Kind == yaml.ScalarNode {
+			meta[node.Content[i].Value] = value.Value
+			continue
+		}
+		flow := *value
+		flow.Style = yaml.FlowStyle
+		if out, err := yaml.Marshal(&flow); err == nil {
+			meta[node.Content[i].Value] = strings.TrimSpace(string(out))
+		}
+	}
+	return meta
+}
+
+// fieldGroup extracts the group name from a `group: <name>` line of a field description, and returns the description
+// without it.
+func fieldGroup(desc string) (string, string) {
+	if !strings.Contains(desc, "group:") {
+		return desc, ""
+	}
+
+	lines := strings.Split(desc, "\n")
+	for i, line := range lines {
+		if group, ok := strings.CutPrefix(strings.TrimSpace(line), "group:"); ok {
+			lines = append(lines[:i], lines[i+1:]...)
+			return strings.TrimSpace(strings.Join(lines, "\n")), strings.TrimSpace(group)
+		}
+	}
+	return desc, ""
+}
+
+// typeAlias extracts the type label from a `@type-alias <name>` line of a field description, and returns the
+// description without it.
+func typeAlias(desc string) (string, string) {
+	if !strings.Contains(desc, "@type-alias") {
+		return desc, ""
+	}
+
+	lines := strings.Split(desc, "\n")
+	for i, line := range lines {
+		if alias, ok := strings.CutPrefix(strings.TrimSpace(line), "@type-alias "); ok && strings.TrimSpace(alias) != "" {
+			lines = append(lines[:i], lines[i+1:]...)
+			return strings.TrimSpace(strings.Join(lines, "\n")), strings.TrimSpace(alias)
+		}
+	}
+	return desc, ""
+}
+
+// anyTypes extracts the full names of the types listed by an `@any-types <full name>, ...` line of a field description
+// (e.g. `@any-types com.example.Car, com.example.Truck`), and returns the description without it.
+func anyTypes(desc string) (string, []string) {
+	if !strings.Contains(desc, "@any-types") {
+		return desc, nil
+	}
+
+	lines := strings.Split(desc, "\n")
+	for i, line := range lines {
+		if list, ok := strings.CutPrefix(strings.TrimSpace(line), "@any-types "); ok && strings.TrimSpace(list) != "" {
+			var refs []string
+			for _, ref := range strings.Split(list, ",") {
+				if ref = strings.TrimPrefix(strings.TrimSpace(ref), "."); ref != "" {
+					refs = append(refs, ref)
+				}
+			}
+			lines = append(lines[:i], lines[i+1:]...)
+			return strings.TrimSpace(strings.Join(lines, "\n")), refs
+		}
+	}
+	return desc, nil
+}
+
+// seeAlso extracts the full names of the types referenced by `@see <full name>` lines of a description (e.g.
+// `@see com.example.Vehicle`), and returns the description without them.
+func seeAlso(desc string) (string, []string) {
+	if !strings.Contains(desc, "@see") {
+		return desc, nil
+	}
+
+	var refs []string
+	lines := strings.Split(desc, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if ref, ok := strings.CutPrefix(strings.TrimSpace(line), "@see "); ok && strings.TrimSpace(ref) != "" {
+			refs = append(refs, strings.TrimPrefix(strings.TrimSpace(ref), "."))
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n")), refs
+}
+
+// includePattern matches the `@include <name>` lines of descriptions (see WithSnippets).
+var includePattern = regexp.MustCompile(`(?m)^[ \t]*@include[ \t]+(\S+)[ \t]*$`)
+
+// includes replaces the `@include <name>` lines of a description with the named snippets. Lines naming unknown snippets
+// are left as they are.
+func includes(desc string, snippets map[string]string) string {
+	if !strings.Contains(desc, "@include") {
+		return desc
+	}
+	return includePattern.ReplaceAllStringFunc(desc, func(line string) string {
+		if snippet, ok := snippets[includePattern.FindStringSubmatch(line)[1]]; ok {
+			return strings.TrimSpace(snippet)
+		}
+		return line
+	})
+}
+
+// expandIncludes expands the `@include` lines of all descriptions (see WithSnippets).
+func (t *Template) expandIncludes() {
+	expand := func(desc *string) { *desc = includes(*desc, t.snippets) }
+	for _, pkg := range t.Packages {
+		for _, desc := range pkg.Descriptions {
+			expand(&desc.Description)
+		}
+	}
+	for _, file := range t.Files {
+		expand(&file.Description)
+		for _, ext := range file.Extensions {
+			expand(&ext.Description)
+		}
+		for _, msg := range file.Messages {
+			expand(&msg.Description)
+			for _, field := range msg.allFields() {
+				expand(&field.Description)
+			}
+			for _, oneOf := range msg.OneOfs {
+				expand(&oneOf.Description)
+			}
+			for _, ext := range msg.Extensions {
+				expand(&ext.Description)
+			}
+		}
+		for _, enum := range file.Enums {
+			expand(&enum.Description)
+			for _, value := range enum.Values {
+				expand(&value.Description)
+			}
+		}
+		for _, service := range file.Services {
+			expand(&service.Description)
+			for _, method := range service.Methods {
+				expand(&method.Description)
+			}
+		}
+	}
+}
+
+// resolveSeeAlso returns the links to the referenced types, see Message.SeeAlso and MessageField.AnyTypes.
+func (t *Template) resolveSeeAlso(refs []string) []*Link {
+	if len(refs) == 0 {
+		return nil
+	}
+
+	links := make([]*Link, 0, len(refs))
+	for _, ref := range refs {
+		if l := t.resolveLink(ref); l != nil {
+			links = append(links, l)
+		} else {
+			links = append(links, &Link{FullName: ref})
+		}
+	}
+	return links
+}
+
+func visibleMessages(messages []*Message) []*Message {
+	visible := make([]*Message, 0, len(messages))
+	for _, m := range messages {
+		if !m.Internal {
+			visible = append(visible, m)
+		}
+	}
+	return visible
+}
+
 type orderedEnums []*Enum
 
 func (oe orderedEnums) Len() int           { return len(oe) }
@@ -831,3 +3282,163 @@ type orderedServices []*Service
 func (os orderedServices) Len() int           { return len(os) }
 func (os orderedServices) Swap(i, j int)      { os[i], os[j] = os[j], os[i] }
 func (os orderedServices) Less(i, j int) bool { return os[i].LongName < os[j].LongName }
+
+// serviceAPIOptions are the field numbers of the string extensions of google.protobuf.ServiceOptions defined by
+// google/api/client.proto.
+var serviceAPIOptions = map[string]protowire.Number{
+	"google.api.default_host": 1049,
+	"google.api.oauth_scopes": 1050,
+}
+
+// unknownString returns the (last) value of the string field with the given number among the unknown fields of the
+// options, e.g. an extension that isn't registered.
+func unknownString(opts *descriptor.ServiceOptions, number protowire.Number) (string, bool) {
+	var value string
+	found := false
+	forEachUnknownField(opts, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) {
+		if num == number && typ == protowire.BytesType {
+			value, found = string(v), true
+		}
+	})
+	return value, found
+}
+
+// httpRuleNumber is the field number of the google.api.http extension of google.protobuf.MethodOptions.
+const httpRuleNumber = 72295728
+
+// httpRuleMethods are the field numbers of the patterns of google.api.HttpRule.
+var httpRuleMethods = map[protowire.Number]string{2: "GET", 3: "PUT", 4: "POST", 5: "DELETE", 6: "PATCH"}
+
+// parseHTTPRules returns the bindings of the google.api.http annotation, read from the unknown fields of the options.
+func parseHTTPRules(opts *descriptor.MethodOptions) []*HTTPRule {
+	var rules []*HTTPRule
+	forEachUnknownField(opts, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) {
+		if num == httpRuleNumber && typ == protowire.BytesType {
+			rules = appendHTTPRule(rules, v)
+		}
+	})
+	return rules
+}
+
+// appendHTTPRule decodes an encoded google.api.HttpRule and appends it to the rules, followed by its additional
+// bindings.
+func appendHTTPRule(rules []*HTTPRule, b []byte) []*HTTPRule {
+	rule := new(HTTPRule)
+	var additional [][]byte
+	forEachField(b, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) {
+		if typ != protowire.BytesType {
+			return
+		}
+		switch num {
+		case 2, 3, 4, 5, 6:
+			rule.Method, rule.Path = httpRuleMethods[num], string(v)
+		case 7:
+			rule.Body = string(v)
+		case 8:
+			rule.Method, rule.Path = parseCustomHTTPPattern(v)
+		case 11:
+			additional = append(additional, v)
+		case 12:
+			rule.ResponseBody = string(v)
+		}
+	})
+
+	if rule.Path != "" {
+		rules = append(rules, rule)
+	}
+	for _, v := range additional {
+		rules = appendHTTPRule(rules, v)
+	}
+	return rules
+}
+
+// parseCustomHTTPPattern decodes an encoded google.api.CustomHttpPattern into its kind and path.
+func parseCustomHTTPPattern(b []byte) (kind, path string) {
+	forEachField(b, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) {
+		if typ != protowire.BytesType {
+			return
+		}
+		switch num {
+		case 1:
+			kind = string(v)
+		case 2:
+			path = string(v)
+		}
+	})
+	return kind, path
+}
+
+// oneofConstraintsNumber is the field number of the buf.validate.oneof extension of google.protobuf.OneofOptions, whose
+// `required` field has number 1.
+const oneofConstraintsNumber = 1159
+
+// parseOneofRequired returns whether `(buf.validate.oneof).required` is set. Unless the extension is registered (in
+// which case it's already part of the options), it's read from the unknown fields of the options.
+func parseOneofRequired(opts *descriptor.OneofOptions, options map[string]interface{}) bool {
+	if constraints, ok := options["buf.validate.oneof"].(map[string]interface{}); ok {
+		required, _ := constraints["required"].(bool)
+		return required
+	}
+
+	required := false
+	constraints, _ := unknownMessage(opts, oneofConstraintsNumber)
+	forEachField(constraints, func(num protowire.Number, typ protowire.Type, _ []byte, v uint64) {
+		if num == 1 && typ == protowire.VarintType {
+			required = v != 0
+		}
+	})
+	return required
+}
+
+// fieldBehaviorNumber is the field number of the google.api.field_behavior extension of google.protobuf.FieldOptions.
+const fieldBehaviorNumber = 1052
+
+var fieldBehaviorNames = []string{
+	"FIELD_BEHAVIOR_UNSPECIFIED",
+	"OPTIONAL",
+	"REQUIRED",
+	"OUTPUT_ONLY",
+	"INPUT_ONLY",
+	"IMMUTABLE",
+	"UNORDERED_LIST",
+	"NON_EMPTY_DEFAULT",
+	"IDENTIFIER",
+}
+
+// parseFieldBehaviors returns the values of the google.api.field_behavior annotation. Unless the extension is
+// registered (in which case it's already part of the options), it's read from the unknown fields of the options.
+func parseFieldBehaviors(opts *descriptor.FieldOptions, options map[string]interface{}) []string {
+	if values, ok := options["google.api.field_behavior"].([]interface{}); ok {
+		behaviors := make([]string, 0, len(values))
+		for _, value := range values {
+			behaviors = append(behaviors, fmt.Sprint(value))
+		}
+		return behaviors
+	}
+
+	var behaviors []string
+	add := func(v uint64) {
+		if v < uint64(len(fieldBehaviorNames)) {
+			behaviors = append(behaviors, fieldBehaviorNames[v])
+		} else {
+			behaviors = append(behaviors, fmt.Sprint(v))
+		}
+	}
+
+	forEachUnknownField(opts, func(num protowire.Number, typ protowire.Type, packed []byte, v uint64) {
+		switch {
+		case num == fieldBehaviorNumber && typ == protowire.VarintType:
+			add(v)
+		case num == fieldBehaviorNumber && typ == protowire.BytesType:
+			for len(packed) > 0 {
+				v, n := protowire.ConsumeVarint(packed)
+				if n < 0 {
+					return
+				}
+				add(v)
+				packed = packed[n:]
+			}
+		}
+	})
+	return behaviors
+}
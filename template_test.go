@@ -1,16 +1,27 @@
 package gendoc_test
 
 import (
+	"fmt"
 	"os"
+	"slices"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin_go "github.com/golang/protobuf/protoc-gen-go/plugin"
 	. "github.com/pseudomuto/protoc-gen-doc"
 	"github.com/pseudomuto/protoc-gen-doc/extensions"
 	"github.com/pseudomuto/protokit"
 	"github.com/pseudomuto/protokit/utils"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
 )
 
 var (
@@ -25,7 +36,10 @@ var (
 func TestMain(m *testing.M) {
 	registerTestExtensions()
 
-	set, _ := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	if err != nil {
+		panic(err)
+	}
 	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto")
 	result := protokit.ParseCodeGenRequest(req)
 
@@ -33,7 +47,10 @@ func TestMain(m *testing.M) {
 	bookingFile = template.Files[0]
 	vehicleFile = template.Files[1]
 
-	set, _ = utils.LoadDescriptorSet("fixtures", "cookie.pb")
+	set, err = utils.LoadDescriptorSet("fixtures", "cookie.pb")
+	if err != nil {
+		panic(err)
+	}
 	req = utils.CreateGenRequest(set, "Cookie.proto")
 	result = protokit.ParseCodeGenRequest(req)
 	cookieTemplate = NewTemplate(result)
@@ -137,7 +154,102 @@ func TestFileProperties(t *testing.T) {
 	require.True(t, bookingFile.HasMessages)
 	require.True(t, bookingFile.HasServices)
 	require.NotEmpty(t, bookingFile.Options)
-	require.True(t, *bookingFile.Option(E_ExtendFile.Name).(*bool))
+	require.True(t, bookingFile.Option(E_ExtendFile.Name).(bool))
+}
+
+func TestFileChecksum(t *testing.T) {
+	require.Len(t, bookingFile.Checksum, 64)
+	require.NotEqual(t, bookingFile.Checksum, vehicleFile.Checksum)
+
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+	req := utils.CreateGenRequest(set, "Booking.proto")
+	require.Equal(t, bookingFile.Checksum, NewTemplate(protokit.ParseCodeGenRequest(req)).Files[0].Checksum)
+
+	text := `name: "a.proto" package: "test" message_type: { name: "A" }`
+	require.Equal(t, newTestTemplate(t, text).Files[0].Checksum, newTestTemplate(t, text).Files[0].Checksum)
+	require.NotEqual(t,
+		newTestTemplate(t, text).Files[0].Checksum,
+		newTestTemplate(t, `name: "a.proto" package: "test" message_type: { name: "B" }`).Files[0].Checksum,
+	)
+}
+
+func TestFileModule(t *testing.T) {
+	require.Empty(t, bookingFile.Module)
+
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+	req := utils.CreateGenRequest(set, "Booking.proto", "Vehicle.proto")
+	tmpl := NewTemplate(protokit.ParseCodeGenRequest(req), WithModules(map[string]string{
+		"Vehicle.proto": "buf.build/example/vehicle",
+	}))
+	require.Empty(t, tmpl.Files[0].Module)
+	require.Equal(t, "buf.build/example/vehicle", tmpl.Files[1].Module)
+}
+
+func TestEntityFile(t *testing.T) {
+	require.Equal(t, "Booking.proto", findMessage("Booking", bookingFile).File)
+	require.Equal(t, "Booking.proto", findEnum("BookingStatus.StatusCode", bookingFile).File)
+	require.Equal(t, "Booking.proto", findService("BookingService", bookingFile).File)
+	require.Equal(t, "Vehicle.proto", findMessage("Vehicle.Category", vehicleFile).File)
+
+	for _, pkg := range template.Packages {
+		for _, msg := range pkg.Messages {
+			require.Equal(t, msg.Source.File, msg.File)
+		}
+	}
+}
+
+func TestFileStandardOptions(t *testing.T) {
+	require.False(t, bookingFile.Deprecated)
+	require.Empty(t, bookingFile.StandardOptions())
+
+	tmpl := newTestTemplate(t, `
+		name: "old.proto"
+		package: "test"
+		options: {
+			java_package: "com.example.old"
+			go_package: "example.com/old;old"
+			java_multiple_files: true
+			optimize_for: CODE_SIZE
+			deprecated: true
+		}
+	`)
+	require.True(t, tmpl.Files[0].Deprecated)
+	require.Equal(t, []Option{
+		{Name: "go_package", Value: "example.com/old;old"},
+		{Name: "java_multiple_files", Value: "true"},
+		{Name: "java_package", Value: "com.example.old"},
+		{Name: "optimize_for", Value: "CODE_SIZE"},
+	}, tmpl.Files[0].StandardOptions())
+}
+
+func TestFileOptionAccessors(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		options: {
+			java_package: "com.example.api"
+			java_outer_classname: "ApiProto"
+			go_package: "example.com/api;api"
+			cc_enable_arenas: true
+			optimize_for: LITE_RUNTIME
+		}
+	`)
+	file := tmpl.Files[0]
+	require.Equal(t, "example.com/api;api", file.GoPackage())
+	require.Equal(t, "com.example.api", file.JavaPackage())
+	require.Equal(t, "ApiProto", file.JavaOuterClassname())
+	require.True(t, file.CcEnableArenas())
+	require.Equal(t, "LITE_RUNTIME", file.OptimizeFor())
+
+	file = newTestTemplate(t, `name: "empty.proto" package: "test"`).Files[0]
+	require.Empty(t, file.GoPackage())
+	require.Empty(t, file.JavaPackage())
+	require.Empty(t, file.JavaOuterClassname())
+	require.False(t, file.CcEnableArenas())
+	require.Empty(t, file.OptimizeFor())
+	require.Empty(t, File{}.GoPackage())
 }
 
 func TestFileEnumProperties(t *testing.T) {
@@ -159,14 +271,14 @@ func TestFileEnumProperties(t *testing.T) {
 
 	enum = findEnum("BookingType", bookingFile)
 	require.NotEmpty(t, enum.Options)
-	require.True(t, *enum.Option(E_ExtendEnum.Name).(*bool))
+	require.True(t, enum.Option(E_ExtendEnum.Name).(bool))
 	require.Contains(t, enum.ValueOptions(), E_ExtendEnumValue.Name)
 	require.NotEmpty(t, enum.ValuesWithOption(E_ExtendEnumValue.Name))
 
 	for _, value := range enum.Values {
 		if value.Name == "FUTURE" {
 			require.NotEmpty(t, value.Options)
-			require.True(t, *value.Option(E_ExtendEnumValue.Name).(*bool))
+			require.True(t, value.Option(E_ExtendEnumValue.Name).(bool))
 		}
 	}
 }
@@ -188,6 +300,474 @@ func TestFileExtensionProperties(t *testing.T) {
 	require.Equal(t, "com.example.BookingStatus", ext.ContainingFullType)
 }
 
+func TestCustomOptionDefinitions(t *testing.T) {
+	ext := findExtension("BookingStatus.country", bookingFile)
+	require.False(t, ext.IsOptionDefinition)
+	require.Equal(t, []*FileExtension{ext}, bookingFile.TypeExtensions())
+	require.Empty(t, bookingFile.CustomOptions())
+
+	tmpl := newTestTemplate(t, `
+		name: "options.proto"
+		package: "test"
+		message_type: { name: "Target" extension_range: { start: 100 end: 200 } }
+		extension: {
+			name: "sensitive" number: 50000 label: LABEL_OPTIONAL type: TYPE_BOOL extendee: ".google.protobuf.FieldOptions"
+			options: { retention: RETENTION_SOURCE targets: TARGET_TYPE_FIELD targets: TARGET_TYPE_ONEOF }
+		}
+		extension: { name: "owner" number: 50001 label: LABEL_OPTIONAL type: TYPE_STRING extendee: ".google.protobuf.ServiceOptions" }
+		extension: { name: "note" number: 100 label: LABEL_OPTIONAL type: TYPE_STRING extendee: ".test.Target" }
+	`)
+	file := tmpl.Files[0]
+	require.Len(t, file.Extensions, 3)
+
+	options := file.CustomOptions()
+	require.Len(t, options, 2)
+	require.Equal(t, "test.sensitive", options[0].OptionName)
+	require.Equal(t, "FieldOptions", options[0].ContainingType)
+	require.True(t, options[0].IsOptionDefinition)
+	require.Equal(t, "SOURCE", options[0].Retention)
+	require.Equal(t, []string{"FIELD", "ONEOF"}, options[0].Targets)
+	require.Equal(t, "test.owner", options[1].OptionName)
+	require.Equal(t, "ServiceOptions", options[1].ContainingType)
+	require.Empty(t, options[1].Retention)
+	require.Empty(t, options[1].Targets)
+
+	require.Len(t, file.TypeExtensions(), 1)
+	require.Equal(t, "test.Target.note", file.TypeExtensions()[0].FullName)
+	require.False(t, file.TypeExtensions()[0].IsOptionDefinition)
+	require.Empty(t, file.TypeExtensions()[0].OptionName)
+
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "### Custom Options")
+	require.Contains(t, string(output), "| (test.sensitive) | bool | FieldOptions | 50000 |  Retention: `SOURCE` Targets: `FIELD`, `ONEOF` |")
+}
+
+func TestFieldSignature(t *testing.T) {
+	vehicle := findMessage("Vehicle", vehicleFile)
+	require.Equal(t, "int32 id = 1", findField("id", vehicle).Signature())
+	require.Equal(t, "Vehicle.Category category = 5", findField("category", vehicle).Signature())
+	require.Equal(t, "repeated sint32 rates = 6", findField("rates", vehicle).Signature())
+	require.Equal(t, "map<string, string> properties = 7", findField("properties", vehicle).Signature())
+	require.Equal(t, "int32 kilometers = 8", findField("kilometers", vehicle).Signature())
+
+	booking := findMessage("Booking", bookingFile)
+	require.Equal(t, "optional string color_preference = 6", findField("color_preference", booking).Signature())
+
+	tmpl := newTestTemplate(t, `
+		name: "sig.proto"
+		package: "test"
+		message_type: {
+			name: "Pet"
+			field: { name: "name" number: 1 label: LABEL_REQUIRED type: TYPE_STRING }
+			field: { name: "cat" number: 2 label: LABEL_OPTIONAL type: TYPE_BOOL oneof_index: 0 }
+			oneof_decl: { name: "kind" }
+		}
+	`)
+	pet := findMessage("Pet", tmpl.Files[0])
+	require.Equal(t, "required string name = 1", findField("name", pet).Signature())
+	require.Equal(t, "bool cat = 2", findField("cat", pet).Signature())
+}
+
+func TestRenderedDefault(t *testing.T) {
+	tests := []struct {
+		typ, value, rendered string
+	}{
+		{"string", "", ""},
+		{"string", "china", `"china"`},
+		{"string", `say "hi"`, `"say \"hi\""`},
+		{"bytes", `\000\377a\n\x7f\\`, "0x00ff610a7f5c"},
+		{"bool", "false", "false"},
+		{"int32", "-12", "-12"},
+		{"double", "inf", "inf"},
+		{"StatusCode", "OK", "OK"},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.rendered, MessageField{Type: test.typ, DefaultValue: test.value}.RenderedDefault())
+	}
+
+	require.Equal(t, "false", findField("payment_received", findMessage("Booking", bookingFile)).RenderedDefault())
+	require.Equal(t, `"china"`, findExtension("BookingStatus.country", bookingFile).RenderedDefault())
+}
+
+func TestEnumDefaultValue(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "Book"
+			field: { name: "status" number: 1 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".test.Status" default_value: "ACTIVE" }
+			field: { name: "unset" number: 2 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".test.Status" }
+			field: { name: "imported" number: 3 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".other.Code" default_value: "OK" }
+		}
+		enum_type: {
+			name: "Status"
+			value: { name: "UNKNOWN" number: 0 }
+			value: { name: "ACTIVE" number: 3 }
+		}
+	`)
+
+	book := findMessage("Book", tmpl.Files[0])
+	require.Equal(t, "ACTIVE", findField("status", book).DefaultValue)
+	require.Equal(t, "ACTIVE (3)", findField("status", book).RenderedDefault())
+	require.Empty(t, findField("unset", book).RenderedDefault())
+	require.Equal(t, "OK", findField("imported", book).RenderedDefault())
+
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "Default: ACTIVE (3) |")
+}
+
+func TestBytesDefaultValue(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "Blob"
+			field: { name: "escaped" number: 1 label: LABEL_OPTIONAL type: TYPE_BYTES default_value: "\\000\\377ab\\\\" }
+			field: { name: "raw" number: 2 label: LABEL_OPTIONAL type: TYPE_BYTES default_value: "\xff\xfe" }
+			field: { name: "text" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING default_value: "ünï" }
+		}
+	`)
+
+	msg := findMessage("Blob", tmpl.Files[0])
+	require.Equal(t, `\x00\xffab\\`, findField("escaped", msg).DefaultValue)
+	require.Equal(t, "0x00ff61625c", findField("escaped", msg).RenderedDefault())
+	require.Equal(t, `\xff\xfe`, findField("raw", msg).DefaultValue)
+	require.Equal(t, "0xfffe", findField("raw", msg).RenderedDefault())
+	require.Equal(t, "ünï", findField("text", msg).DefaultValue)
+
+	output, err := RenderTemplate(RenderTypeJSON, tmpl, "")
+	require.NoError(t, err)
+	require.True(t, utf8.Valid(output))
+	require.Contains(t, string(output), `"defaultValue": "\\xff\\xfe"`)
+}
+
+func TestDisplayType(t *testing.T) {
+	vehicle := findMessage("Vehicle", vehicleFile)
+	field := findField("category", vehicle)
+	require.Equal(t, "Vehicle.Category", field.DisplayType("com.example"))
+	require.Equal(t, "com.example.Vehicle.Category", field.DisplayType("com.other"))
+	require.Equal(t, "com.example.Vehicle.Category", field.DisplayType(""))
+	require.Equal(t, "com.example.Vehicle.Category", field.DisplayType("com"))
+	require.Equal(t, "com.example.Vehicle.Category", field.DisplayType("com.ex"))
+
+	require.Equal(t, "int32", findField("id", vehicle).DisplayType("com.example"))
+	require.Equal(t, "string", findExtension("BookingStatus.country", bookingFile).DisplayType("com.other"))
+
+	// types of imported files (not documented) are known by their package as well
+	tmpl := newTestTemplate(t, `name: "lib.proto" package: "acme.lib" message_type: { name: "Book" }`, `
+		name: "api.proto"
+		package: "acme"
+		dependency: "lib.proto"
+		message_type: {
+			name: "Shelf"
+			field: { name: "book" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".acme.lib.Book" }
+			field: { name: "next" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".acme.Shelf" }
+		}
+	`)
+	shelf := findMessage("Shelf", tmpl.Files[0])
+	require.Equal(t, "acme.lib.Book", findField("book", shelf).DisplayType("acme"))
+	require.Equal(t, "Book", findField("book", shelf).DisplayType("acme.lib"))
+	require.Equal(t, "Shelf", findField("next", shelf).DisplayType("acme"))
+}
+
+func TestFieldBehaviors(t *testing.T) {
+	msg := findMessage("Vehicle", vehicleFile)
+	require.False(t, msg.HasFieldBehaviors())
+	require.Empty(t, findField("id", msg).FieldBehaviors)
+	require.False(t, findField("id", msg).BehaviorColumn())
+
+	fd := new(descriptor.FileDescriptorProto)
+	require.NoError(t, prototext.Unmarshal([]byte(`
+		name: "aip.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Book"
+			field: { name: "name" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING options: {} }
+			field: { name: "title" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING options: {} }
+			field: { name: "shelf" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING }
+		}
+	`), fd))
+
+	// google.api.field_behavior = 1052, unpacked for name and packed for title
+	var name, title []byte
+	name = protowire.AppendTag(name, 1052, protowire.VarintType)
+	name = protowire.AppendVarint(name, 8)
+	title = protowire.AppendTag(title, 1052, protowire.BytesType)
+	title = protowire.AppendBytes(title, []byte{2, 5})
+	fd.MessageType[0].Field[0].Options.ProtoReflect().SetUnknown(name)
+	fd.MessageType[0].Field[1].Options.ProtoReflect().SetUnknown(title)
+
+	tmpl := newTestTemplateFromFiles(fd)
+	msg = findMessage("Book", tmpl.Files[0])
+	require.True(t, msg.HasFieldBehaviors())
+	require.Equal(t, []string{"IDENTIFIER"}, findField("name", msg).FieldBehaviors)
+	require.Equal(t, []string{"REQUIRED", "IMMUTABLE"}, findField("title", msg).FieldBehaviors)
+	require.Empty(t, findField("shelf", msg).FieldBehaviors)
+	require.True(t, findField("shelf", msg).BehaviorColumn())
+
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "| Field | Type | Label | Behavior | Description |")
+	require.Contains(t, string(output), "| title | [string](#string) |  | `REQUIRED` `IMMUTABLE` |  |")
+	require.Contains(t, string(output), "| shelf | [string](#string) |  | |  |")
+}
+
+func TestPackedFields(t *testing.T) {
+	legacy := findMessage("Legacy", newTestTemplate(t, `
+		name: "packed2.proto"
+		package: "test"
+		message_type: {
+			name: "Legacy"
+			field: { name: "ids" number: 1 label: LABEL_REPEATED type: TYPE_INT32 }
+			field: { name: "scores" number: 2 label: LABEL_REPEATED type: TYPE_DOUBLE options: { packed: true } }
+		}
+	`).Files[0])
+	require.False(t, findField("ids", legacy).Packed)
+	require.False(t, findField("ids", legacy).PackedExplicit)
+	require.True(t, findField("scores", legacy).Packed)
+	require.True(t, findField("scores", legacy).PackedExplicit)
+
+	tmpl := newTestTemplate(t, `
+		name: "packed3.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Modern"
+			field: { name: "ids" number: 1 label: LABEL_REPEATED type: TYPE_INT32 }
+			field: { name: "flags" number: 2 label: LABEL_REPEATED type: TYPE_BOOL options: { packed: false } }
+			field: { name: "names" number: 3 label: LABEL_REPEATED type: TYPE_STRING }
+			field: { name: "id" number: 4 label: LABEL_OPTIONAL type: TYPE_INT32 }
+		}
+	`)
+	modern := findMessage("Modern", tmpl.Files[0])
+	require.True(t, findField("ids", modern).Packed)
+	require.False(t, findField("ids", modern).PackedExplicit)
+	require.False(t, findField("flags", modern).Packed)
+	require.True(t, findField("flags", modern).PackedExplicit)
+	require.False(t, findField("names", modern).Packed)
+	require.False(t, findField("id", modern).Packed)
+
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "| ids | [int32](#int32) | repeated packed |")
+	require.Contains(t, string(output), "| flags | [bool](#bool) | repeated unpacked |")
+
+	fd := new(descriptor.FileDescriptorProto)
+	require.NoError(t, prototext.Unmarshal([]byte(`
+		name: "packed_editions.proto"
+		package: "test"
+		syntax: "editions"
+		edition: "2023"
+		message_type: {
+			name: "Current"
+			field: { name: "ids" number: 1 label: LABEL_REPEATED type: TYPE_INT32 }
+			field: { name: "flags" number: 2 label: LABEL_REPEATED type: TYPE_BOOL options: {} }
+		}
+		message_type: {
+			name: "Expanded"
+			field: { name: "ids" number: 1 label: LABEL_REPEATED type: TYPE_INT32 }
+			options: {}
+		}
+	`), fd))
+
+	// features = 50 of the options, with repeated_field_encoding = 3 set to EXPANDED = 2
+	expanded := protowire.AppendBytes(protowire.AppendTag(nil, 50, protowire.BytesType), []byte{3 << 3, 2})
+	fd.MessageType[0].Field[1].Options.ProtoReflect().SetUnknown(expanded)
+	fd.MessageType[1].Options.ProtoReflect().SetUnknown(expanded)
+
+	tmpl = newTestTemplateFromFiles(fd)
+	current := findMessage("Current", tmpl.Files[0])
+	require.True(t, findField("ids", current).Packed)
+	require.False(t, findField("ids", current).PackedExplicit)
+	require.False(t, findField("flags", current).Packed)
+	require.True(t, findField("flags", current).PackedExplicit)
+	ids := findField("ids", findMessage("Expanded", tmpl.Files[0]))
+	require.False(t, ids.Packed)
+	require.False(t, ids.PackedExplicit)
+}
+
+func TestOneOfOptions(t *testing.T) {
+	fd := new(descriptor.FileDescriptorProto)
+	require.NoError(t, prototext.Unmarshal([]byte(`
+		name: "oneof.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Pet"
+			field: { name: "cat" number: 1 label: LABEL_OPTIONAL type: TYPE_BOOL oneof_index: 0 }
+			field: { name: "dog" number: 2 label: LABEL_OPTIONAL type: TYPE_BOOL oneof_index: 0 }
+			field: { name: "name" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 1 }
+			oneof_decl: { name: "kind" options: {} }
+			oneof_decl: { name: "label" }
+		}
+	`), fd))
+
+	// buf.validate.oneof = 1159 with required = 1
+	var constraints, opts []byte
+	constraints = protowire.AppendTag(constraints, 1, protowire.VarintType)
+	constraints = protowire.AppendVarint(constraints, 1)
+	opts = protowire.AppendTag(opts, 1159, protowire.BytesType)
+	opts = protowire.AppendBytes(opts, constraints)
+	fd.MessageType[0].OneofDecl[0].Options.ProtoReflect().SetUnknown(opts)
+
+	msg := findMessage("Pet", newTestTemplateFromFiles(fd).Files[0])
+	require.Len(t, msg.OneOfs, 2)
+	require.True(t, msg.OneOfs[0].IsRequired)
+	require.Equal(t, 2, msg.OneOfs[0].FieldCount())
+	require.False(t, msg.OneOfs[1].IsRequired)
+	require.Equal(t, 1, msg.OneOfs[1].FieldCount())
+	require.Empty(t, msg.OneOfs[1].Options)
+}
+
+func TestMessageAllFields(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Book"
+			field: { name: "title" number: 5 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "isbn" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 }
+			field: { name: "pages" number: 2 label: LABEL_OPTIONAL type: TYPE_INT32 }
+			field: { name: "issn" number: 4 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 }
+			field: { name: "subtitle" number: 6 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 1 proto3_optional: true }
+			field: { name: "author" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING }
+			oneof_decl: { name: "id" }
+			oneof_decl: { name: "_subtitle" }
+		}
+	`)
+
+	msg := findMessage("Book", tmpl.Files[0])
+	var names []string
+	for _, field := range msg.AllFields() {
+		names = append(names, field.Name)
+	}
+
+	// declared before, inside and after the oneof, whatever the numbers
+	require.Equal(t, []string{"title", "isbn", "pages", "issn", "subtitle", "author"}, names)
+	require.Len(t, msg.Fields, 4)
+	require.Len(t, msg.OneOfs[0].Fields, 2)
+}
+
+func TestMessageOneofsAcrossSyntaxes(t *testing.T) {
+	// real oneofs are documented as such whatever the syntax, unlike the synthetic oneofs of proto3 optional fields
+	for _, syntax := range []string{`syntax: "proto2"`, `syntax: "proto3"`, `syntax: "editions" edition: "2023"`} {
+		optional, synthetic := "", ""
+		if syntax == `syntax: "proto3"` {
+			optional, synthetic = `oneof_index: 1 proto3_optional: true`, `oneof_decl: { name: "_subtitle" }`
+		}
+		tmpl := newTestTemplate(t, fmt.Sprintf(`
+			name: "api.proto"
+			package: "test"
+			%s
+			message_type: {
+				name: "Book"
+				field: { name: "title" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+				field: { name: "isbn" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 }
+				field: { name: "issn" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 }
+				field: { name: "subtitle" number: 4 label: LABEL_OPTIONAL type: TYPE_STRING %s }
+				oneof_decl: { name: "id" }
+				%s
+			}
+		`, syntax, optional, synthetic))
+
+		msg := findMessage("Book", tmpl.Files[0])
+		var names []string
+		for _, field := range msg.Fields {
+			names = append(names, field.Name)
+		}
+		require.Equal(t, []string{"title", "subtitle"}, names, syntax)
+		require.Len(t, msg.OneOfs, 1, syntax)
+		require.Equal(t, "id", msg.OneOfs[0].Name, syntax)
+		require.Len(t, msg.OneOfs[0].Fields, 2, syntax)
+
+		subtitle := findField("subtitle", msg)
+		require.Equal(t, "optional", subtitle.Label, syntax)
+		require.True(t, subtitle.HasPresence, syntax)
+		require.Equal(t, "optional string subtitle = 4", subtitle.Signature(), syntax)
+		require.Equal(t, "string isbn = 2", findField("isbn", msg).Signature(), syntax)
+	}
+}
+
+func TestMessageFieldMapDoc(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Shelf"
+		}
+		message_type: {
+			name: "Library"
+			field: { name: "shelves" number: 1 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Library.ShelvesEntry" json_name: "shelves" }
+			field: { name: "counts" number: 2 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Library.CountsEntry" json_name: "counts" }
+			field: { name: "name" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING }
+			nested_type: {
+				name: "ShelvesEntry"
+				field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+				field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Shelf" }
+				options: { map_entry: true }
+			}
+			nested_type: {
+				name: "CountsEntry"
+				field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_INT64 }
+				field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_INT32 }
+				options: { map_entry: true }
+			}
+		}
+	`)
+
+	msg := findMessage("Library", tmpl.Files[0])
+	doc := findField("shelves", msg).MapDoc()
+	require.NotNil(t, doc)
+	require.Equal(t, "shelves", doc.Field)
+	require.Equal(t, "string", doc.KeyType)
+	require.Equal(t, "Shelf", doc.ValueType)
+	require.Equal(t, "test.Shelf", doc.ValueFullType)
+	require.NotNil(t, doc.ValueLink)
+	require.Equal(t, "test.Shelf", doc.ValueLink.FullName)
+
+	// scalar values aren't linked
+	doc = findField("counts", msg).MapDoc()
+	require.Equal(t, "int64", doc.KeyType)
+	require.Equal(t, "int32", doc.ValueType)
+	require.Nil(t, doc.ValueLink)
+
+	require.Nil(t, findField("name", msg).MapDoc())
+}
+
+func TestNestedOptionValues(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "nested.proto"
+		package: "test"
+		options: {
+			java_package: "com.test"
+			optimize_for: CODE_SIZE
+			uninterpreted_option: {
+				name: { name_part: "foo" is_extension: true }
+				name: { name_part: "bar" is_extension: false }
+				positive_int_value: 42
+			}
+		}
+	`)
+
+	file := tmpl.Files[0]
+	require.Equal(t, "com.test", file.Option("javaPackage"))
+	require.Equal(t, "CODE_SIZE", file.Option("optimizeFor"))
+	require.Equal(t, []interface{}{
+		map[string]interface{}{
+			"name": []interface{}{
+				map[string]interface{}{"namePart": "foo", "isExtension": true},
+				map[string]interface{}{"namePart": "bar", "isExtension": false},
+			},
+			"positiveIntValue": uint64(42),
+		},
+	}, file.Option("uninterpretedOption"))
+}
+
 func TestMessageProperties(t *testing.T) {
 	msg := findMessage("Vehicle", vehicleFile)
 	require.Equal(t, "Vehicle", msg.Name)
@@ -197,11 +777,1341 @@ func TestMessageProperties(t *testing.T) {
 	require.False(t, msg.HasExtensions)
 	require.True(t, msg.HasFields)
 	require.NotEmpty(t, msg.Options)
-	require.True(t, *msg.Option(E_ExtendMessage.Name).(*bool))
+	require.True(t, msg.Option(E_ExtendMessage.Name).(bool))
 	require.Contains(t, msg.FieldOptions(), E_ExtendField.Name)
 	require.NotEmpty(t, msg.FieldsWithOption(E_ExtendField.Name))
 }
 
+func TestTrailingComments(t *testing.T) {
+	proto := `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "User"
+			field: { name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32 }
+			field: { name: "name" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "email" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING }
+		}
+		enum_type: {
+			name: "Role"
+			value: { name: "ROLE_UNSPECIFIED" number: 0 }
+		}
+		source_code_info: {
+			location: { path: [4, 0, 2, 0] span: [1, 0, 1] leading_comments: " Leading.\n" trailing_comments: " user id\n" }
+			location: { path: [4, 0, 2, 1] span: [2, 0, 1] leading_comments: " The name.\n" }
+			location: { path: [4, 0, 2, 2] span: [3, 0, 1] leading_comments: "@exclude\n" trailing_comments: " email\n" }
+			location: { path: [5, 0, 2, 0] span: [4, 0, 1] leading_comments: " Leading.\n" trailing_comments: " unknown role\n" }
+		}
+	`
+
+	tmpl := newTestTemplate(t, proto)
+	msg := findMessage("User", tmpl.Files[0])
+	require.Equal(t, "Leading.\n\nuser id", findField("id", msg).Description)
+	require.Equal(t, "Leading.\n\nunknown role", findEnum("Role", tmpl.Files[0]).Values[0].Description)
+
+	fd := new(descriptor.FileDescriptorProto)
+	require.NoError(t, prototext.Unmarshal([]byte(proto), fd))
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.ProtoFile = []*descriptor.FileDescriptorProto{fd}
+	req.FileToGenerate = []string{"api.proto"}
+
+	tmpl = NewTemplate(protokit.ParseCodeGenRequest(req), WithTrailingComments(true))
+	msg = findMessage("User", tmpl.Files[0])
+	require.Equal(t, "user id", findField("id", msg).Description)
+	require.Equal(t, "The name.", findField("name", msg).Description)
+	require.Empty(t, findField("email", msg).Description)
+	require.Equal(t, "unknown role", findEnum("Role", tmpl.Files[0]).Values[0].Description)
+}
+
+func TestTemplateMutators(t *testing.T) {
+	var calls []string
+	rename := func(tmpl *Template) {
+		calls = append(calls, "rename")
+		msg := findMessage("Book", tmpl.Files[0])
+		msg.Name, msg.LongName, msg.FullName = "Volume", "Volume", "test.Volume"
+		tmpl.Files[0].Services[0].Methods[0].RequestFullType = "test.Volume"
+	}
+	annotate := func(tmpl *Template) {
+		calls = append(calls, "annotate")
+		tmpl.Files[0].Description = "Annotated."
+	}
+
+	tmpl := newTestTemplateWithOptions(t, []TemplateOption{WithMutators(rename), WithMutators(annotate)}, `
+		name: "api.proto"
+		package: "test"
+		message_type: { name: "Book" }
+		service: {
+			name: "Library"
+			method: { name: "Get" input_type: ".test.Book" output_type: ".test.Book" }
+		}
+	`)
+
+	require.Equal(t, []string{"rename", "annotate"}, calls)
+	require.Equal(t, "Annotated.", tmpl.Files[0].Description)
+
+	method := tmpl.Files[0].Services[0].Methods[0]
+	require.Equal(t, &Link{Package: "test", FullName: "test.Volume", Anchor: "test-Volume"}, method.RequestLink)
+	require.Equal(t, "test.Volume", method.RequestMessage.FullName)
+	require.Equal(t, &Link{FullName: "test.Book", External: true}, method.ResponseLink)
+
+	output, err := RenderTemplate(RenderTypeHTML, tmpl, `{{typeRef "test.Volume" "Volume"}}`)
+	require.NoError(t, err)
+	require.Equal(t, `<a href="#test-Volume">Volume</a>`, string(output))
+}
+
+func TestByCategory(t *testing.T) {
+	proto := `
+		name: "api.proto"
+		package: "test"
+		dependency: "docs.proto"
+		message_type: {
+			name: "Invoice"
+			field: { name: "lines" number: 1 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Invoice.LinesEntry" }
+			nested_type: {
+				name: "LinesEntry"
+				field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+				field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING }
+				options: { map_entry: true }
+			}
+			options: { [docs.category]: "Billing" }
+		}
+		message_type: { name: "User" options: { [docs.category]: "Identity" } }
+		message_type: { name: "Payment" options: { [docs.billing]: "Billing" } }
+		enum_type: { name: "Currency" value: { name: "EUR" number: 0 } options: { [docs.enum_category]: "Billing" } }
+		service: { name: "Billing" options: { [docs.service_category]: "Billing" } }
+	`
+	docs := docsProto(
+		"MessageOptions string category",
+		"MessageOptions string billing",
+		"EnumOptions string enum_category",
+		"ServiceOptions string service_category",
+	)
+
+	tmpl := newTestTemplate(t, docs, proto)
+	file := tmpl.Files[0]
+	require.Equal(t, "Billing", findMessage("Invoice", file).Category())
+	require.Equal(t, "Identity", findMessage("User", file).Category())
+	require.Empty(t, findMessage("Payment", file).Category())
+	require.Empty(t, findMessage("Invoice.LinesEntry", file).Category())
+	require.Empty(t, findEnum("Currency", file).Category())
+
+	groups := tmpl.ByCategory()
+	require.Len(t, groups, 3)
+	require.Equal(t, []interface{}{findMessage("Invoice", file)}, groups["Billing"])
+	require.Equal(t, []interface{}{findMessage("User", file)}, groups["Identity"])
+	require.Equal(t, []interface{}{findMessage("Payment", file), findEnum("Currency", file), findService("Billing", file)},
+		groups[Uncategorized])
+
+	// an extension extends the options of a single kind of entities, so enums and services are categorized by options
+	// of their own
+	for _, option := range []string{"docs.billing", "docs.enum_category", "docs.service_category"} {
+		tmpl = newTestTemplateWithOptions(t, []TemplateOption{WithOptionName(CategoryOption, option)}, docs, proto)
+		require.Len(t, tmpl.ByCategory()["Billing"], 1, option)
+	}
+	file = tmpl.Files[0]
+	require.Empty(t, findMessage("User", file).Category())
+	require.Equal(t, "Billing", findService("Billing", file).Category())
+}
+
+func TestOrderOption(t *testing.T) {
+	docs := docsProto("MessageOptions int32 order", "FieldOptions int32 field_order", "EnumOptions int64 enum_order",
+		"ServiceOptions uint32 service_order")
+	proto := `
+		name: "api.proto"
+		package: "test"
+		dependency: "docs.proto"
+		message_type: {
+			name: "Book"
+			field: { name: "title" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "author" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "isbn" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING options: { [docs.field_order]: 1 } }
+			options: { [docs.order]: 2 }
+		}
+		message_type: { name: "Author" }
+		message_type: { name: "Shelf" options: { [docs.order]: 2 } }
+		message_type: { name: "Library" options: { [docs.order]: 1 } }
+		enum_type: { name: "Format" value: { name: "PAPERBACK" number: 0 } }
+		enum_type: { name: "Genre" value: { name: "FICTION" number: 0 } options: { [docs.enum_order]: 1 } }
+		service: { name: "Search" options: { [docs.service_order]: 1 } }
+		service: { name: "Lending" }
+	`
+	messageNames := func(file *File) []string {
+		var names []string
+		for _, msg := range file.Messages {
+			names = append(names, msg.Name)
+		}
+		return names
+	}
+
+	tmpl := newTestTemplate(t, docs, proto)
+	file := tmpl.Files[0]
+	require.Equal(t, []string{"Library", "Book", "Shelf", "Author"}, messageNames(file))
+	require.Equal(t, "Library", tmpl.Packages[0].Messages[0].Name)
+	require.Equal(t, "Format", file.Enums[0].Name)
+	require.Equal(t, "title", findMessage("Book", file).Fields[0].Name)
+
+	// an extension extends the options of a single kind of entities, so the others are ordered by options of their own
+	tmpl = newTestTemplateWithOptions(t, []TemplateOption{WithOptionName(OrderOption, "docs.field_order")}, docs, proto)
+	file = tmpl.Files[0]
+	require.Equal(t, []string{"Author", "Book", "Library", "Shelf"}, messageNames(file))
+	book := findMessage("Book", file)
+	require.Equal(t, "isbn", book.Fields[0].Name)
+	require.Equal(t, "title", book.Fields[1].Name)
+
+	tmpl = newTestTemplateWithOptions(t, []TemplateOption{WithOptionName(OrderOption, "docs.enum_order")}, docs, proto)
+	require.Equal(t, "Genre", tmpl.Files[0].Enums[0].Name)
+
+	tmpl = newTestTemplateWithOptions(t, []TemplateOption{WithOptionName(OrderOption, "docs.service_order")}, docs, proto)
+	require.Equal(t, "Search", tmpl.Files[0].Services[0].Name)
+}
+
+func TestReplacedByOption(t *testing.T) {
+	docs := docsProto("MessageOptions string replaced_by", "FieldOptions string field_replaced_by",
+		"EnumValueOptions string value_replaced_by", "MethodOptions string method_replaced_by")
+	proto := `
+		name: "api.proto"
+		package: "test"
+		dependency: "docs.proto"
+		message_type: {
+			name: "OldBook"
+			field: {
+				name: "name" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING
+				options: { deprecated: true [docs.field_replaced_by]: ".test.Book.title" }
+			}
+			field: {
+				name: "author" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING
+				options: { deprecated: true [docs.field_replaced_by]: "Author" }
+			}
+			options: { deprecated: true [docs.replaced_by]: "test.Book" }
+		}
+		message_type: {
+			name: "Book"
+			field: { name: "title" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+		}
+		enum_type: {
+			name: "Genre"
+			value: { name: "NOVEL" number: 0 options: { deprecated: true [docs.value_replaced_by]: "Genre" } }
+			value: { name: "FICTION" number: 1 }
+		}
+		service: {
+			name: "Library"
+			method: {
+				name: "GetOldBook" input_type: ".test.OldBook" output_type: ".test.OldBook"
+				options: { deprecated: true [docs.method_replaced_by]: "Library" }
+			}
+			method: { name: "GetBook" input_type: ".test.Book" output_type: ".test.Book" }
+		}
+	`
+	tmpl := newTestTemplate(t, docs, proto)
+	file := tmpl.Files[0]
+	old := findMessage("OldBook", file)
+	require.Equal(t, &Link{Package: "test", FullName: "test.Book", Anchor: "test-Book"}, old.ReplacedBy)
+	require.Nil(t, findMessage("Book", file).ReplacedBy)
+
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "\n> **Deprecated** — use [test.Book](#test-Book) instead.\n")
+
+	output, err = RenderTemplate(RenderTypeHTML, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `<p class="replaced-by"><strong>Deprecated</strong> — use <a href="#test-Book">test.Book</a> instead.</p>`)
+
+	// an extension extends the options of a single kind of entities, so the others name successors with options of
+	// their own
+	tmpl = newTestTemplateWithOptions(t, []TemplateOption{WithOptionName(ReplacedByOption, "docs.field_replaced_by")}, docs, proto)
+	old = findMessage("OldBook", tmpl.Files[0])
+	require.Nil(t, old.ReplacedBy)
+	require.Equal(t, &Link{Package: "test", FullName: "test.Book.title"}, findField("name", old).ReplacedBy)
+	require.Equal(t, &Link{FullName: "Author"}, findField("author", old).ReplacedBy)
+
+	output, err = RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "| name | [string](#string) | optional | **Deprecated** — use test.Book.title instead.  |")
+
+	tmpl = newTestTemplateWithOptions(t, []TemplateOption{WithOptionName(ReplacedByOption, "docs.value_replaced_by")}, docs, proto)
+	genre := findEnum("Genre", tmpl.Files[0])
+	require.Equal(t, &Link{Package: "test", FullName: "test.Genre", Anchor: "test-Genre"}, genre.Values[0].ReplacedBy)
+	require.Nil(t, genre.Values[1].ReplacedBy)
+
+	output, err = RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "| NOVEL | 0 | **Deprecated** — use [test.Genre](#test-Genre) instead.  |")
+
+	tmpl = newTestTemplateWithOptions(t, []TemplateOption{WithOptionName(ReplacedByOption, "docs.method_replaced_by")}, docs, proto)
+	library := findService("Library", tmpl.Files[0])
+	require.Equal(t, &Link{FullName: "Library"}, findServiceMethod("GetOldBook", library).ReplacedBy)
+	require.Nil(t, findServiceMethod("GetBook", library).ReplacedBy)
+
+	tmpl = newTestTemplateWithOptions(t, []TemplateOption{WithOptionName(ReplacedByOption, "acme.successor")}, docs, proto)
+	require.Nil(t, findMessage("OldBook", tmpl.Files[0]).ReplacedBy)
+}
+
+func TestFileFrontMatter(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		source_code_info: {
+			location: {
+				path: [12]
+				span: [1, 0, 18]
+				leading_comments: " ---\n title: \"Library: Books\"\n weight: 2\n tags:\n   - books\n   - v1\n ---\n\n The library API.\n"
+			}
+		}
+	`)
+
+	file := tmpl.Files[0]
+	require.Equal(t, "The library API.", file.Description)
+	require.Equal(t, map[string]string{"title": "Library: Books", "weight": "2", "tags": "[books, v1]"}, file.Meta)
+	require.Equal(t, "weight: 2\ntags:\n    - books\n    - v1\n", file.FrontMatter("title"))
+	require.Empty(t, file.FrontMatter("title", "weight", "tags"))
+
+	output, err := RenderTemplate(RenderTypeMDX, tmpl, "")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(output),
+		"---\nslug: /test\ntitle: \"Library: Books\"\nweight: 2\ntags:\n    - books\n    - v1\n---\n"))
+
+	for _, comment := range []string{
+		"---\ntitle: [unclosed\n---\nThe library API.\n",
+		"---\n- a list\n---\nThe library API.\n",
+		"---\ntitle: Library\nThe library API.\n",
+		"The library API.\n---\ntitle: Library\n---\n",
+	} {
+		tmpl = newTestTemplate(t, fmt.Sprintf(`
+			name: "api.proto"
+			package: "test"
+			source_code_info: { location: { path: [12] span: [1, 0, 18] leading_comments: %q } }
+		`, comment))
+		require.Nil(t, tmpl.Files[0].Meta, comment)
+		require.Equal(t, strings.TrimSpace(comment), tmpl.Files[0].Description, comment)
+		require.Empty(t, tmpl.Files[0].FrontMatter())
+	}
+}
+
+func TestPackageOverviewDescriptions(t *testing.T) {
+	pkg := Package{Name: "test", Descriptions: []*PackageDesc{
+		{File: "a.proto", Description: "Copyright Acme."},
+		{File: "b.proto", Description: "The library API."},
+		{File: "c.proto", Description: "Copyright Acme."},
+	}}
+
+	require.Equal(t, []*PackageDesc{pkg.Descriptions[0], pkg.Descriptions[1]}, pkg.Overview())
+	require.Empty(t, Package{Name: "empty"}.Overview())
+}
+
+func TestTemplateGlossary(t *testing.T) {
+	files := make([]*descriptor.FileDescriptorProto, 0, 2)
+	for _, text := range []string{
+		`name: "store.proto" package: "store" message_type: { name: "author" }`,
+		`name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Book"
+			field: { name: "tags" number: 1 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Book.TagsEntry" }
+			nested_type: {
+				name: "TagsEntry"
+				field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+				field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING }
+				options: { map_entry: true }
+			}
+			nested_type: { name: "Author" }
+		}
+		enum_type: { name: "Genre" value: { name: "GENRE_UNSPECIFIED" number: 0 } }
+		service: { name: "Library" }
+		source_code_info: {
+			location: { path: [4, 0] span: [1, 0, 18] leading_comments: " A book of the\n library. Books have authors.\n" }
+			location: { path: [5, 0] span: [2, 0, 18] leading_comments: " The genre of a book\n" }
+		}`,
+	} {
+		fd := new(descriptor.FileDescriptorProto)
+		require.NoError(t, prototext.Unmarshal([]byte(text), fd))
+		files = append(files, fd)
+	}
+
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.ProtoFile = files
+	req.FileToGenerate = []string{"store.proto", "api.proto"}
+	tmpl := NewTemplate(protokit.ParseCodeGenRequest(req), WithPages("index.html", nil))
+
+	require.Equal(t, []*GlossaryEntry{
+		{Name: "author", FullName: "store.author", Kind: NavKindMessage, Anchor: "store-author", URL: "index.html#store-author"},
+		{Name: "Author", FullName: "test.Book.Author", Kind: NavKindMessage, Anchor: "test-Book-Author", URL: "index.html#test-Book-Author"},
+		{
+			Name:     "Book",
+			FullName: "test.Book",
+			Kind:     NavKindMessage,
+			Summary:  "A book of the library.",
+			Anchor:   "test-Book",
+			URL:      "index.html#test-Book",
+		},
+		{
+			Name:     "Genre",
+			FullName: "test.Genre",
+			Kind:     NavKindEnum,
+			Summary:  "The genre of a book",
+			Anchor:   "test-Genre",
+			URL:      "index.html#test-Genre",
+		},
+		{Name: "Library", FullName: "test.Library", Kind: NavKindService, Anchor: "test-Library", URL: "index.html#test-Library"},
+	}, tmpl.Glossary())
+}
+
+func TestTemplateNavigation(t *testing.T) {
+	tmpl := newTestTemplateWithOptions(t, []TemplateOption{WithInlineNested(true), WithPages("test/index.html", nil)}, `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Book"
+			field: { name: "price" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Book.Price" }
+			field: { name: "tags" number: 2 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Book.TagsEntry" }
+			nested_type: {
+				name: "Price"
+				field: { name: "cents" number: 1 label: LABEL_OPTIONAL type: TYPE_INT64 }
+			}
+			nested_type: {
+				name: "TagsEntry"
+				field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+				field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING }
+				options: { map_entry: true }
+			}
+		}
+		enum_type: { name: "Genre" value: { name: "GENRE_UNSPECIFIED" number: 0 } }
+		service: {
+			name: "Library"
+			method: { name: "GetBook" input_type: ".test.Book" output_type: ".test.Book" }
+		}
+		source_code_info: {
+			location: { path: [12] span: [1, 0, 18] leading_comments: " ---\n title: Library\n ---\n" }
+		}
+	`)
+
+	require.Equal(t, &NavNode{
+		Title: "Protocol Documentation",
+		Kind:  NavKindRoot,
+		Children: []*NavNode{{
+			Title: "test",
+			Kind:  NavKindPackage,
+			Children: []*NavNode{{
+				Title:  "Library",
+				Kind:   NavKindFile,
+				Anchor: "api-proto",
+				URL:    "test/index.html#api-proto",
+				Children: []*NavNode{
+					{Title: "Book", Kind: NavKindMessage, Anchor: "test-Book", URL: "test/index.html#test-Book"},
+					{Title: "Genre", Kind: NavKindEnum, Anchor: "test-Genre", URL: "test/index.html#test-Genre"},
+					{Title: "Library", Kind: NavKindService, Anchor: "test-Library", URL: "test/index.html#test-Library"},
+				},
+			}},
+		}},
+	}, tmpl.Navigation())
+
+	output, err := RenderTemplate(RenderTypeNavigation, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `"url": "#test-Book"`)
+}
+
+func TestTemplateGraph(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto2"
+		message_type: {
+			name: "Book"
+			field: { name: "genre" number: 1 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".test.Genre" }
+			field: { name: "title" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "related" number: 3 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Book" }
+			field: { name: "shelves" number: 4 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Book.ShelvesEntry" }
+			field: { name: "isbn" number: 5 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 }
+			field: { name: "published" number: 6 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Timestamp" oneof_index: 0 }
+			nested_type: {
+				name: "ShelvesEntry"
+				field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+				field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Shelf" }
+				options: { map_entry: true }
+			}
+			oneof_decl: { name: "id" }
+			extension_range: { start: 100 end: 200 }
+		}
+		message_type: { name: "Shelf" }
+		enum_type: { name: "Genre" value: { name: "GENRE_UNSPECIFIED" number: 0 } }
+		service: {
+			name: "Library"
+			method: { name: "GetBook" input_type: ".test.Shelf" output_type: ".test.Book" }
+		}
+		extension: { name: "rating" number: 100 label: LABEL_OPTIONAL type: TYPE_INT32 extendee: ".test.Book" }
+	`)
+
+	require.Equal(t, &Graph{
+		Nodes: []*GraphNode{
+			{ID: "test.Book", Kind: GraphNodeMessage, File: "api.proto"},
+			{ID: "test.Shelf", Kind: GraphNodeMessage, File: "api.proto"},
+			{ID: "test.Genre", Kind: GraphNodeEnum, File: "api.proto"},
+			{ID: "test.Library", Kind: GraphNodeService, File: "api.proto"},
+			{ID: "test.Book.rating", Kind: GraphNodeExtension, File: "api.proto"},
+			{ID: "google.protobuf.Timestamp", Kind: GraphNodeMessage, External: true},
+		},
+		Edges: []*GraphEdge{
+			{From: "test.Book", To: "test.Genre", Kind: GraphEdgeField, Label: "genre"},
+			{From: "test.Book", To: "test.Book", Kind: GraphEdgeField, Label: "related"},
+			{From: "test.Book", To: "test.Shelf", Kind: GraphEdgeMapValue, Label: "shelves"},
+			{From: "test.Book", To: "google.protobuf.Timestamp", Kind: GraphEdgeField, Label: "published"},
+			{From: "test.Library", To: "test.Shelf", Kind: GraphEdgeRequest, Label: "GetBook"},
+			{From: "test.Library", To: "test.Book", Kind: GraphEdgeResponse, Label: "GetBook"},
+			{From: "test.Book.rating", To: "test.Book", Kind: GraphEdgeExtends, Label: "rating"},
+		},
+	}, tmpl.Graph())
+
+	output, err := RenderTemplate(RenderTypeGraph, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `"kind": "map_value"`)
+}
+
+func TestTemplateUnusedMessages(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto2"
+		message_type: {
+			name: "GetBookRequest"
+			field: { name: "filter" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Filter" }
+		}
+		message_type: {
+			name: "Filter"
+			field: { name: "range" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Range" }
+		}
+		message_type: { name: "Range" }
+		message_type: {
+			name: "Book"
+			field: { name: "shelves" number: 1 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Book.ShelvesEntry" }
+			nested_type: {
+				name: "ShelvesEntry"
+				field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+				field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Shelf" }
+				options: { map_entry: true }
+			}
+			extension_range: { start: 100 end: 200 }
+		}
+		message_type: { name: "Shelf" }
+		message_type: {
+			name: "Legacy"
+			field: { name: "parent" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Legacy" }
+			field: { name: "note" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Note" }
+		}
+		message_type: { name: "Note" }
+		message_type: { name: "Extended" extension_range: { start: 100 end: 200 } }
+		service: {
+			name: "Library"
+			method: { name: "GetBook" input_type: ".test.GetBookRequest" output_type: ".test.Book" }
+		}
+		extension: { name: "rating" number: 100 label: LABEL_OPTIONAL type: TYPE_INT32 extendee: ".test.Extended" }
+	`)
+
+	var names []string
+	for _, msg := range tmpl.UnusedMessages() {
+		names = append(names, msg.FullName)
+	}
+
+	// Filter and Range are only used transitively through the request, Note by an unused message, and Legacy by itself
+	require.Equal(t, []string{"test.Legacy"}, names)
+}
+
+func TestTemplateSlugs(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "test.proto"
+		package: "test"
+		message_type: { name: "proto" }
+		message_type: {
+			name: "Book"
+			field: { name: "kind" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.proto" }
+		}
+	`)
+
+	file := tmpl.Files[0]
+	require.Equal(t, "test-proto", findMessage("proto", file).Anchor)
+	require.Equal(t, "test-proto-file", file.Anchor)
+	require.Equal(t, "test-proto-file", tmpl.Slug("file", "test.proto"))
+	require.Equal(t, "test-Missing", tmpl.Slug("message", "test.Missing"))
+
+	// every output format agrees on the anchors
+	output, err := RenderTemplate(RenderTypeJSON, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `"anchor": "test-proto-file"`)
+	require.Contains(t, string(output), `"anchor": "test-proto"`)
+
+	output, err = RenderTemplate(RenderTypeHTML, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `<h2 id="test-proto-file">test.proto</h2>`)
+	require.Contains(t, string(output), `<h3 id="test-proto" class="pgd-message">`)
+	require.Contains(t, string(output), `<td><a href="#test-proto">proto</a></td>`)
+
+	output, err = RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `<a name="test-proto-file"></a>`)
+	require.Contains(t, string(output), `<a name="test-proto"></a>`)
+	require.Contains(t, string(output), "| kind | [proto](#test-proto) |")
+}
+
+func TestTypeLanguage(t *testing.T) {
+	proto := `
+		name: "acme/vehicle_service.proto"
+		package: "acme"
+		message_type: {
+			name: "Vehicle"
+			field: { name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_UINT64 }
+			field: { name: "tags" number: 2 label: LABEL_REPEATED type: TYPE_STRING }
+			field: { name: "scores" number: 3 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".acme.Vehicle.ScoresEntry" }
+			field: { name: "category" number: 4 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".acme.Vehicle.Category" }
+			field: { name: "created" number: 5 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Timestamp" }
+			nested_type: {
+				name: "ScoresEntry"
+				field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+				field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_INT32 }
+				options: { map_entry: true }
+			}
+			enum_type: { name: "Category" value: { name: "CAR" number: 0 } }
+		}
+		service: { name: "VehicleService" }
+	`
+
+	msg := findMessage("Vehicle", newTestTemplate(t, proto).Files[0])
+	require.Empty(t, findField("id", msg).LanguageType)
+
+	tmpl := newTestTemplateWithOptions(t, []TemplateOption{WithTypeLanguage(TypeLanguageJava)}, proto)
+	require.Equal(t, TypeLanguageJava, tmpl.TypeLanguage())
+	msg = findMessage("Vehicle", tmpl.Files[0])
+	require.Equal(t, "long", findField("id", msg).LanguageType)
+	require.Equal(t, "List<String>", findField("tags", msg).LanguageType)
+	require.Equal(t, "Map<String, Integer>", findField("scores", msg).LanguageType)
+	// the outer class is named after the file, unless a top-level type has the same name
+	require.Equal(t, "VehicleServiceOuterClass.Vehicle.Category", findField("category", msg).LanguageType)
+	require.Equal(t, "Timestamp", findField("created", msg).LanguageType)
+
+	tmpl = newTestTemplateWithOptions(t, []TemplateOption{WithTypeLanguage(TypeLanguageCSharp)}, proto)
+	msg = findMessage("Vehicle", tmpl.Files[0])
+	require.Equal(t, "ulong", findField("id", msg).LanguageType)
+	require.Equal(t, "RepeatedField<string>", findField("tags", msg).LanguageType)
+	require.Equal(t, "MapField<string, int>", findField("scores", msg).LanguageType)
+	require.Equal(t, "Vehicle.Types.Category", findField("category", msg).LanguageType)
+	require.Equal(t, "Timestamp", findField("created", msg).LanguageType)
+
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "| id | [uint64](#uint64) `ulong` |")
+}
+
+func TestJavaOuterClass(t *testing.T) {
+	tests := map[string]struct{ file, options, expected string }{
+		"file name":        {"acme/v1/fleet-api2_v1.proto", "", "FleetApi2V1.Vehicle"},
+		"outer class name": {"acme/v1/vehicle.proto", `options: { java_outer_classname: "VehicleProto" }`, "VehicleProto.Vehicle"},
+		"multiple files":   {"acme/v1/vehicle.proto", `options: { java_multiple_files: true }`, "Vehicle"},
+		"conflict":         {"acme/v1/vehicle.proto", "", "VehicleOuterClass.Vehicle"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tmpl := newTestTemplateWithOptions(t, []TemplateOption{WithTypeLanguage(TypeLanguageJava)}, fmt.Sprintf(`
+				name: "%s"
+				package: "acme.v1"
+				%s
+				message_type: {
+					name: "Vehicle"
+					field: { name: "parent" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".acme.v1.Vehicle" }
+				}
+			`, test.file, test.options))
+
+			msg := findMessage("Vehicle", tmpl.Files[0])
+			require.Equal(t, test.expected, findField("parent", msg).LanguageType)
+		})
+	}
+}
+
+func TestSeeAlso(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "Book"
+			field: { name: "shelf_id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32 }
+		}
+		message_type: { name: "Shelf" }
+		enum_type: { name: "Genre" value: { name: "FICTION" number: 0 } }
+		service: {
+			name: "Library"
+			method: { name: "GetBook" input_type: ".test.Book" output_type: ".test.Book" }
+		}
+		source_code_info: {
+			location: { path: [4, 0] span: [1, 0, 1] leading_comments: " A book.\n @see test.Shelf\n @see .google.protobuf.Any\n" }
+			location: { path: [4, 0, 2, 0] span: [2, 0, 1] leading_comments: " The shelf.\n @see test.Shelf\n" }
+			location: { path: [5, 0] span: [3, 0, 1] leading_comments: " @see test.Book\n A genre.\n" }
+			location: { path: [6, 0, 2, 0] span: [4, 0, 1] leading_comments: " Gets a book.\n @see test.Missing\n" }
+		}
+	`)
+
+	file := tmpl.Files[0]
+	book := findMessage("Book", file)
+	require.Equal(t, "A book.", book.Description)
+	require.Len(t, book.SeeAlso, 2)
+	require.Equal(t, &Link{Package: "test", FullName: "test.Shelf", Anchor: "test-Shelf"}, book.SeeAlso[0])
+	require.True(t, book.SeeAlso[1].External)
+
+	field := findField("shelf_id", book)
+	require.Equal(t, "The shelf.", field.Description)
+	require.Equal(t, []*Link{{Package: "test", FullName: "test.Shelf", Anchor: "test-Shelf"}}, field.SeeAlso)
+
+	genre := findEnum("Genre", file)
+	require.Equal(t, "A genre.", genre.Description)
+	require.Equal(t, []*Link{{Package: "test", FullName: "test.Book", Anchor: "test-Book"}}, genre.SeeAlso)
+
+	method := findServiceMethod("GetBook", findService("Library", file))
+	require.Equal(t, "Gets a book.", method.Description)
+	require.Equal(t, []*Link{{FullName: "test.Missing"}}, method.SeeAlso)
+	require.Empty(t, findMessage("Shelf", file).SeeAlso)
+
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "A book.\n\nSee also: [test.Shelf](#test-Shelf), "+
+		"[google.protobuf.Any](https://protobuf.dev/reference/protobuf/google.protobuf/#any)\n")
+	require.Contains(t, string(output), "| The shelf. See also: [test.Shelf](#test-Shelf) |")
+	require.Contains(t, string(output), "| Gets a book. See also: test.Missing |")
+
+	output, err = RenderTemplate(RenderTypeHTML, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `<p class="see-also">See also: <a href="#test-Book">test.Book</a></p>`)
+}
+
+func TestFieldPresence(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "v3.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Book"
+			field: { name: "title" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "subtitle" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 proto3_optional: true }
+			field: { name: "shelf" number: 3 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Book" }
+			field: { name: "tags" number: 4 label: LABEL_REPEATED type: TYPE_STRING }
+			field: { name: "isbn" number: 5 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 1 }
+			oneof_decl: { name: "_subtitle" }
+			oneof_decl: { name: "code" }
+		}
+	`)
+
+	book := findMessage("Book", tmpl.Files[0])
+	for name, presence := range map[string]FieldPresence{
+		"title":    PresenceImplicit,
+		"subtitle": PresenceExplicit,
+		"shelf":    PresenceExplicit,
+		"tags":     PresenceImplicit,
+		"isbn":     PresenceExplicit,
+	} {
+		field := findField(name, book)
+		require.Equal(t, presence, field.Presence, name)
+		require.Equal(t, presence != PresenceImplicit, field.HasPresence, name)
+	}
+
+	output, err := RenderTemplate(RenderTypeJSON, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `"presence": "explicit",
+              "hasPresence": true,`)
+	require.Contains(t, string(output), `"presence": "implicit",
+              "hasPresence": false,`)
+
+	tmpl = newTestTemplate(t, `
+		name: "v2.proto"
+		package: "test"
+		syntax: "proto2"
+		message_type: {
+			name: "Book"
+			field: { name: "title" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "id" number: 2 label: LABEL_REQUIRED type: TYPE_INT32 }
+		}
+	`)
+
+	book = findMessage("Book", tmpl.Files[0])
+	require.Equal(t, PresenceExplicit, findField("title", book).Presence)
+	require.Equal(t, PresenceRequired, findField("id", book).Presence)
+	require.True(t, findField("id", book).HasPresence)
+}
+
+func TestMessageReferencedEnums(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "Book"
+			field: { name: "genre" number: 1 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".test.Genre" }
+			field: { name: "format" number: 2 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".test.Book.Format" oneof_index: 0 }
+			field: { name: "ratings" number: 3 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Book.RatingsEntry" }
+			field: { name: "genres" number: 4 label: LABEL_REPEATED type: TYPE_ENUM type_name: ".test.Genre" }
+			field: { name: "null" number: 5 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".google.protobuf.NullValue" }
+			nested_type: {
+				name: "RatingsEntry"
+				field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+				field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".test.Rating" }
+				options: { map_entry: true }
+			}
+			enum_type: { name: "Format" value: { name: "PAPERBACK" number: 0 } }
+			oneof_decl: { name: "edition" }
+		}
+		message_type: { name: "Shelf" }
+		enum_type: { name: "Genre" value: { name: "FICTION" number: 0 } }
+		enum_type: { name: "Rating" value: { name: "GOOD" number: 0 } }
+	`)
+
+	file := tmpl.Files[0]
+	var names []string
+	for _, enum := range findMessage("Book", file).ReferencedEnums() {
+		names = append(names, enum.FullName)
+	}
+	require.Equal(t, []string{"test.Book.Format", "test.Genre", "test.Rating"}, names)
+	require.Empty(t, findMessage("Shelf", file).ReferencedEnums())
+}
+
+func TestMessageMetrics(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "Order"
+			field: { name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "items" number: 2 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Item" }
+			field: { name: "coupon" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 }
+			field: { name: "gift_card" number: 4 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 }
+			oneof_decl: { name: "discount" }
+		}
+		message_type: {
+			name: "Item"
+			field: { name: "prices" number: 1 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Item.PricesEntry" }
+			nested_type: {
+				name: "PricesEntry"
+				field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+				field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Price" }
+				options: { map_entry: true }
+			}
+		}
+		message_type: { name: "Price" field: { name: "amount" number: 1 label: LABEL_OPTIONAL type: TYPE_INT64 } }
+		message_type: {
+			name: "Node"
+			field: { name: "children" number: 1 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Node" }
+			field: { name: "link" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Link" }
+		}
+		message_type: { name: "Link" field: { name: "target" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Node" } }
+		message_type: { name: "Empty" }
+	`)
+
+	file := tmpl.Files[0]
+	order := findMessage("Order", file)
+	require.Equal(t, 4, order.FieldCount())
+	require.Equal(t, 3, order.MaxDepth())
+	require.Equal(t, 4+1+1+2*2, order.Complexity())
+
+	require.Equal(t, 2, findMessage("Item", file).MaxDepth())
+	require.Equal(t, 1, findMessage("Price", file).MaxDepth())
+
+	node := findMessage("Node", file)
+	require.Equal(t, 2, node.MaxDepth())
+	require.Equal(t, 2, findMessage("Link", file).MaxDepth())
+	require.Equal(t, 2+1+2, node.Complexity())
+
+	empty := findMessage("Empty", file)
+	require.Zero(t, empty.FieldCount())
+	require.Equal(t, 1, empty.MaxDepth())
+	require.Zero(t, empty.Complexity())
+}
+
+func TestReservedRanges(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "Book"
+			reserved_range: { start: 10 end: 13 }
+			reserved_range: { start: 5 end: 6 }
+			reserved_range: { start: 13 end: 16 }
+			reserved_range: { start: 20 end: 536870912 }
+			reserved_name: ["isbn", "author"]
+		}
+		message_type: { name: "Shelf" }
+		enum_type: {
+			name: "Genre"
+			value: { name: "GENRE_UNSPECIFIED" number: 0 }
+			reserved_range: { start: 2 end: 4 }
+			reserved_range: { start: 1 end: 1 }
+			reserved_range: { start: 3 end: 7 }
+			reserved_range: { start: 9 end: 9 }
+			reserved_name: "POETRY"
+		}
+	`)
+
+	format := func(ranges []*ReservedRange) []string {
+		var formatted []string
+		for _, r := range ranges {
+			formatted = append(formatted, r.String())
+		}
+		return formatted
+	}
+
+	file := tmpl.Files[0]
+	book := findMessage("Book", file)
+	require.Equal(t, []string{"5", "10-15", "20-536870911"}, format(book.ReservedRanges))
+	require.Equal(t, []string{"isbn", "author"}, book.ReservedNames)
+
+	shelf := findMessage("Shelf", file)
+	require.Empty(t, shelf.ReservedRanges)
+	require.Empty(t, shelf.ReservedNames)
+
+	genre := file.Enums[0]
+	require.Equal(t, []string{"1-7", "9"}, format(genre.ReservedRanges))
+	require.Equal(t, []string{"POETRY"}, genre.ReservedNames)
+}
+
+func TestFieldTypeAlias(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "Book"
+			field: { name: "price" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Amount" }
+			field: { name: "cost" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Amount" }
+		}
+		message_type: { name: "Amount" }
+		source_code_info: {
+			location: { path: [4, 0, 2, 0] span: [1, 0, 1] leading_comments: " The price.\n @type-alias Money\n" }
+			location: { path: [4, 0, 2, 1] span: [2, 0, 1] leading_comments: " The cost.\n @type-alias\n" }
+		}
+	`)
+
+	book := findMessage("Book", tmpl.Files[0])
+	field := findField("price", book)
+	require.Equal(t, "The price.", field.Description)
+	require.Equal(t, "Money", field.TypeAlias)
+	require.Equal(t, "Money", field.TypeLabel())
+
+	field = findField("cost", book)
+	require.Equal(t, "The cost.\n@type-alias", field.Description)
+	require.Empty(t, field.TypeAlias)
+	require.Equal(t, "Amount", field.TypeLabel())
+
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "| price | [Money](#test-Amount) |")
+}
+
+func TestFieldAnyTypes(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "Event"
+			field: { name: "payload" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Any" }
+			field: { name: "details" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Any" }
+		}
+		message_type: { name: "Book" }
+		message_type: { name: "Shelf" }
+		source_code_info: {
+			location: { path: [4, 0, 2, 0] span: [1, 0, 1] leading_comments: " The payload.\n @any-types test.Book, .test.Shelf,test.Missing\n" }
+		}
+	`)
+
+	event := findMessage("Event", tmpl.Files[0])
+	field := findField("payload", event)
+	require.Equal(t, "The payload.", field.Description)
+	require.Equal(t, []*Link{
+		{Package: "test", FullName: "test.Book", Anchor: "test-Book"},
+		{Package: "test", FullName: "test.Shelf", Anchor: "test-Shelf"},
+		{FullName: "test.Missing"},
+	}, field.AnyTypes)
+	require.Empty(t, findField("details", event).AnyTypes)
+
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "| The payload. May contain: [test.Book](#test-Book), [test.Shelf](#test-Shelf), test.Missing |")
+}
+
+func TestFieldVisibility(t *testing.T) {
+	protos := []string{docsProto("FieldOptions Visibility visibility", "FieldOptions Visibility level"), `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		dependency: "docs.proto"
+		message_type: {
+			name: "Book"
+			field: { name: "title" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "isbn" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING options: { [docs.visibility]: VISIBILITY_PUBLIC } }
+			field: { name: "cost" number: 3 label: LABEL_OPTIONAL type: TYPE_INT32 options: { [docs.visibility]: VISIBILITY_INTERNAL } }
+			field: { name: "secret" number: 4 label: LABEL_OPTIONAL type: TYPE_STRING options: { [docs.visibility]: VISIBILITY_PRIVATE [docs.level]: VISIBILITY_PUBLIC } }
+			field: { name: "audit_id" number: 5 label: LABEL_OPTIONAL type: TYPE_INT32 oneof_index: 0 options: { [docs.visibility]: VISIBILITY_INTERNAL } }
+			oneof_decl: { name: "audit" }
+		}
+		message_type: {
+			name: "Ledger"
+			field: { name: "balance" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32 options: { [docs.visibility]: VISIBILITY_INTERNAL } }
+		}
+	`}
+	names := func(fields []*MessageField) []string {
+		var names []string
+		for _, field := range fields {
+			names = append(names, field.Name)
+		}
+		return names
+	}
+
+	tmpl := newTestTemplate(t, protos...)
+	book := findMessage("Book", tmpl.Files[0])
+	require.Equal(t, []string{"title", "isbn", "cost", "secret"}, names(book.Fields))
+	require.Len(t, book.OneOfs, 1)
+
+	tmpl = newTestTemplateWithOptions(t, []TemplateOption{WithVisibility(VisibilityInternal)}, protos...)
+	book = findMessage("Book", tmpl.Files[0])
+	require.Equal(t, []string{"title", "isbn", "cost"}, names(book.Fields))
+	require.Equal(t, []string{"audit_id"}, names(book.OneOfs[0].Fields))
+
+	tmpl = newTestTemplateWithOptions(t, []TemplateOption{WithVisibility(VisibilityPublic)}, protos...)
+	book = findMessage("Book", tmpl.Files[0])
+	require.Equal(t, []string{"title", "isbn"}, names(book.Fields))
+	require.Empty(t, book.OneOfs)
+	require.False(t, book.HasOneofs)
+	ledger := findMessage("Ledger", tmpl.Files[0])
+	require.Empty(t, ledger.Fields)
+	require.False(t, ledger.HasFields)
+
+	tmpl = newTestTemplateWithOptions(t, []TemplateOption{
+		WithVisibility(VisibilityPublic),
+		WithOptionName(VisibilityOption, "docs.level"),
+	}, protos...)
+	require.Equal(t, []string{"title", "isbn", "cost", "secret"}, names(findMessage("Book", tmpl.Files[0]).Fields))
+}
+
+func TestMapFieldLabels(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Library"
+			field: { name: "books" number: 1 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Library.BooksEntry" }
+			nested_type: {
+				name: "BooksEntry"
+				field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_UINT64 }
+				field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Book" }
+				options: { map_entry: true }
+			}
+		}
+		message_type: { name: "Book" }
+	`)
+
+	field := findField("books", findMessage("Library", tmpl.Files[0]))
+	require.Equal(t, "uint64", field.MapKeyLabel())
+	require.Equal(t, "Book", field.MapValueLabel())
+	require.Empty(t, findField("key", findMessage("Library.BooksEntry", tmpl.Files[0])).MapKeyLabel())
+
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "| books | map&lt;[uint64](#uint64), [Book](#test-Book)&gt; | repeated |")
+
+	output, err = RenderTemplate(RenderTypeHTML, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), `<td>map&lt;<a href="#uint64">uint64</a>, <a href="#test-Book">Book</a>&gt;</td>`)
+}
+
+func TestInlineNestedMessages(t *testing.T) {
+	proto := `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Book"
+			field: { name: "price" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Book.Price" }
+			field: { name: "chapters" number: 2 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Book.Chapter" }
+			field: { name: "tags" number: 3 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Book.TagsEntry" }
+			nested_type: {
+				name: "Price"
+				field: { name: "cents" number: 1 label: LABEL_OPTIONAL type: TYPE_INT64 }
+			}
+			nested_type: {
+				name: "Chapter"
+				field: { name: "sections" number: 1 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".test.Book.Chapter" }
+			}
+			nested_type: {
+				name: "Tag"
+			}
+			nested_type: {
+				name: "TagsEntry"
+				field: { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+				field: { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Book.Tag" }
+				options: { map_entry: true }
+			}
+		}
+	`
+
+	tmpl := newTestTemplate(t, proto)
+	candidates := tmpl.InlineCandidates()
+	require.Len(t, candidates, 1)
+	require.Equal(t, "test.Book.Price", candidates[0].FullName)
+	require.False(t, candidates[0].Inlined())
+	require.Nil(t, findField("price", findMessage("Book", tmpl.Files[0])).InlineMessage())
+
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "  - [Book.Price](#test-Book-Price)")
+
+	tmpl = newTestTemplateWithOptions(t, []TemplateOption{WithInlineNested(true)}, proto)
+	price := findMessage("Book.Price", tmpl.Files[0])
+	require.True(t, price.Inlined())
+	require.Equal(t, price, findField("price", findMessage("Book", tmpl.Files[0])).InlineMessage())
+	require.False(t, findMessage("Book.Chapter", tmpl.Files[0]).Inlined())
+
+	output, err = RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(string(output), "### Book.Price"))
+	require.Less(t, strings.Index(string(output), "### Book.Price"), strings.Index(string(output), "### Book.Chapter"))
+
+	output, err = RenderTemplate(RenderTypeHTML, tmpl, "")
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(string(output), `<h3 id="test-Book-Price"`))
+}
+
+func TestNewTemplateFromFileDescriptorSet(t *testing.T) {
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+
+	tmpl, err := NewTemplateFromFileDescriptorSet(set, WithExcludedPackages([]string{"google.protobuf"}))
+	require.NoError(t, err)
+	var names []string
+	for _, file := range tmpl.Files {
+		names = append(names, file.Name)
+	}
+	require.Contains(t, names, "Booking.proto")
+	require.Contains(t, names, "Vehicle.proto")
+	require.Equal(t, "Represents the booking of a vehicle.\n\nVehicles are some cool shit. But drive carefully!",
+		findMessage("Booking", tmpl.Files[slices.Index(names, "Booking.proto")]).Description)
+
+	// without source info, there are no comments
+	stripped := proto.Clone(set).(*descriptor.FileDescriptorSet)
+	for _, file := range stripped.File {
+		file.SourceCodeInfo = nil
+	}
+	tmpl, err = NewTemplateFromFileDescriptorSet(stripped)
+	require.NoError(t, err)
+	for _, file := range tmpl.Files {
+		if file.Name == "Booking.proto" {
+			require.Empty(t, findMessage("Booking", file).Description)
+			require.Len(t, findMessage("Booking", file).Fields, 6)
+		}
+	}
+
+	// dependencies must be part of the set
+	missing := &descriptor.FileDescriptorSet{File: []*descriptor.FileDescriptorProto{{
+		Name:       proto.String("api.proto"),
+		Dependency: []string{"shelf.proto"},
+	}}}
+	_, err = NewTemplateFromFileDescriptorSet(missing)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Invalid descriptor set")
+}
+
+func TestScalarLangNotes(t *testing.T) {
+	scalars := map[string]*ScalarValue{}
+	for _, scalar := range template.Scalars {
+		scalars[scalar.ProtoType] = scalar
+	}
+
+	require.Contains(t, scalars["int64"].LangNote("typescript"), "2^53 - 1")
+	require.NotEmpty(t, scalars["uint32"].LangNote("java"))
+	require.Empty(t, scalars["int32"].LangNote("java"))
+	require.Empty(t, scalars["int64"].LangNote("go"))
+	require.NotEmpty(t, scalars["int32"].Notes)
+
+	output, err := RenderTemplate(RenderTypeMarkdown, template, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "| bigint<br>JavaScript numbers lose precision beyond 2^53 - 1. The JSON form is a string. |")
+
+	output, err = RenderTemplate(RenderTypeHTML, template, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "<td>bigint<br><small>JavaScript numbers lose precision beyond 2^53 - 1.")
+}
+
+func TestExcludedPackages(t *testing.T) {
+	files := make([]*descriptor.FileDescriptorProto, 0, 3)
+	for _, text := range []string{
+		`name: "reflection.proto" package: "grpc.reflection.v1" message_type: { name: "ServerReflectionRequest" }`,
+		`name: "grpcx.proto" package: "grpcx" message_type: { name: "Extra" }`,
+		`name: "api.proto"
+		package: "test"
+		dependency: ["reflection.proto", "grpcx.proto"]
+		service: {
+			name: "Reflection"
+			method: { name: "Info" input_type: ".grpc.reflection.v1.ServerReflectionRequest" output_type: ".google.protobuf.Empty" }
+		}`,
+	} {
+		fd := new(descriptor.FileDescriptorProto)
+		require.NoError(t, prototext.Unmarshal([]byte(text), fd))
+		files = append(files, fd)
+	}
+
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.ProtoFile = files
+	req.FileToGenerate = []string{"reflection.proto", "grpcx.proto", "api.proto"}
+	tmpl := NewTemplate(protokit.ParseCodeGenRequest(req), WithExcludedPackages([]string{"grpc", "google.protobuf"}))
+
+	require.Len(t, tmpl.Files, 2)
+	require.Equal(t, "grpcx.proto", tmpl.Files[0].Name)
+	require.Equal(t, "api.proto", tmpl.Files[1].Name)
+	require.Len(t, tmpl.Packages, 2)
+	require.Equal(t, "grpcx", tmpl.Packages[0].Name)
+	require.Equal(t, "test", tmpl.Packages[1].Name)
+
+	method := findService("Reflection", tmpl.Files[1]).Methods[0]
+	require.Equal(t, &Link{Package: "grpc", FullName: "grpc.reflection.v1.ServerReflectionRequest", External: true}, method.RequestLink)
+	require.Equal(t, "https://protobuf.dev/reference/protobuf/google.protobuf/#empty", method.ResponseLink.ExternalHREF)
+}
+
+func TestFieldAnchor(t *testing.T) {
+	msg := findMessage("Vehicle", vehicleFile)
+	require.Equal(t, "com-example-Vehicle--id", findField("id", msg).Anchor())
+	require.Equal(t, "com-example-Vehicle--1", findField("id", msg).StableAnchor(msg))
+	require.Equal(t, "com-example-Vehicle--5", findField("category", msg).StableAnchor(nil))
+	require.Equal(t, "com-example-Vehicle-Category--code", findField("code", findMessage("Vehicle.Category", vehicleFile)).Anchor())
+	require.Equal(t, "com-example-Vehicle--kilometers", findField("kilometers", msg).Anchor())
+}
+
+func TestFieldGroups(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: {
+			name: "Login"
+			field: { name: "user" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "password" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "token" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "locale" number: 4 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "sso" number: 5 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 }
+			field: { name: "theme" number: 6 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 }
+			oneof_decl: { name: "method" }
+		}
+		source_code_info: {
+			location: { path: [4, 0, 2, 0] span: [1, 0, 1] leading_comments: " The user name.\n group: Authentication\n" }
+			location: { path: [4, 0, 2, 1] span: [2, 0, 1] leading_comments: " group:Authentication\n" }
+			location: { path: [4, 0, 2, 2] span: [3, 0, 1] leading_comments: " group: Session\n The session token.\n" }
+			location: { path: [4, 0, 2, 3] span: [4, 0, 1] leading_comments: " The preferred locale.\n" }
+			location: { path: [4, 0, 2, 4] span: [5, 0, 1] leading_comments: " group: Authentication\n" }
+		}
+	`)
+
+	msg := findMessage("Login", tmpl.Files[0])
+	require.Equal(t, "Authentication", findField("user", msg).Group)
+	require.Equal(t, "The user name.", findField("user", msg).Description)
+	require.Empty(t, findField("password", msg).Description)
+	require.Equal(t, "The session token.", findField("token", msg).Description)
+	require.Empty(t, findField("locale", msg).Group)
+
+	groups := msg.FieldGroups()
+	require.Len(t, groups, 3)
+	require.Equal(t, []*MessageField{findField("user", msg), findField("password", msg), findField("sso", msg)},
+		groups["Authentication"])
+	require.Equal(t, []*MessageField{findField("token", msg)}, groups["Session"])
+	require.Equal(t, []*MessageField{findField("locale", msg), findField("theme", msg)}, groups[Ungrouped])
+}
+
+func TestEnumZeroValue(t *testing.T) {
+	enum := findEnum("Type", vehicleFile)
+	require.True(t, enum.HasZeroValue())
+	require.Equal(t, "COUPE", enum.ZeroValue().Name)
+
+	enum = findEnum("BookingStatus.StatusCode", bookingFile)
+	require.False(t, enum.HasZeroValue())
+	require.Nil(t, enum.ZeroValue())
+}
+
+func TestEnumValueNumberHex(t *testing.T) {
+	require.Equal(t, "0x10", EnumValue{Number: "16"}.NumberHex())
+	require.Equal(t, "0x0", EnumValue{Number: "0"}.NumberHex())
+	require.Equal(t, "-0x1", EnumValue{Number: "-1"}.NumberHex())
+	require.Equal(t, "bogus", EnumValue{Number: "bogus"}.NumberHex())
+
+	value := findEnum("BookingStatus.StatusCode", bookingFile).Values[0]
+	require.Equal(t, "200", value.DisplayNumber())
+
+	set, err := utils.LoadDescriptorSet("fixtures", "fileset.pb")
+	require.NoError(t, err)
+	req := utils.CreateGenRequest(set, "Booking.proto")
+	tmpl := NewTemplate(protokit.ParseCodeGenRequest(req), WithEnumHex(true))
+
+	value = findEnum("BookingStatus.StatusCode", tmpl.Files[0]).Values[0]
+	require.Equal(t, "200", value.Number)
+	require.Equal(t, "0xc8", value.DisplayNumber())
+}
+
+func TestEnumHexOption(t *testing.T) {
+	tmpl := newTestTemplate(t, docsProto("EnumOptions bool hex"), `
+		name: "api.proto"
+		package: "test"
+		dependency: "docs.proto"
+		enum_type: {
+			name: "Flags"
+			value: { name: "NONE" number: 0 }
+			value: { name: "ALL" number: 255 }
+			options: { [docs.hex]: true }
+		}
+		enum_type: {
+			name: "Genre"
+			value: { name: "GENRE_UNSPECIFIED" number: 0 }
+			value: { name: "FICTION" number: 16 }
+		}
+	`)
+
+	file := tmpl.Files[0]
+	require.Equal(t, "0xff", findEnum("Flags", file).Values[1].DisplayNumber())
+	require.Equal(t, "16", findEnum("Genre", file).Values[1].DisplayNumber())
+
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "| ALL | 0xff |")
+}
+
+func TestVisibleMessages(t *testing.T) {
+	require.NotNil(t, findMessage("Vehicle.PropertiesEntry", vehicleFile))
+	require.True(t, findMessage("Vehicle.PropertiesEntry", vehicleFile).Internal)
+
+	for _, m := range vehicleFile.VisibleMessages() {
+		require.NotEqual(t, "Vehicle.PropertiesEntry", m.LongName)
+	}
+	require.Len(t, vehicleFile.VisibleMessages(), len(vehicleFile.Messages)-1)
+
+	for _, pkg := range template.Packages {
+		for _, m := range pkg.VisibleMessages() {
+			require.False(t, m.Internal)
+		}
+	}
+}
+
+func TestOptionList(t *testing.T) {
+	msg := findMessage("Vehicle", vehicleFile)
+	require.Equal(t, []Option{{Name: E_ExtendMessage.Name, Value: "true"}}, msg.OptionList())
+
+	field := findField("color_preference", findMessage("Booking", bookingFile))
+	require.Equal(t, []Option{{Name: "deprecated", Value: "true"}}, field.OptionList())
+
+	field = findField("payment_received", findMessage("Booking", bookingFile))
+	require.Equal(t, []Option{{Name: E_ExtendField.Name, Value: "true"}}, field.OptionList())
+
+	require.Nil(t, findMessage("Model", vehicleFile).OptionList())
+
+	tmpl := newTestTemplate(t, `
+		name: "opts.proto"
+		package: "test"
+		options: { java_package: "com.test" java_multiple_files: true optimize_for: SPEED }
+	`)
+	require.Equal(t, []Option{
+		{Name: "javaMultipleFiles", Value: "true"},
+		{Name: "javaPackage", Value: "com.test"},
+		{Name: "optimizeFor", Value: "SPEED"},
+	}, tmpl.Files[0].OptionList())
+}
+
 func TestNestedMessageProperties(t *testing.T) {
 	msg := findMessage("Vehicle.Category", vehicleFile)
 	require.Equal(t, "Category", msg.Name)
@@ -212,6 +2122,39 @@ func TestNestedMessageProperties(t *testing.T) {
 	require.True(t, msg.HasFields)
 }
 
+func TestNestedTypes(t *testing.T) {
+	msg := findMessage("Vehicle", vehicleFile)
+	require.Len(t, msg.NestedMessages, 3)
+	require.Equal(t, "Vehicle.Category", msg.NestedMessages[0].LongName)
+	require.Equal(t, "Vehicle.Engine", msg.NestedMessages[1].LongName)
+	require.Equal(t, "Vehicle.PropertiesEntry", msg.NestedMessages[2].LongName)
+	require.Len(t, msg.VisibleNestedMessages(), 2)
+	require.Empty(t, msg.NestedEnums)
+
+	engine := msg.NestedMessages[1]
+	require.Same(t, findMessage("Vehicle.Engine", vehicleFile), engine)
+	require.Len(t, engine.NestedEnums, 1)
+	require.Same(t, findEnum("Vehicle.Engine.FuelType", vehicleFile), engine.NestedEnums[0])
+	require.Len(t, engine.NestedMessages, 1)
+	require.Equal(t, "Vehicle.Engine.Stats", engine.NestedMessages[0].LongName)
+
+	require.Empty(t, findMessage("Model", vehicleFile).NestedMessages)
+}
+
+func TestMessageBreadcrumb(t *testing.T) {
+	vehicle := findMessage("Vehicle", vehicleFile)
+	engine := findMessage("Vehicle.Engine", vehicleFile)
+	stats := findMessage("Vehicle.Engine.Stats", vehicleFile)
+
+	require.Nil(t, vehicle.Parent)
+	require.Same(t, vehicle, engine.Parent)
+	require.Same(t, engine, stats.Parent)
+
+	require.Empty(t, vehicle.Breadcrumb())
+	require.Equal(t, []*Message{vehicle}, engine.Breadcrumb())
+	require.Equal(t, []*Message{vehicle, engine}, stats.Breadcrumb())
+}
+
 func TestMultiplyNestedMessages(t *testing.T) {
 	require.NotNil(t, findEnum("Vehicle.Engine.FuelType", vehicleFile))
 	require.NotNil(t, findMessage("Vehicle.Engine.Stats", vehicleFile))
@@ -253,7 +2196,7 @@ func TestFieldProperties(t *testing.T) {
 	require.Empty(t, field.DefaultValue)
 	require.False(t, field.IsOneof)
 	require.NotEmpty(t, field.Options)
-	require.True(t, *field.Option(E_ExtendField.Name).(*bool))
+	require.True(t, field.Option(E_ExtendField.Name).(bool))
 
 	field = findField("status_code", msg)
 	require.Equal(t, "status_code", field.Name)
@@ -283,6 +2226,8 @@ func TestFieldProperties(t *testing.T) {
 	require.Equal(t, "com.example.Vehicle.PropertiesEntry", field.FullType)
 	require.Empty(t, field.DefaultValue)
 	require.True(t, field.IsMap)
+	require.Equal(t, "string", field.MapKeyLabel())
+	require.Equal(t, "string", field.MapValueLabel())
 	require.False(t, field.IsOneof)
 
 	field = findField("rates", findMessage("Vehicle", vehicleFile))
@@ -391,7 +2336,7 @@ func TestServiceProperties(t *testing.T) {
 	require.Equal(t, "The vehicle service.\n\nManages vehicles and such...", service.Description)
 	require.Len(t, service.Methods, 3)
 	require.NotEmpty(t, service.Options)
-	require.True(t, *service.Option(E_ExtendService.Name).(*bool))
+	require.True(t, service.Option(E_ExtendService.Name).(bool))
 	require.Contains(t, service.MethodOptions(), E_ExtendMethod.Name)
 	require.NotEmpty(t, service.MethodsWithOption(E_ExtendMethod.Name))
 }
@@ -423,7 +2368,411 @@ func TestServiceMethodProperties(t *testing.T) {
 	require.Equal(t, "com.example.Vehicle", method.ResponseFullType)
 	require.False(t, method.ResponseStreaming)
 	require.NotEmpty(t, method.Options)
-	require.True(t, *method.Option(E_ExtendMethod.Name).(*bool))
+	require.True(t, method.Option(E_ExtendMethod.Name).(bool))
+}
+
+func TestServiceMethodLinks(t *testing.T) {
+	method := findServiceMethod("GetVehicle", findService("VehicleService", vehicleFile))
+	require.NotNil(t, method.RequestLink)
+	require.Equal(t, "com.example.FindVehicleById", method.RequestLink.FullName)
+	require.Equal(t, "com.example", method.RequestLink.Package)
+	require.False(t, method.RequestLink.External)
+	require.NotNil(t, method.ResponseLink)
+	require.Equal(t, "com.example.Vehicle", method.ResponseLink.FullName)
+
+	tmpl := newTestTemplate(t, `
+		name: "empty.proto"
+		package: "google.protobuf"
+		message_type: { name: "Empty" }
+	`, `
+		name: "svc.proto"
+		package: "test"
+		dependency: "empty.proto"
+		message_type: { name: "Ping" }
+		service: {
+			name: "PingService"
+			method: { name: "Ping" input_type: ".test.Ping" output_type: ".google.protobuf.Empty" }
+			method: { name: "Pong" input_type: ".test.Ping" output_type: ".other.Pong" }
+		}
+	`)
+	service := findService("PingService", tmpl.Files[0])
+
+	method = findServiceMethod("Ping", service)
+	require.Equal(t, "test.Ping", method.RequestLink.FullName)
+	require.True(t, method.ResponseLink.External)
+	require.Equal(t, "https://protobuf.dev/reference/protobuf/google.protobuf/#empty", method.ResponseLink.ExternalHREF)
+
+	method = findServiceMethod("Pong", service)
+	require.NotNil(t, method.RequestLink)
+	require.Equal(t, &Link{FullName: "other.Pong", External: true}, method.ResponseLink)
+}
+
+func TestExternalBaseURL(t *testing.T) {
+	protos := []string{`
+		name: "money.proto"
+		package: "acme"
+		message_type: { name: "Money" }
+	`, `
+		name: "empty.proto"
+		package: "google.protobuf"
+		message_type: { name: "Empty" }
+	`, `
+		name: "api.proto"
+		package: "test"
+		dependency: "money.proto"
+		dependency: "empty.proto"
+		message_type: {
+			name: "Book"
+			field: { name: "price" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".acme.Money" }
+			field: { name: "cover" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Empty" }
+		}
+	`}
+	typeRefs := `{{typeRef "acme.Money" "Money"}} {{typeRef "google.protobuf.Empty" "Empty"}} {{typeRef "acme.Coin" "Coin"}}`
+
+	tmpl := newTestTemplate(t, protos...)
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, typeRefs)
+	require.NoError(t, err)
+	require.Equal(t, "Money [Empty](https://protobuf.dev/reference/protobuf/google.protobuf/#empty) Coin", string(output))
+
+	tmpl = newTestTemplateWithOptions(t, []TemplateOption{WithExternalBaseURL("https://docs.example.com/#")}, protos...)
+	output, err = RenderTemplate(RenderTypeMarkdown, tmpl, typeRefs)
+	require.NoError(t, err)
+	require.Equal(t, "[Money](https://docs.example.com/#acme.Money) "+
+		"[Empty](https://protobuf.dev/reference/protobuf/google.protobuf/#empty) Coin", string(output))
+
+	tmpl = newTestTemplateWithOptions(t, []TemplateOption{
+		WithExternalBaseURL("https://docs.example.com/#"),
+		WithExcludedPackages([]string{"other"}),
+	}, `
+		name: "other.proto"
+		package: "other"
+		message_type: { name: "Shelf" }
+	`, `
+		name: "api.proto"
+		package: "test"
+		dependency: "other.proto"
+		message_type: {
+			name: "Book"
+			field: { name: "shelf" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".other.Shelf" }
+		}
+	`)
+	output, err = RenderTemplate(RenderTypeHTML, tmpl, `{{typeRef "other.Shelf" "Shelf"}}`)
+	require.NoError(t, err)
+	require.Equal(t, `<a href="https://docs.example.com/#other.Shelf">Shelf</a>`, string(output))
+}
+
+func TestServiceMethodStubSignature(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "empty.proto"
+		package: "google.protobuf"
+		message_type: { name: "Empty" }
+	`, `
+		name: "api.proto"
+		package: "test.v1"
+		dependency: "empty.proto"
+		message_type: { name: "Book" nested_type: { name: "Page" } }
+		service: {
+			name: "Library"
+			method: { name: "GetBook" input_type: ".test.v1.Book" output_type: ".test.v1.Book" }
+			method: { name: "ReadBook" input_type: ".test.v1.Book" output_type: ".test.v1.Book.Page" server_streaming: true }
+			method: { name: "Import" input_type: ".test.v1.Book" output_type: ".google.protobuf.Empty" client_streaming: true }
+			method: { name: "Sync" input_type: ".test.v1.Book.Page" output_type: ".test.v1.Book" client_streaming: true server_streaming: true }
+		}
+	`)
+	service := findService("Library", tmpl.Files[0])
+
+	for name, signatures := range map[string][2]string{
+		"GetBook": {
+			"func (c *LibraryClient) GetBook(ctx context.Context, in *Book, opts ...grpc.CallOption) (*Book, error)",
+			"def GetBook(self, request: Book) -> Book",
+		},
+		"ReadBook": {
+			"func (c *LibraryClient) ReadBook(ctx context.Context, in *Book, opts ...grpc.CallOption) " +
+				"(grpc.ServerStreamingClient[Book_Page], error)",
+			"def ReadBook(self, request: Book) -> Iterator[Book.Page]",
+		},
+		"Import": {
+			"func (c *LibraryClient) Import(ctx context.Context, opts ...grpc.CallOption) " +
+				"(grpc.ClientStreamingClient[Book, protobuf.Empty], error)",
+			"def Import(self, request_iterator: Iterator[Book]) -> protobuf.Empty",
+		},
+		"Sync": {
+			"func (c *LibraryClient) Sync(ctx context.Context, opts ...grpc.CallOption) " +
+				"(grpc.BidiStreamingClient[Book_Page, Book], error)",
+			"def Sync(self, request_iterator: Iterator[Book.Page]) -> Iterator[Book]",
+		},
+	} {
+		method := findServiceMethod(name, service)
+		require.Equal(t, signatures[0], method.StubSignature("go"))
+		require.Equal(t, signatures[1], method.StubSignature("python"))
+		require.Empty(t, method.StubSignature("cobol"))
+	}
+}
+
+func TestServiceMethodsByTag(t *testing.T) {
+	tmpl := newTestTemplate(t, docsProto("MethodOptions string tags"), `
+		name: "api.proto"
+		package: "test"
+		dependency: "docs.proto"
+		message_type: { name: "Empty" }
+		service: {
+			name: "Library"
+			method: { name: "DeleteBook" input_type: ".test.Empty" output_type: ".test.Empty" options: { [docs.tags]: "admin, beta" } }
+			method: { name: "GetBook" input_type: ".test.Empty" output_type: ".test.Empty" }
+			method: { name: "ListBooks" input_type: ".test.Empty" output_type: ".test.Empty" options: { [docs.tags]: "beta," } }
+		}
+	`)
+
+	library := findService("Library", tmpl.Files[0])
+	require.Equal(t, []string{"admin", "beta"}, findServiceMethod("DeleteBook", library).Tags)
+	require.Equal(t, []string{"beta"}, findServiceMethod("ListBooks", library).Tags)
+	require.Empty(t, findServiceMethod("GetBook", library).Tags)
+
+	names := func(methods []*ServiceMethod) []string {
+		var res []string
+		for _, m := range methods {
+			res = append(res, m.Name)
+		}
+		return res
+	}
+	groups := library.MethodsByTag()
+	require.Len(t, groups, 3)
+	require.Equal(t, []string{"DeleteBook"}, names(groups["admin"]))
+	require.Equal(t, []string{"DeleteBook", "ListBooks"}, names(groups["beta"]))
+	require.Equal(t, []string{"GetBook"}, names(groups[Untagged]))
+}
+
+func TestEditionsFeatures(t *testing.T) {
+	fd := new(descriptor.FileDescriptorProto)
+	require.NoError(t, prototext.Unmarshal([]byte(`
+		name: "api.proto"
+		package: "test"
+		syntax: "editions"
+		edition: "2023"
+		options: {}
+		message_type: {
+			name: "Book"
+			field: { name: "title" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "isbn" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING options: {} }
+			nested_type: {
+				name: "Page"
+				field: { name: "number" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32 }
+				options: {}
+			}
+		}
+	`), fd))
+
+	// features = 50 of the options, and field_presence = 1 or message_encoding = 5 of the feature set
+	features := func(number protowire.Number, value uint64) []byte {
+		set := protowire.AppendTag(nil, number, protowire.VarintType)
+		set = protowire.AppendVarint(set, value)
+		b := protowire.AppendTag(nil, 50, protowire.BytesType)
+		return protowire.AppendBytes(b, set)
+	}
+	fd.Options.ProtoReflect().SetUnknown(features(1, 2))
+	fd.MessageType[0].Field[1].Options.ProtoReflect().SetUnknown(features(1, 3))
+	fd.MessageType[0].NestedType[0].Options.ProtoReflect().SetUnknown(features(5, 2))
+
+	tmpl := newTestTemplateFromFiles(fd)
+	require.Equal(t, map[string]string{
+		"field_presence":          "IMPLICIT",
+		"enum_type":               "OPEN",
+		"repeated_field_encoding": "PACKED",
+		"utf8_validation":         "VERIFY",
+		"message_encoding":        "LENGTH_PREFIXED",
+	}, tmpl.Files[0].Features)
+
+	book := findMessage("Book", tmpl.Files[0])
+	require.Equal(t, "IMPLICIT", findField("title", book).Features["field_presence"])
+	require.Equal(t, "LEGACY_REQUIRED", findField("isbn", book).Features["field_presence"])
+	number := findField("number", findMessage("Book.Page", tmpl.Files[0]))
+	require.Equal(t, "IMPLICIT", number.Features["field_presence"])
+	require.Equal(t, "DELIMITED", number.Features["message_encoding"])
+
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "### Features\n| Feature | Value |\n| ------- | ----- |\n"+
+		"| enum_type | OPEN |\n| field_presence | IMPLICIT |\n")
+
+	// proto2 and proto3 files have no features
+	proto3 := newTestTemplate(t, `
+		name: "a.proto"
+		package: "test"
+		syntax: "proto3"
+		message_type: { name: "A" field: { name: "a" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING } }
+	`)
+	require.Empty(t, proto3.Files[0].Features)
+	require.Empty(t, proto3.Files[0].Messages[0].Fields[0].Features)
+
+	output, err = RenderTemplate(RenderTypeMarkdown, proto3, "")
+	require.NoError(t, err)
+	require.NotContains(t, string(output), "Features")
+}
+
+func TestServiceAPIOptions(t *testing.T) {
+	fd := new(descriptor.FileDescriptorProto)
+	require.NoError(t, prototext.Unmarshal([]byte(`
+		name: "api.proto"
+		package: "test"
+		message_type: { name: "Empty" }
+		service: {
+			name: "Library"
+			method: { name: "Ping" input_type: ".test.Empty" output_type: ".test.Empty" }
+			options: {}
+		}
+		service: {
+			name: "Local"
+			method: { name: "Ping" input_type: ".test.Empty" output_type: ".test.Empty" }
+		}
+	`), fd))
+
+	// google.api.default_host = 1049 and google.api.oauth_scopes = 1050
+	var opts []byte
+	opts = protowire.AppendTag(opts, 1049, protowire.BytesType)
+	opts = protowire.AppendString(opts, "library.example.com")
+	opts = protowire.AppendTag(opts, 1050, protowire.BytesType)
+	opts = protowire.AppendString(opts, "https://example.com/auth/read, https://example.com/auth/write")
+	fd.Service[0].Options.ProtoReflect().SetUnknown(opts)
+
+	tmpl := newTestTemplateFromFiles(fd)
+	library := findService("Library", tmpl.Files[0])
+	require.Equal(t, "library.example.com", library.DefaultHost())
+	require.Equal(t, []string{"https://example.com/auth/read", "https://example.com/auth/write"}, library.OAuthScopes())
+
+	local := findService("Local", tmpl.Files[0])
+	require.Empty(t, local.DefaultHost())
+	require.Empty(t, local.OAuthScopes())
+
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "Default host: `library.example.com`\n\n"+
+		"OAuth scopes: `https://example.com/auth/read`, `https://example.com/auth/write`\n")
+}
+
+func TestHTTPRules(t *testing.T) {
+	fd := new(descriptor.FileDescriptorProto)
+	require.NoError(t, prototext.Unmarshal([]byte(`
+		name: "api.proto"
+		package: "test"
+		message_type: { name: "Book" }
+		service: {
+			name: "Library"
+			method: { name: "GetBook" input_type: ".test.Book" output_type: ".test.Book" options: {} }
+			method: { name: "Ping" input_type: ".test.Book" output_type: ".test.Book" }
+		}
+	`), fd))
+	// google.api.http = 72295728 with additional bindings (11), a custom pattern (8), a body (7) and a response body (12)
+	fd.Service[0].Method[0].Options.ProtoReflect().SetUnknown(encodeFields(72295728, encodeFields(
+		2, "/v1/{name=books/*}",
+		11, encodeFields(8, encodeFields(1, "HEAD", 2, "/v1/{name=books/*}")),
+		11, encodeFields(4, "/v1/books:get", 7, "*", 12, "name"),
+	)))
+
+	tmpl := newTestTemplateFromFiles(fd)
+	library := findService("Library", tmpl.Files[0])
+	require.Equal(t, []*HTTPRule{
+		{Method: "GET", Path: "/v1/{name=books/*}"},
+		{Method: "HEAD", Path: "/v1/{name=books/*}"},
+		{Method: "POST", Path: "/v1/books:get", Body: "*", ResponseBody: "name"},
+	}, library.Methods[0].HTTPRules)
+	require.Empty(t, library.Methods[1].HTTPRules)
+}
+
+// encodeFields encodes pairs of field numbers and values (strings, encoded messages or bools) in the wire format.
+func encodeFields(fields ...interface{}) []byte {
+	var b []byte
+	for i := 0; i+1 < len(fields); i += 2 {
+		num := protowire.Number(fields[i].(int))
+		switch v := fields[i+1].(type) {
+		case string:
+			b = protowire.AppendTag(b, num, protowire.BytesType)
+			b = protowire.AppendString(b, v)
+		case []byte:
+			b = protowire.AppendTag(b, num, protowire.BytesType)
+			b = protowire.AppendBytes(b, v)
+		case bool:
+			b = protowire.AppendTag(b, num, protowire.VarintType)
+			b = protowire.AppendVarint(b, protowire.EncodeBool(v))
+		}
+	}
+	return b
+}
+
+func TestOpenAPIOptions(t *testing.T) {
+	fd := new(descriptor.FileDescriptorProto)
+	require.NoError(t, prototext.Unmarshal([]byte(`
+		name: "api.proto"
+		package: "test"
+		message_type: { name: "Book" }
+		service: {
+			name: "Library"
+			method: { name: "GetBook" input_type: ".test.Book" output_type: ".test.Book" options: {} }
+			method: { name: "Ping" input_type: ".test.Book" output_type: ".test.Book" }
+			options: {}
+		}
+		service: {
+			name: "Local"
+			method: { name: "Ping" input_type: ".test.Book" output_type: ".test.Book" }
+		}
+	`), fd))
+	// grpc.gateway.protoc_gen_openapiv2.options.openapiv2_tag = 1042 (name 5, description 2, external docs 3)
+	fd.Service[0].Options.ProtoReflect().SetUnknown(encodeFields(1042, encodeFields(
+		5, "Books",
+		2, "Managing books.",
+		3, encodeFields(1, "Guide", 2, "https://example.com/books"),
+	)))
+	// grpc.gateway.protoc_gen_openapiv2.options.openapiv2_operation = 1042 with tags (1), a summary (2), an operation
+	// ID (5), deprecated (11) and security requirements (12) mapping schemes to scopes
+	fd.Service[0].Method[0].Options.ProtoReflect().SetUnknown(encodeFields(1042, encodeFields(
+		1, "Books",
+		1, "Public",
+		2, "Get a book",
+		5, "getBook",
+		11, true,
+		12, encodeFields(1, encodeFields(1, "OAuth2", 2, encodeFields(1, "read", 1, "write"))),
+		12, encodeFields(1, encodeFields(1, "ApiKey", 2, []byte{})),
+	)))
+
+	tmpl := newTestTemplateFromFiles(fd)
+	library := findService("Library", tmpl.Files[0])
+	require.Equal(t, &OpenAPITag{
+		Name:         "Books",
+		Description:  "Managing books.",
+		ExternalDocs: &OpenAPIExternalDocs{Description: "Guide", URL: "https://example.com/books"},
+	}, library.OpenAPITag)
+	require.Equal(t, &OpenAPIOperation{
+		Summary:     "Get a book",
+		OperationID: "getBook",
+		Tags:        []string{"Books", "Public"},
+		Deprecated:  true,
+		Security:    []map[string][]string{{"OAuth2": {"read", "write"}}, {"ApiKey": nil}},
+	}, library.Methods[0].OpenAPIOperation)
+	require.Nil(t, library.Methods[1].OpenAPIOperation)
+	require.Nil(t, findService("Local", tmpl.Files[0]).OpenAPITag)
+}
+
+func TestServiceMethodMessages(t *testing.T) {
+	method := findServiceMethod("GetVehicle", findService("VehicleService", vehicleFile))
+	require.Same(t, findMessage("FindVehicleById", vehicleFile), method.RequestMessage)
+	require.Same(t, findMessage("Vehicle", vehicleFile), method.ResponseMessage)
+
+	tmpl := newTestTemplate(t, `
+		name: "empty.proto"
+		package: "google.protobuf"
+		message_type: { name: "Empty" }
+	`, `
+		name: "svc.proto"
+		package: "test"
+		dependency: "empty.proto"
+		message_type: { name: "Ping" }
+		service: {
+			name: "PingService"
+			method: { name: "Ping" input_type: ".test.Ping" output_type: ".google.protobuf.Empty" }
+		}
+	`)
+	method = findServiceMethod("Ping", findService("PingService", tmpl.Files[0]))
+	require.Equal(t, "Ping", method.RequestMessage.Name)
+	require.Nil(t, method.ResponseMessage)
 }
 
 func TestExcludedComments(t *testing.T) {
@@ -436,6 +2785,153 @@ func TestExcludedComments(t *testing.T) {
 	require.Equal(t, "the id of this message.", findField("id", message).Description)
 }
 
+func TestSnippets(t *testing.T) {
+	text := `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "Book"
+			field: { name: "title" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+		}
+		service: {
+			name: "Library"
+			method: { name: "ListBooks" input_type: ".test.Book" output_type: ".test.Book" }
+		}
+		source_code_info: {
+			location: { path: [4, 0] span: [1, 0, 1] leading_comments: " A book.\n\n  @include  pagination \n" }
+			location: { path: [4, 0, 2, 0] span: [2, 0, 1] leading_comments: " The title.\n@include missing\n" }
+			location: { path: [6, 0, 2, 0] span: [3, 0, 1] leading_comments: " Lists books.\n@include pagination\n" }
+		}
+	`
+	opts := []TemplateOption{WithSnippets(map[string]string{"pagination": "Results are paginated.\n"})}
+	tmpl := newTestTemplateWithOptions(t, opts, text)
+
+	book := findMessage("Book", tmpl.Files[0])
+	require.Equal(t, "A book.\n\nResults are paginated.", book.Description)
+	require.Equal(t, "The title.\n@include missing", findField("title", book).Description)
+	method := findServiceMethod("ListBooks", findService("Library", tmpl.Files[0]))
+	require.Equal(t, "Lists books.\nResults are paginated.", method.Description)
+
+	// without snippets, comments are left as they are
+	book = findMessage("Book", newTestTemplate(t, text).Files[0])
+	require.Equal(t, "A book.\n\n @include  pagination", book.Description)
+}
+
+// newTestTemplate builds a template documenting the last of the given (text) FileDescriptorProtos, with the others as
+// its imports. Custom options can be set with the extensions the files before declare, e.g. `[docs.category]: "Billing"`
+// with a `docs.proto` file declaring `docs.category`. Like protoc, which doesn't know about them either, the plugin gets
+// them as unknown fields.
+func newTestTemplate(t *testing.T, protos ...string) *Template {
+	t.Helper()
+	return newTestTemplateWithOptions(t, nil, protos...)
+}
+
+// newTestTemplateWithOptions is like newTestTemplate, but builds the template with the given options.
+func newTestTemplateWithOptions(t *testing.T, opts []TemplateOption, protos ...string) *Template {
+	t.Helper()
+
+	req := newTestRequest(t, protos...)
+	return NewTemplate(protokit.ParseCodeGenRequest(req), append([]TemplateOption{WithProtoFiles(req.ProtoFile)}, opts...)...)
+}
+
+// newTestRequest returns the CodeGeneratorRequest protoc sends for the last of the given (text) FileDescriptorProtos,
+// see newTestTemplate.
+func newTestRequest(t *testing.T, protos ...string) *plugin_go.CodeGeneratorRequest {
+	t.Helper()
+
+	files := make([]*descriptor.FileDescriptorProto, 0, len(protos))
+	for _, text := range protos {
+		fd := new(descriptor.FileDescriptorProto)
+		require.NoError(t, prototext.UnmarshalOptions{Resolver: newTestOptionTypes(t, files)}.Unmarshal([]byte(text), fd))
+
+		data, err := proto.Marshal(fd)
+		require.NoError(t, err)
+		fd = new(descriptor.FileDescriptorProto)
+		require.NoError(t, proto.Unmarshal(data, fd))
+		files = append(files, fd)
+	}
+
+	return &plugin_go.CodeGeneratorRequest{ProtoFile: files, FileToGenerate: []string{files[len(files)-1].GetName()}}
+}
+
+// docsProto returns a `docs.proto` file declaring the given custom options in the `docs` package, e.g.
+// `MessageOptions string category` for `[docs.category]: "Billing"` in the options of messages. Options typed with the
+// `Visibility` enum can be set to `VISIBILITY_PUBLIC`, `VISIBILITY_INTERNAL` or `VISIBILITY_PRIVATE`.
+func docsProto(options ...string) string {
+	types := map[string]string{
+		"bool":       "type: TYPE_BOOL",
+		"int32":      "type: TYPE_INT32",
+		"int64":      "type: TYPE_INT64",
+		"uint32":     "type: TYPE_UINT32",
+		"string":     "type: TYPE_STRING",
+		"Visibility": `type: TYPE_ENUM type_name: ".docs.Visibility"`,
+	}
+
+	var b strings.Builder
+	b.WriteString(`name: "docs.proto" package: "docs" dependency: "google/protobuf/descriptor.proto"
+		enum_type: {
+			name: "Visibility"
+			value: { name: "VISIBILITY_UNSPECIFIED" number: 0 }
+			value: { name: "VISIBILITY_PUBLIC" number: 1 }
+			value: { name: "VISIBILITY_INTERNAL" number: 2 }
+			value: { name: "VISIBILITY_PRIVATE" number: 3 }
+		}`)
+	for i, option := range options {
+		var extendee, typ, name string
+		fmt.Sscan(option, &extendee, &typ, &name)
+		fmt.Fprintf(&b, "\n\t\textension: { name: %q number: %d label: LABEL_OPTIONAL %s extendee: \".google.protobuf.%s\" }",
+			name, 50000+i, types[typ], extendee)
+	}
+	return b.String()
+}
+
+// testOptionTypes resolves the extensions declared by the files of a test, then the registered ones.
+type testOptionTypes struct {
+	*protoregistry.Types
+}
+
+func newTestOptionTypes(t *testing.T, files []*descriptor.FileDescriptorProto) testOptionTypes {
+	t.Helper()
+
+	reg, err := protodesc.FileOptions{AllowUnresolvable: true}.NewFiles(&descriptor.FileDescriptorSet{File: files})
+	require.NoError(t, err)
+
+	types := testOptionTypes{new(protoregistry.Types)}
+	reg.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		for i := 0; i < fd.Extensions().Len(); i++ {
+			require.NoError(t, types.RegisterExtension(dynamicpb.NewExtensionType(fd.Extensions().Get(i))))
+		}
+		return true
+	})
+	return types
+}
+
+func (r testOptionTypes) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	if xt, err := r.Types.FindExtensionByName(field); err == nil {
+		return xt, nil
+	}
+	return protoregistry.GlobalTypes.FindExtensionByName(field)
+}
+
+func (r testOptionTypes) FindExtensionByNumber(
+	message protoreflect.FullName,
+	field protoreflect.FieldNumber,
+) (protoreflect.ExtensionType, error) {
+	if xt, err := r.Types.FindExtensionByNumber(message, field); err == nil {
+		return xt, nil
+	}
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
+}
+
+// newTestTemplateFromFiles is like newTestTemplate for already parsed FileDescriptorProtos.
+func newTestTemplateFromFiles(files ...*descriptor.FileDescriptorProto) *Template {
+	req := new(plugin_go.CodeGeneratorRequest)
+	req.ProtoFile = files
+	req.FileToGenerate = []string{files[len(files)-1].GetName()}
+
+	return NewTemplate(protokit.ParseCodeGenRequest(req))
+}
+
 func findService(name string, f *File) *Service {
 	for _, s := range f.Services {
 		if s.Name == name {
@@ -493,5 +2989,13 @@ func findField(name string, m *Message) *MessageField {
 		}
 	}
 
+	for _, o := range m.OneOfs {
+		for _, f := range o.Fields {
+			if f.Name == name {
+				return f
+			}
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,206 @@
+package gendoc
+
+import (
+	"fmt"
+	"strings"
+)
+
+type typeScriptRenderer struct{}
+
+// Apply renders TypeScript declarations for the (non-internal) messages and enums of the template: an `interface` per
+// message and a union of value names per enum, named by tsNames. Fields are named by their JSON name, typed from the
+// TypeScript column of the scalar value table and marked optional (`?`) when they track presence: messages, oneof
+// members and explicitly optional fields. Types that aren't part of the template are `unknown`.
+func (r *typeScriptRenderer) Apply(template *Template) ([]byte, error) {
+	scalars := make(map[string]string, len(template.Scalars))
+	for _, scalar := range template.Scalars {
+		scalars[scalar.ProtoType] = scalar.TsType
+	}
+
+	names := tsNames(template)
+	enums := make(map[string]bool)
+	for _, file := range template.Files {
+		for _, enum := range file.Enums {
+			enums[enum.FullName] = true
+		}
+	}
+
+	typeName := func(fullType string) string {
+		if t, ok := scalars[fullType]; ok {
+			return t
+		}
+		if t, ok := names[fullType]; ok {
+			return t
+		}
+		return "unknown"
+	}
+
+	var out strings.Builder
+	out.WriteString("// Code generated by protoc-gen-doc. DO NOT EDIT.\n")
+
+	for _, file := range template.Files {
+		fmt.Fprintf(&out, "\n// %s\n", file.Name)
+
+		for _, enum := range file.Enums {
+			values := make([]string, 0, len(enum.Values))
+			for _, value := range enum.Values {
+				values = append(values, fmt.Sprintf("%q", value.Name))
+			}
+
+			out.WriteString("\n")
+			writeTSDoc(&out, "", enum.Description, isDeprecated(enum.Options))
+			fmt.Fprintf(&out, "export type %s = %s;\n", names[enum.FullName], strings.Join(values, " | "))
+		}
+
+		for _, msg := range file.VisibleMessages() {
+			out.WriteString("\n")
+			writeTSDoc(&out, "", msg.Description, isDeprecated(msg.Options))
+			fmt.Fprintf(&out, "export interface %s {\n", names[msg.FullName])
+
+			for _, field := range msg.allFields() {
+				var t string
+				switch {
+				case field.IsMap:
+					key := "string"
+					if typeName(field.MapKeyType) == "number" {
+						key = "number"
+					}
+					t = fmt.Sprintf("Record<%s, %s>", key, typeName(field.MapValueType))
+				case field.Label == "repeated":
+					t = typeName(field.FullType) + "[]"
+				default:
+					t = typeName(field.FullType)
+				}
+
+				// proto3 scalars and enums without a label have implicit presence, they're always set
+				_, scalar := scalars[field.FullType]
+				implicit := field.Label == "" && (scalar || enums[field.FullType])
+				optional := ""
+				if field.Label == "optional" || field.IsOneof || (field.Label == "" && !implicit) {
+					optional = "?"
+				}
+
+				writeTSDoc(&out, "  ", field.Description, isDeprecated(field.Options))
+				fmt.Fprintf(&out, "  %s%s: %s;\n", field.JSONName, optional, t)
+			}
+
+			out.WriteString("}\n")
+		}
+	}
+
+	return []byte(out.String()), nil
+}
+
+type typeScriptEnumsRenderer struct{}
+
+// Apply renders a TypeScript `enum` per enum of the template, named like the declarations of the typescript output
+// (e.g. `Vehicle_Category`), along with a const map of the names of the values by number (e.g.
+// `Vehicle_Category_name`), like the maps of protoc-gen-go. Aliases (allow_alias) are members of the enum, but the map
+// keeps the first name of each number, which is the one protojson uses. Descriptions become doc comments.
+func (r *typeScriptEnumsRenderer) Apply(template *Template) ([]byte, error) {
+	names := tsNames(template)
+
+	var out strings.Builder
+	out.WriteString("// Code generated by protoc-gen-doc. DO NOT EDIT.\n")
+
+	for _, file := range template.Files {
+		if len(file.Enums) == 0 {
+			continue
+		}
+		fmt.Fprintf(&out, "\n// %s\n", file.Name)
+
+		for _, enum := range file.Enums {
+			name := names[enum.FullName]
+
+			out.WriteString("\n")
+			writeTSDoc(&out, "", enum.Description, isDeprecated(enum.Options))
+			fmt.Fprintf(&out, "export enum %s {\n", name)
+			for _, value := range enum.Values {
+				writeTSDoc(&out, "  ", value.Description, isDeprecated(value.Options))
+				fmt.Fprintf(&out, "  %s = %s,\n", value.Name, value.Number)
+			}
+			out.WriteString("}\n")
+
+			fmt.Fprintf(&out, "\nexport const %s_name = {\n", name)
+			seen := make(map[string]bool, len(enum.Values))
+			for _, value := range enum.Values {
+				if seen[value.Number] {
+					continue
+				}
+				seen[value.Number] = true
+
+				// negative numbers aren't valid keys of object literals unless they're quoted
+				key := value.Number
+				if strings.HasPrefix(key, "-") {
+					key = fmt.Sprintf("%q", key)
+				}
+				fmt.Fprintf(&out, "  %s: %q,\n", key, value.Name)
+			}
+			out.WriteString("} as const;\n")
+		}
+	}
+
+	return []byte(out.String()), nil
+}
+
+// tsNames maps the full names of the (non-internal) messages and enums of the template to their TypeScript identifiers:
+// their long names with underscores (e.g. `Vehicle_Category`), or their full names when types of several packages
+// would get the same one (e.g. `a_v1_Request` and `b_v1_Request`), as same-named declarations would merge or clash.
+func tsNames(template *Template) map[string]string {
+	byName := make(map[string][]string)
+	add := func(fullName, longName string) {
+		name := tsName(longName)
+		byName[name] = append(byName[name], fullName)
+	}
+	for _, file := range template.Files {
+		for _, enum := range file.Enums {
+			add(enum.FullName, enum.LongName)
+		}
+		for _, msg := range file.VisibleMessages() {
+			add(msg.FullName, msg.LongName)
+		}
+	}
+
+	names := make(map[string]string)
+	for name, fullNames := range byName {
+		for _, fullName := range fullNames {
+			if len(fullNames) > 1 {
+				names[fullName] = tsName(fullName)
+			} else {
+				names[fullName] = name
+			}
+		}
+	}
+	return names
+}
+
+// tsName turns the (long or full) name of a type into a TypeScript identifier, e.g. `Vehicle.Category` becomes
+// `Vehicle_Category`.
+func tsName(name string) string {
+	return strings.ReplaceAll(name, ".", "_")
+}
+
+// writeTSDoc writes a JSDoc comment with the description, if there is anything to document.
+func writeTSDoc(out *strings.Builder, indent, desc string, deprecated bool) {
+	lines := make([]string, 0, 2)
+	if desc = strings.TrimSpace(desc); desc != "" {
+		lines = append(lines, strings.Split(strings.ReplaceAll(desc, "*/", "*\\/"), "\n")...)
+	}
+	if deprecated {
+		lines = append(lines, "@deprecated")
+	}
+
+	switch len(lines) {
+	case 0:
+		return
+	case 1:
+		fmt.Fprintf(out, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+
+	fmt.Fprintf(out, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(out, "%s%s\n", indent, strings.TrimRight(" * "+line, " "))
+	}
+	fmt.Fprintf(out, "%s */\n", indent)
+}
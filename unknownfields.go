@@ -0,0 +1,63 @@
+package gendoc
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// forEachField calls fn with the number, type and value of the fields of an encoded message, in order: the bytes of
+// length-delimited fields, the value of varint fields. It stops at the first malformed field.
+func forEachField(b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, x uint64)) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return
+		}
+		b = b[n:]
+
+		switch typ {
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return
+			}
+			fn(num, typ, v, 0)
+			b = b[n:]
+		case protowire.VarintType:
+			x, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return
+			}
+			fn(num, typ, nil, x)
+			b = b[n:]
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return
+			}
+			b = b[n:]
+		}
+	}
+}
+
+// forEachUnknownField calls fn with the unknown fields of the options like forEachField does, e.g. with the values of
+// extensions that aren't registered. Nil options have none.
+func forEachUnknownField(opts protoreflect.ProtoMessage, fn func(num protowire.Number, typ protowire.Type, v []byte, x uint64)) {
+	if opts == nil || !opts.ProtoReflect().IsValid() {
+		return
+	}
+	forEachField(opts.ProtoReflect().GetUnknown(), fn)
+}
+
+// unknownMessage returns the encoded message field with the given number among the unknown fields of the options, e.g.
+// an extension that isn't registered. Repeated occurrences are concatenated, which merges them.
+func unknownMessage(opts protoreflect.ProtoMessage, number protowire.Number) ([]byte, bool) {
+	var msg []byte
+	found := false
+	forEachUnknownField(opts, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) {
+		if num == number && typ == protowire.BytesType {
+			msg, found = append(msg, v...), true
+		}
+	})
+	return msg, found
+}
@@ -0,0 +1,118 @@
+package gendoc
+
+import (
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Warning points at a discouraged pattern found by Template.Validate. Location is the full name of the entity, e.g.
+// `com.example.Booking.vehicle_id` for a field.
+type Warning struct {
+	File     string `json:"file"`
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+// String formats the warning like `Booking.proto: com.example.Booking.vehicle_id: <message>`.
+func (w Warning) String() string { return fmt.Sprintf("%s: %s: %s", w.File, w.Location, w.Message) }
+
+// Validate returns warnings about discouraged (but valid) patterns and malformed descriptors in the documented files,
+// sorted by file and location:
+//   - proto2 `required` fields, which can't ever be made optional without breaking compatibility
+//   - `@see` references to types that can't be resolved (see Message.SeeAlso)
+//   - fields sharing the JSON name of another field of their message, which protojson rejects
+//   - `@include` lines naming unknown snippets (see WithSnippets)
+//   - field numbers outside of 1 to 536,870,911, or within 19,000 to 19,999 which is reserved for the implementation
+func (t *Template) Validate() []*Warning {
+	var warnings []*Warning
+	includes := func(file, location, desc string) {
+		for _, match := range includePattern.FindAllStringSubmatch(desc, -1) {
+			warnings = append(warnings, &Warning{
+				File:     file,
+				Location: location,
+				Message:  fmt.Sprintf("@include of unknown snippet %s", match[1]),
+			})
+		}
+	}
+	seeAlso := func(file, location string, links []*Link) {
+		for _, l := range links {
+			if t.resolveLink(l.FullName) == nil {
+				warnings = append(warnings, &Warning{
+					File:     file,
+					Location: location,
+					Message:  fmt.Sprintf("@see reference to unknown type %s", l.FullName),
+				})
+			}
+		}
+	}
+
+	for _, file := range t.Files {
+		for _, msg := range file.VisibleMessages() {
+			seeAlso(file.Name, msg.FullName, msg.SeeAlso)
+			includes(file.Name, msg.FullName, msg.Description)
+			jsonNames := make(map[string]string)
+			for _, field := range msg.allFields() {
+				seeAlso(file.Name, msg.FullName+"."+field.Name, field.SeeAlso)
+				includes(file.Name, msg.FullName+"."+field.Name, field.Description)
+				if field.Required {
+					warnings = append(warnings, &Warning{
+						File:     file.Name,
+						Location: msg.FullName + "." + field.Name,
+						Message:  "required fields are discouraged, they can't be made optional later on",
+					})
+				}
+				if problem := fieldNumberProblem(protowire.Number(field.Index)); problem != "" {
+					warnings = append(warnings, &Warning{
+						File:     file.Name,
+						Location: msg.FullName + "." + field.Name,
+						Message:  problem,
+					})
+				}
+				if other, ok := jsonNames[field.JSONName]; ok {
+					warnings = append(warnings, &Warning{
+						File:     file.Name,
+						Location: msg.FullName + "." + field.Name,
+						Message:  fmt.Sprintf("JSON name %q is also the JSON name of field %s", field.JSONName, other),
+					})
+				} else {
+					jsonNames[field.JSONName] = field.Name
+				}
+			}
+		}
+		for _, enum := range file.Enums {
+			seeAlso(file.Name, enum.FullName, enum.SeeAlso)
+			includes(file.Name, enum.FullName, enum.Description)
+		}
+		for _, service := range file.Services {
+			includes(file.Name, service.FullName, service.Description)
+			for _, method := range service.Methods {
+				seeAlso(file.Name, service.FullName+"."+method.Name, method.SeeAlso)
+				includes(file.Name, service.FullName+"."+method.Name, method.Description)
+			}
+		}
+	}
+
+	sort.SliceStable(warnings, func(i, j int) bool {
+		if warnings[i].File != warnings[j].File {
+			return warnings[i].File < warnings[j].File
+		}
+		return warnings[i].Location < warnings[j].Location
+	})
+	return warnings
+}
+
+// fieldNumberProblem describes why the given field number is invalid, or returns an empty string if it's valid.
+func fieldNumberProblem(number protowire.Number) string {
+	switch {
+	case number < protowire.MinValidNumber:
+		return fmt.Sprintf("field number %d is below the minimum of %d", number, protowire.MinValidNumber)
+	case number > protowire.MaxValidNumber:
+		return fmt.Sprintf("field number %d exceeds the maximum of %d", number, protowire.MaxValidNumber)
+	case number >= protowire.FirstReservedNumber && number <= protowire.LastReservedNumber:
+		return fmt.Sprintf("field number %d is within the range reserved for the implementation (%d to %d)",
+			number, protowire.FirstReservedNumber, protowire.LastReservedNumber)
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package gendoc_test
+
+import (
+	"testing"
+
+	. "github.com/pseudomuto/protoc-gen-doc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRequiredFields(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "Book"
+			field: { name: "title" number: 1 label: LABEL_REQUIRED type: TYPE_STRING }
+			field: { name: "id" number: 2 label: LABEL_REQUIRED type: TYPE_INT32 }
+			field: { name: "subtitle" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING }
+		}
+	`)
+
+	book := findMessage("Book", tmpl.Files[0])
+	require.True(t, findField("title", book).Required)
+	require.False(t, findField("subtitle", book).Required)
+
+	warnings := tmpl.Validate()
+	require.Len(t, warnings, 2)
+	require.Equal(t, "test.Book.id", warnings[0].Location)
+	require.Equal(t, "test.Book.title", warnings[1].Location)
+	require.Equal(t, "api.proto: test.Book.title: required fields are discouraged, they can't be made optional later on",
+		warnings[1].String())
+
+	output, err := RenderTemplate(RenderTypeMarkdown, tmpl, "")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "| title | [string](#string) | **required** |")
+	require.Contains(t, string(output), "| subtitle | [string](#string) | optional |")
+
+	require.Empty(t, newTestTemplate(t, `name: "empty.proto" package: "test"`).Validate())
+}
+
+func TestValidateSeeAlso(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "Book"
+			field: { name: "shelf_id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32 }
+		}
+		source_code_info: {
+			location: { path: [4, 0] span: [1, 0, 1] leading_comments: " A book.\n @see test.Book\n" }
+			location: { path: [4, 0, 2, 0] span: [2, 0, 1] leading_comments: " @see test.Shelf\n" }
+		}
+	`)
+
+	warnings := tmpl.Validate()
+	require.Len(t, warnings, 1)
+	require.Equal(t, "api.proto: test.Book.shelf_id: @see reference to unknown type test.Shelf", warnings[0].String())
+}
+
+func TestValidateSnippets(t *testing.T) {
+	tmpl := newTestTemplateWithOptions(t, []TemplateOption{WithSnippets(map[string]string{"pagination": "Paginated."})}, `
+		name: "api.proto"
+		package: "test"
+		message_type: { name: "Book" }
+		source_code_info: {
+			location: { path: [4, 0] span: [1, 0, 1] leading_comments: " A book.\n @include pagination\n @include missing\n" }
+		}
+	`)
+
+	require.Equal(t, "A book.\nPaginated.\n@include missing", tmpl.Files[0].Messages[0].Description)
+
+	warnings := tmpl.Validate()
+	require.Len(t, warnings, 1)
+	require.Equal(t, "api.proto: test.Book: @include of unknown snippet missing", warnings[0].String())
+}
+
+func TestValidateFieldNumbers(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "Book"
+			field: { name: "a_zero" number: 0 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "b_negative" number: -1 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "c_reserved" number: 19000 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "d_too_large" number: 536870912 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "e_max" number: 536870911 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field: { name: "f_min" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+		}
+	`)
+
+	warnings := tmpl.Validate()
+	require.Len(t, warnings, 4)
+	require.Equal(t, "api.proto: test.Book.a_zero: field number 0 is below the minimum of 1", warnings[0].String())
+	require.Equal(t, "api.proto: test.Book.b_negative: field number -1 is below the minimum of 1", warnings[1].String())
+	require.Equal(t, "api.proto: test.Book.c_reserved: field number 19000 is within the range reserved for the "+
+		"implementation (19000 to 19999)", warnings[2].String())
+	require.Equal(t, "api.proto: test.Book.d_too_large: field number 536870912 exceeds the maximum of 536870911",
+		warnings[3].String())
+}
+
+func TestValidateJSONNameConflicts(t *testing.T) {
+	tmpl := newTestTemplate(t, `
+		name: "api.proto"
+		package: "test"
+		message_type: {
+			name: "Book"
+			field: { name: "book_id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32 }
+			field: { name: "bookId" number: 2 label: LABEL_OPTIONAL type: TYPE_INT32 }
+			field: { name: "title" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "bookId" }
+			field: { name: "isbn" number: 4 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 json_name: "shelf" }
+			field: { name: "shelf" number: 5 label: LABEL_OPTIONAL type: TYPE_STRING }
+			oneof_decl: { name: "code" }
+		}
+	`)
+
+	warnings := tmpl.Validate()
+	require.Len(t, warnings, 3)
+	require.Equal(t, `api.proto: test.Book.bookId: JSON name "bookId" is also the JSON name of field book_id`,
+		warnings[0].String())
+	require.Equal(t, `api.proto: test.Book.shelf: JSON name "shelf" is also the JSON name of field isbn`,
+		warnings[1].String())
+	require.Equal(t, `api.proto: test.Book.title: JSON name "bookId" is also the JSON name of field book_id`,
+		warnings[2].String())
+}
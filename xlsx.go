@@ -0,0 +1,238 @@
+package gendoc
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// xlsxModified is the modification time of the parts of XLSX workbooks, fixed so that the output is deterministic.
+var xlsxModified = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Styles of the cells of XLSX workbooks, indexes of the cellXfs of xlsxStyles.
+const (
+	xlsxStyleDefault = iota
+	xlsxStyleBold
+	xlsxStyleLink
+)
+
+type xlsxCell struct {
+	value  string
+	number bool
+	style  int
+	// link is the location (e.g. `'Messages'!A2`) the cell links to, if any.
+	link string
+}
+
+type xlsxSheet struct {
+	name string
+	rows [][]xlsxCell
+}
+
+// location returns the location of the first cell of the given (1-based) row, for hyperlinks.
+func (s *xlsxSheet) location(row int) string { return fmt.Sprintf("'%s'!A%d", s.name, row) }
+
+func (s *xlsxSheet) add(cells ...xlsxCell) { s.rows = append(s.rows, cells) }
+
+type xlsxRenderer struct{}
+
+// Apply renders an XLSX workbook with three sheets: the (non-internal) messages and their fields, the enums and their
+// values, and the methods of the services (RPCs). Every message, enum and service gets a bold row followed by a row
+// per member. Field, request and response types that are part of the template link to the rows of their types.
+func (r *xlsxRenderer) Apply(template *Template) ([]byte, error) {
+	messages := &xlsxSheet{name: "Messages"}
+	enums := &xlsxSheet{name: "Enums"}
+	rpcs := &xlsxSheet{name: "RPCs"}
+
+	// the rows of the types, counting the header rows, for linking to them
+	locations := make(map[string]string)
+	messageRow, enumRow := 2, 2
+	for _, file := range template.Files {
+		for _, msg := range file.VisibleMessages() {
+			locations[msg.FullName] = messages.location(messageRow)
+			messageRow += 1 + len(msg.allFields())
+		}
+		for _, enum := range file.Enums {
+			locations[enum.FullName] = enums.location(enumRow)
+			enumRow += 1 + len(enum.Values)
+		}
+	}
+	typeCell := func(fullType, text string) xlsxCell {
+		if location, ok := locations[fullType]; ok {
+			return xlsxCell{value: text, style: xlsxStyleLink, link: location}
+		}
+		return xlsxCell{value: text}
+	}
+	text := func(value string) xlsxCell { return xlsxCell{value: value} }
+	bold := func(value string) xlsxCell { return xlsxCell{value: value, style: xlsxStyleBold} }
+	deprecated := func(options map[string]interface{}) xlsxCell {
+		if isDeprecated(options) {
+			return text("yes")
+		}
+		return text("")
+	}
+
+	messages.add(bold("Message"), bold("Field"), bold("Number"), bold("Type"), bold("Label"), bold("Deprecated"),
+		bold("Description"))
+	enums.add(bold("Enum"), bold("Value"), bold("Number"), bold("Deprecated"), bold("Description"))
+	rpcs.add(bold("Service"), bold("Method"), bold("Request"), bold("Response"), bold("Deprecated"),
+		bold("Description"))
+
+	for _, file := range template.Files {
+		for _, msg := range file.VisibleMessages() {
+			messages.add(bold(msg.FullName), text(""), text(""), text(""), text(""), deprecated(msg.Options),
+				text(msg.Description))
+			for _, field := range msg.allFields() {
+				t := typeCell(field.FullType, field.TypeLabel())
+				if field.IsMap {
+					t = typeCell(field.MapValueType, fmt.Sprintf("map<%s, %s>", field.MapKeyType, field.MapValueType))
+				}
+				messages.add(text(msg.FullName), text(field.Name), xlsxCell{value: fmt.Sprint(field.Index), number: true},
+					t, text(field.Label), deprecated(field.Options), text(field.Description))
+			}
+		}
+
+		for _, enum := range file.Enums {
+			enums.add(bold(enum.FullName), text(""), text(""), deprecated(enum.Options), text(enum.Description))
+			for _, value := range enum.Values {
+				enums.add(text(enum.FullName), text(value.Name), xlsxCell{value: value.Number, number: true},
+					deprecated(value.Options), text(value.Description))
+			}
+		}
+
+		for _, service := range file.Services {
+			rpcs.add(bold(service.FullName), text(""), text(""), text(""), deprecated(service.Options),
+				text(service.Description))
+			for _, method := range service.Methods {
+				request := typeCell(method.RequestFullType, method.RequestLongType)
+				if method.RequestStreaming {
+					request.value = "stream " + request.value
+				}
+				response := typeCell(method.ResponseFullType, method.ResponseLongType)
+				if method.ResponseStreaming {
+					response.value = "stream " + response.value
+				}
+				rpcs.add(text(service.FullName), text(method.Name), request, response, deprecated(method.Options),
+					text(method.Description))
+			}
+		}
+	}
+
+	return writeXLSX(messages, enums, rpcs)
+}
+
+// writeXLSX packs the sheets into a workbook, with frozen header rows.
+func writeXLSX(sheets ...*xlsxSheet) ([]byte, error) {
+	var contentTypes, workbook, workbookRels strings.Builder
+	contentTypes.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	workbook.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	parts := make([][2]string, 0, len(sheets)+5)
+	for i, sheet := range sheets {
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" `+
+			`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xlsxEscape(sheet.name), i+1, i+1)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" `+
+			`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" `+
+			`Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+		parts = append(parts, [2]string{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), sheetXML(sheet)})
+	}
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" `+
+		`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`+
+		`</Relationships>`, len(sheets)+1)
+
+	parts = append([][2]string{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" ` +
+			`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" ` +
+			`Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"xl/styles.xml", xlsxStyles},
+	}, parts...)
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, part := range parts {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: part[0], Method: zip.Deflate, Modified: xlsxModified})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write([]byte(part[1])); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func sheetXML(sheet *xlsxSheet) string {
+	var b, links strings.Builder
+	b.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheetViews><sheetView workbookViewId="0">` +
+		`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>` +
+		`<sheetData>`)
+	for i, row := range sheet.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, cell := range row {
+			if cell.value == "" {
+				continue
+			}
+			ref := fmt.Sprintf("%c%d", 'A'+j, i+1)
+			style := ""
+			if cell.style != xlsxStyleDefault {
+				style = fmt.Sprintf(` s="%d"`, cell.style)
+			}
+			if cell.number {
+				fmt.Fprintf(&b, `<c r="%s"%s><v>%s</v></c>`, ref, style, xlsxEscape(cell.value))
+			} else {
+				fmt.Fprintf(&b, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style,
+					xlsxEscape(cell.value))
+			}
+			if cell.link != "" {
+				fmt.Fprintf(&links, `<hyperlink ref="%s" location="%s"/>`, ref, xlsxEscape(cell.link))
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData>`)
+	if links.Len() > 0 {
+		b.WriteString(`<hyperlinks>` + links.String() + `</hyperlinks>`)
+	}
+	b.WriteString(`</worksheet>`)
+	return b.String()
+}
+
+func xlsxEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// xlsxStyles defines the cell styles of XLSX workbooks: default, bold (headers and types) and links.
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="3"><font><sz val="11"/><name val="Calibri"/></font>` +
+	`<font><b/><sz val="11"/><name val="Calibri"/></font>` +
+	`<font><u/><sz val="11"/><color rgb="FF0563C1"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="0" fontId="2" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`</styleSheet>`